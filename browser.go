@@ -0,0 +1,118 @@
+package main
+
+import (
+	"fmt"
+	"os/exec"
+	"runtime"
+	"strings"
+)
+
+// goos is the platform to build commands for; tests change it to check other platforms
+var goos = runtime.GOOS
+
+// supportedBrowsers lists the browsers that can be selected with -browser
+var supportedBrowsers = []string{"chrome", "firefox"}
+
+// openCommand builds the command that opens url for an open mode run. first is
+// true for the first URL, which is the one opened in a new window if requested.
+func openCommand(opts OpenOptions, url string, first bool) (*exec.Cmd, error) {
+	if opts.Browser != "" {
+		return browserCommand(opts.Browser, opts.Profile, opts.NewWindow && first, url)
+	}
+	return openerCommand(opts.Opener, url)
+}
+
+// openerCommand builds the command that opens url in a browser
+func openerCommand(opener, url string) (*exec.Cmd, error) {
+	if args := strings.Fields(opener); len(args) > 0 {
+		return exec.Command(args[0], append(args[1:], url)...), nil
+	}
+
+	switch goos {
+	case "darwin":
+		return exec.Command("open", url), nil
+	case "windows":
+		// Not cmd's start, which would split the URL at each & in its query string
+		return exec.Command("rundll32", "url.dll,FileProtocolHandler", url), nil
+	case "linux", "freebsd", "openbsd", "netbsd":
+		return exec.Command("xdg-open", url), nil
+	default:
+		return nil, fmt.Errorf("don't know how to open URLs on %s, use -opener to set a command", goos)
+	}
+}
+
+// browserExecutable returns the command used to launch a browser on this platform
+func browserExecutable(browser string) (string, error) {
+	switch goos + "/" + browser {
+	case "darwin/chrome":
+		return "/Applications/Google Chrome.app/Contents/MacOS/Google Chrome", nil
+	case "darwin/firefox":
+		return "/Applications/Firefox.app/Contents/MacOS/firefox", nil
+	case "windows/chrome":
+		return "chrome", nil
+	case "windows/firefox":
+		return "firefox", nil
+	case "linux/chrome", "freebsd/chrome", "openbsd/chrome", "netbsd/chrome":
+		return "google-chrome", nil
+	case "linux/firefox", "freebsd/firefox", "openbsd/firefox", "netbsd/firefox":
+		return "firefox", nil
+	default:
+		return "", fmt.Errorf("don't know how to launch %s on %s, use -opener to set a command", browser, goos)
+	}
+}
+
+// browserCommand builds a command that opens url in the given browser, optionally
+// in a named profile (Chrome profile directory or Firefox profile name) and in a
+// new window. Later URLs sent to the same profile open as tabs in its most recent
+// window, so opening only the first URL with newWindow keeps a session together.
+func browserCommand(browser, profile string, newWindow bool, url string) (*exec.Cmd, error) {
+	executable, err := browserExecutable(browser)
+	if err != nil {
+		return nil, err
+	}
+
+	var args []string
+	switch browser {
+	case "chrome":
+		if profile != "" {
+			args = append(args, "--profile-directory="+profile)
+		}
+		if newWindow {
+			args = append(args, "--new-window")
+		}
+	case "firefox":
+		if profile != "" {
+			args = append(args, "-P", profile)
+		}
+		if newWindow {
+			args = append(args, "-new-window")
+		} else {
+			args = append(args, "-new-tab")
+		}
+	}
+	args = append(args, url)
+
+	if goos == "windows" {
+		// start looks browsers up in the App Paths registry, which plain exec
+		// doesn't. The empty argument is the window title.
+		cmdArgs := []string{"/c", "start", "", executable}
+		for _, arg := range args {
+			cmdArgs = append(cmdArgs, cmdEscape(arg))
+		}
+		return exec.Command("cmd", cmdArgs...), nil
+	}
+	return exec.Command(executable, args...), nil
+}
+
+// cmdEscape escapes the characters cmd.exe treats specially, such as the &
+// between query parameters, so cmd /c passes them through to the command
+func cmdEscape(arg string) string {
+	var b strings.Builder
+	for _, r := range arg {
+		if strings.ContainsRune("^&|<>()%!", r) {
+			b.WriteByte('^')
+		}
+		b.WriteRune(r)
+	}
+	return b.String()
+}
@@ -0,0 +1,41 @@
+package main
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestWindowsCommandsKeepQueryStrings(t *testing.T) {
+	defer func(saved string) { goos = saved }(goos)
+	goos = "windows"
+	url := "https://github.com/acme/widgets/pull/12/files?diff=split&w=1"
+
+	cmd, err := openerCommand("", url)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := []string{"rundll32", "url.dll,FileProtocolHandler", url}; !reflect.DeepEqual(cmd.Args, want) {
+		t.Errorf("opener args = %q, want %q", cmd.Args, want)
+	}
+
+	cmd, err = browserCommand("chrome", "Profile 1", true, url)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []string{"cmd", "/c", "start", "", "chrome", "--profile-directory=Profile 1", "--new-window", "https://github.com/acme/widgets/pull/12/files?diff=split^&w=1"}
+	if !reflect.DeepEqual(cmd.Args, want) {
+		t.Errorf("browser args = %q, want %q", cmd.Args, want)
+	}
+}
+
+func TestCmdEscape(t *testing.T) {
+	for in, want := range map[string]string{
+		"https://github.com/acme/widgets/pull/12": "https://github.com/acme/widgets/pull/12",
+		"a&b|c<d>e^f":   "a^&b^|c^<d^>e^^f",
+		"%USERPROFILE%": "^%USERPROFILE^%",
+	} {
+		if got := cmdEscape(in); got != want {
+			t.Errorf("cmdEscape(%q) = %q, want %q", in, got, want)
+		}
+	}
+}
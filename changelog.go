@@ -0,0 +1,137 @@
+package main
+
+import (
+	"fmt"
+	"io"
+	"regexp"
+	"slices"
+	"strings"
+	"time"
+	"unicode"
+	"unicode/utf8"
+)
+
+// conventionalTitle is a PR title in conventional-commit form, such as
+// "feat(api)!: drop v1 endpoints"
+type conventionalTitle struct {
+	Type     string // lower case, such as feat or fix
+	Scope    string
+	Breaking bool
+	Subject  string
+}
+
+// conventionalTitlePattern matches type, optional scope, optional breaking marker, and subject
+var conventionalTitlePattern = regexp.MustCompile(`^([A-Za-z]+)(?:\(([^)]*)\))?(!)?:\s*(.+)$`)
+
+// parseConventionalTitle parses a conventional-commit PR title. A subject
+// starting with "BREAKING CHANGE:" is breaking too, as in a commit footer.
+func parseConventionalTitle(title string) (conventionalTitle, bool) {
+	m := conventionalTitlePattern.FindStringSubmatch(strings.TrimSpace(title))
+	if m == nil {
+		return conventionalTitle{}, false
+	}
+	c := conventionalTitle{Type: strings.ToLower(m[1]), Scope: strings.TrimSpace(m[2]), Breaking: m[3] == "!", Subject: m[4]}
+	if rest, ok := strings.CutPrefix(c.Subject, "BREAKING CHANGE:"); ok {
+		c.Breaking = true
+		c.Subject = strings.TrimSpace(rest)
+	}
+	return c, true
+}
+
+// changelogSections are keep-a-changelog's headings in order
+var changelogSections = []string{"Added", "Changed", "Deprecated", "Removed", "Fixed", "Security"}
+
+// changelogTypes maps conventional-commit types to changelog headings. Types
+// not listed, such as docs, chore, ci, and test, are left out of the
+// changelog unless they're breaking, and titles without a type are Changed.
+var changelogTypes = map[string]string{
+	"feat":       "Added",
+	"feature":    "Added",
+	"perf":       "Changed",
+	"refactor":   "Changed",
+	"deprecate":  "Deprecated",
+	"deprecated": "Deprecated",
+	"remove":     "Removed",
+	"revert":     "Removed",
+	"fix":        "Fixed",
+	"security":   "Security",
+	"vuln":       "Security",
+}
+
+// changelogEntry returns the heading and line for the PR, or "" if it's left out
+func changelogEntry(pr PR) (string, string) {
+	for _, label := range pr.Labels {
+		if slices.Contains(releaseNotesSkipLabels, strings.ToLower(label)) {
+			return "", ""
+		}
+	}
+	link := fmt.Sprintf("([#%s](%s))", pr.Number, pr.URL)
+	c, ok := parseConventionalTitle(pr.Title)
+	if !ok {
+		return "Changed", fmt.Sprintf("- %s %s", capitalize(pr.Title), link)
+	}
+
+	heading, listed := changelogTypes[c.Type]
+	if c.Type == "fix" && strings.EqualFold(c.Scope, "security") {
+		heading = "Security"
+	}
+	switch {
+	case c.Breaking && !listed:
+		heading = "Changed"
+	case !listed:
+		return "", ""
+	}
+
+	line := "- "
+	if c.Breaking {
+		line += "**Breaking:** "
+	}
+	if c.Scope != "" {
+		line += "**" + c.Scope + ":** "
+	}
+	return heading, line + capitalize(c.Subject) + " " + link
+}
+
+// capitalize upper-cases the first letter of s
+func capitalize(s string) string {
+	if s == "" {
+		return s
+	}
+	r, size := utf8.DecodeRuneInString(s)
+	return string(unicode.ToUpper(r)) + s[size:]
+}
+
+// writeChangelog writes the PRs as a keep-a-changelog section for version,
+// released at until; an empty version is the Unreleased section. Breaking
+// changes come first within each heading.
+func writeChangelog(w io.Writer, prs []PR, version string, until time.Time) {
+	entries := make(map[string][]string)
+	breaking := make(map[string]int) // the number of breaking entries at the start of each heading
+	for _, pr := range prs {
+		heading, line := changelogEntry(pr)
+		if heading == "" {
+			continue
+		}
+		if strings.HasPrefix(line, "- **Breaking:**") {
+			entries[heading] = slices.Insert(entries[heading], breaking[heading], line)
+			breaking[heading]++
+		} else {
+			entries[heading] = append(entries[heading], line)
+		}
+	}
+
+	if version == "" {
+		fmt.Fprintln(w, "## [Unreleased]")
+	} else {
+		fmt.Fprintf(w, "## [%s] - %s\n", strings.TrimPrefix(version, "v"), until.Local().Format("2006-01-02"))
+	}
+	for _, heading := range changelogSections {
+		if len(entries[heading]) == 0 {
+			continue
+		}
+		fmt.Fprintf(w, "\n### %s\n", heading)
+		for _, line := range entries[heading] {
+			fmt.Fprintln(w, line)
+		}
+	}
+}
@@ -0,0 +1,69 @@
+package main
+
+import (
+	"bytes"
+	"testing"
+	"time"
+)
+
+func TestParseConventionalTitle(t *testing.T) {
+	for _, tc := range []struct {
+		title string
+		want  conventionalTitle
+		ok    bool
+	}{
+		{"feat: add retries", conventionalTitle{Type: "feat", Subject: "add retries"}, true},
+		{"Fix(api)!: drop v1 endpoints", conventionalTitle{Type: "fix", Scope: "api", Breaking: true, Subject: "drop v1 endpoints"}, true},
+		{"chore(deps): BREAKING CHANGE: require Go 1.22", conventionalTitle{Type: "chore", Scope: "deps", Breaking: true, Subject: "require Go 1.22"}, true},
+		{"Add retries", conventionalTitle{}, false},
+		{"v2: the sequel", conventionalTitle{}, false},
+	} {
+		got, ok := parseConventionalTitle(tc.title)
+		if got != tc.want || ok != tc.ok {
+			t.Errorf("parseConventionalTitle(%q) = %+v, %v, want %+v, %v", tc.title, got, ok, tc.want, tc.ok)
+		}
+	}
+}
+
+func TestWriteChangelog(t *testing.T) {
+	pr := func(number, title string, labels ...string) PR {
+		return PR{Number: number, Title: title, URL: "https://github.com/acme/widgets/pull/" + number, Labels: labels}
+	}
+	prs := []PR{
+		pr("1", "feat(cli): add -retries flag"),
+		pr("2", "fix: handle empty repos"),
+		pr("3", "docs: explain profiles"),
+		pr("4", "refactor(api)!: rename Client.Do to Client.Send"),
+		pr("5", "Improve error messages"),
+		pr("6", "fix(security): escape author names"),
+		pr("7", "feat: secret feature", "skip-changelog"),
+		pr("8", "perf: cache lookups"),
+	}
+	var b bytes.Buffer
+	writeChangelog(&b, prs, "v1.3.0", time.Date(2024, 3, 10, 12, 0, 0, 0, time.Local))
+	want := `## [1.3.0] - 2024-03-10
+
+### Added
+- **cli:** Add -retries flag ([#1](https://github.com/acme/widgets/pull/1))
+
+### Changed
+- **Breaking:** **api:** Rename Client.Do to Client.Send ([#4](https://github.com/acme/widgets/pull/4))
+- Improve error messages ([#5](https://github.com/acme/widgets/pull/5))
+- Cache lookups ([#8](https://github.com/acme/widgets/pull/8))
+
+### Fixed
+- Handle empty repos ([#2](https://github.com/acme/widgets/pull/2))
+
+### Security
+- **security:** Escape author names ([#6](https://github.com/acme/widgets/pull/6))
+`
+	if b.String() != want {
+		t.Errorf("changelog:\n%s\nwant:\n%s", b.String(), want)
+	}
+
+	b.Reset()
+	writeChangelog(&b, nil, "", time.Now())
+	if b.String() != "## [Unreleased]\n" {
+		t.Errorf("unreleased changelog = %q", b.String())
+	}
+}
@@ -0,0 +1,240 @@
+package main
+
+import (
+	"fmt"
+	"html"
+	"image"
+	"image/color"
+	"image/draw"
+	"image/png"
+	"io"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+
+	"golang.org/x/image/font"
+	"golang.org/x/image/font/basicfont"
+	"golang.org/x/image/math/fixed"
+)
+
+// barChart is a bar chart of counts, rendered to SVG or PNG
+type barChart struct {
+	// Name is used for the chart's file name, such as merges_per_week
+	Name   string
+	Title  string
+	Labels []string
+	Values []int
+	// Horizontal draws one row per label, which suits long labels such as
+	// author names; otherwise bars are columns, which suits time series
+	Horizontal bool
+}
+
+// Chart layout, in pixels
+const (
+	chartWidth     = 640
+	chartBarHeight = 22  // per row in horizontal charts
+	chartHeight    = 240 // plot height of vertical charts
+	chartLabelSize = 140 // room for labels left of horizontal bars
+	chartMargin    = 30
+)
+
+var (
+	chartBarColor  = color.RGBA{0x82, 0x50, 0xdf, 0xff}
+	chartAxisColor = color.RGBA{0xd0, 0xd7, 0xde, 0xff}
+)
+
+// chartRect is a bar, with a tooltip for SVG
+type chartRect struct {
+	X, Y, W, H float64
+	Tooltip    string
+}
+
+// chartText is a label; Anchor is start, middle, or end, as in SVG
+type chartText struct {
+	X, Y   float64
+	Anchor string
+	Bold   bool
+	Text   string
+}
+
+// chartLayout is a chart's shapes, shared by the SVG and PNG renderers
+type chartLayout struct {
+	Width, Height int
+	Rects         []chartRect
+	Texts         []chartText
+	// Axis is the baseline of vertical charts, from (X1, Y) to (X2, Y)
+	Axis *struct{ X1, X2, Y float64 }
+}
+
+// maxValue returns the largest value, at least 1 so bars can be scaled by it
+func (c barChart) maxValue() int {
+	highest := 1
+	for _, v := range c.Values {
+		highest = max(highest, v)
+	}
+	return highest
+}
+
+// layout places the chart's bars and labels
+func (c barChart) layout() chartLayout {
+	l := chartLayout{Width: chartWidth}
+	l.Texts = append(l.Texts, chartText{X: chartMargin / 2, Y: 18, Anchor: "start", Bold: true, Text: c.Title})
+
+	if c.Horizontal {
+		l.Height = chartMargin*2 + chartBarHeight*len(c.Labels)
+		plotWidth := float64(chartWidth - chartLabelSize - chartMargin*2)
+		for i, label := range c.Labels {
+			y := float64(chartMargin + i*chartBarHeight)
+			width := plotWidth * float64(c.Values[i]) / float64(c.maxValue())
+			l.Rects = append(l.Rects, chartRect{X: chartLabelSize, Y: y + 3, W: width, H: chartBarHeight - 6, Tooltip: fmt.Sprintf("%s: %d", label, c.Values[i])})
+			l.Texts = append(l.Texts,
+				chartText{X: chartLabelSize - 6, Y: y + 15, Anchor: "end", Text: truncateLabel(label, 18)},
+				chartText{X: chartLabelSize + width + 4, Y: y + 15, Anchor: "start", Text: fmt.Sprint(c.Values[i])},
+			)
+		}
+		return l
+	}
+
+	l.Height = chartHeight + chartMargin*3
+	baseline := float64(chartMargin + chartHeight)
+	l.Axis = &struct{ X1, X2, Y float64 }{chartMargin, chartWidth - chartMargin, baseline}
+	slot := float64(chartWidth-chartMargin*2) / float64(max(len(c.Labels), 1))
+	// Label every bar unless they would overlap, then every nth
+	labelEvery := max(1, int(70/slot)+1)
+	for i, label := range c.Labels {
+		x := float64(chartMargin) + slot*float64(i)
+		height := float64(chartHeight) * float64(c.Values[i]) / float64(c.maxValue())
+		l.Rects = append(l.Rects, chartRect{X: x + slot*0.1, Y: baseline - height, W: slot * 0.8, H: height, Tooltip: fmt.Sprintf("%s: %d", label, c.Values[i])})
+		if c.Values[i] > 0 && slot >= 18 {
+			l.Texts = append(l.Texts, chartText{X: x + slot/2, Y: baseline - height - 4, Anchor: "middle", Text: fmt.Sprint(c.Values[i])})
+		}
+		if i%labelEvery == 0 {
+			l.Texts = append(l.Texts, chartText{X: x + slot/2, Y: baseline + 16, Anchor: "middle", Text: label})
+		}
+	}
+	return l
+}
+
+// svg renders the chart as a standalone SVG document
+func (c barChart) svg() string {
+	l := c.layout()
+	var b strings.Builder
+	fmt.Fprintf(&b, `<svg xmlns="http://www.w3.org/2000/svg" viewBox="0 0 %d %d" width="%d" height="%d" font-family="-apple-system, Segoe UI, Helvetica, Arial, sans-serif" font-size="12">`+"\n", l.Width, l.Height, l.Width, l.Height)
+	b.WriteString(`<rect width="100%" height="100%" fill="#ffffff"/>` + "\n")
+	if l.Axis != nil {
+		fmt.Fprintf(&b, `<line x1="%.1f" y1="%.1f" x2="%.1f" y2="%.1f" stroke="%s"/>`+"\n", l.Axis.X1, l.Axis.Y, l.Axis.X2, l.Axis.Y, hexColor(chartAxisColor))
+	}
+	for _, r := range l.Rects {
+		fmt.Fprintf(&b, `<rect x="%.1f" y="%.1f" width="%.1f" height="%.1f" fill="%s"><title>%s</title></rect>`+"\n", r.X, r.Y, r.W, r.H, hexColor(chartBarColor), html.EscapeString(r.Tooltip))
+	}
+	for _, t := range l.Texts {
+		weight := ""
+		if t.Bold {
+			weight = ` font-size="14" font-weight="bold"`
+		}
+		fmt.Fprintf(&b, `<text x="%.1f" y="%.1f" text-anchor="%s"%s>%s</text>`+"\n", t.X, t.Y, t.Anchor, weight, html.EscapeString(t.Text))
+	}
+	b.WriteString("</svg>\n")
+	return b.String()
+}
+
+// writePNG renders the chart as a PNG image. Labels use a basic bitmap font,
+// so characters outside ASCII show as boxes.
+func (c barChart) writePNG(w io.Writer) error {
+	l := c.layout()
+	img := image.NewRGBA(image.Rect(0, 0, l.Width, l.Height))
+	draw.Draw(img, img.Bounds(), image.White, image.Point{}, draw.Src)
+
+	if l.Axis != nil {
+		line := image.Rect(int(l.Axis.X1), int(l.Axis.Y), int(l.Axis.X2), int(l.Axis.Y)+1)
+		draw.Draw(img, line, image.NewUniform(chartAxisColor), image.Point{}, draw.Src)
+	}
+	for _, r := range l.Rects {
+		bar := image.Rect(int(r.X), int(r.Y), int(r.X+r.W+0.5), int(r.Y+r.H+0.5))
+		draw.Draw(img, bar, image.NewUniform(chartBarColor), image.Point{}, draw.Src)
+	}
+	drawer := &font.Drawer{Dst: img, Src: image.Black, Face: basicfont.Face7x13}
+	for _, t := range l.Texts {
+		x := t.X
+		switch width := float64(drawer.MeasureString(t.Text).Round()); t.Anchor {
+		case "middle":
+			x -= width / 2
+		case "end":
+			x -= width
+		}
+		drawer.Dot = fixed.P(int(x), int(t.Y))
+		drawer.DrawString(t.Text)
+		if t.Bold {
+			// The bitmap font has no bold face, so draw it again one pixel over
+			drawer.Dot = fixed.P(int(x)+1, int(t.Y))
+			drawer.DrawString(t.Text)
+		}
+	}
+	return png.Encode(w, img)
+}
+
+// hexColor formats c as #rrggbb
+func hexColor(c color.RGBA) string {
+	return fmt.Sprintf("#%02x%02x%02x", c.R, c.G, c.B)
+}
+
+// truncateLabel shortens label to at most n characters, marking it with an ellipsis
+func truncateLabel(label string, n int) string {
+	runes := []rune(label)
+	if len(runes) <= n {
+		return label
+	}
+	return string(runes[:n-1]) + "…"
+}
+
+// chartFormats are the file formats -chart-format accepts
+var chartFormats = []string{"svg", "png"}
+
+// lookupChartFormat returns the chart file format named by format, svg if it's empty
+func lookupChartFormat(format string) (string, error) {
+	if format == "" {
+		return "svg", nil
+	}
+	if !slices.Contains(chartFormats, format) {
+		return "", fmt.Errorf("unknown chart format %q, must be one of %s", format, strings.Join(chartFormats, ", "))
+	}
+	return format, nil
+}
+
+// saveCharts writes each chart to dir as <name>.<format>, returning the files written
+func saveCharts(charts []barChart, dir, format string) ([]string, error) {
+	if err := prepareOutputDir(dir); err != nil {
+		return nil, err
+	}
+	var files []string
+	for _, chart := range charts {
+		path := filepath.Join(dir, chart.Name+"."+format)
+		if err := saveChart(chart, path, format); err != nil {
+			return files, fmt.Errorf("error saving chart %s: %v", path, err)
+		}
+		files = append(files, path)
+	}
+	return files, nil
+}
+
+// saveChart writes one chart to path in format
+func saveChart(chart barChart, path, format string) error {
+	file, err := os.Create(path)
+	if err != nil {
+		return err
+	}
+	switch format {
+	case "svg":
+		_, err = io.WriteString(file, chart.svg())
+	case "png":
+		err = chart.writePNG(file)
+	default:
+		_, err = lookupChartFormat(format)
+	}
+	if err != nil {
+		file.Close()
+		return err
+	}
+	return file.Close()
+}
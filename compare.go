@@ -0,0 +1,102 @@
+package main
+
+import (
+	"fmt"
+	"io"
+	"text/tabwriter"
+	"time"
+)
+
+// comparisonRange returns the range -compare-to names for the range from
+// since until until: "previous" for the range of the same length just before
+// it, or a start date for a range of the same length starting then
+func comparisonRange(compareTo string, since, until time.Time) (time.Time, time.Time, error) {
+	length := until.Sub(since)
+	if compareTo == "previous" {
+		return since.Add(-length), since, nil
+	}
+	start, err := parseSinceDate(compareTo)
+	if err != nil {
+		return time.Time{}, time.Time{}, fmt.Errorf("invalid -compare-to %q: must be previous or a start date: %v", compareTo, err)
+	}
+	return start, start.Add(length), nil
+}
+
+// mergedIn returns the PRs merged at or after since and before until. The
+// search only narrows merges down to the day, so a comparison range ending
+// on the day the main one starts would otherwise count that day twice.
+func mergedIn(prs []PR, since, until time.Time) []PR {
+	var in []PR
+	for _, pr := range prs {
+		merged, err := time.Parse(time.RFC3339, pr.MergedAt)
+		if err == nil && !merged.Before(since) && merged.Before(until) {
+			in = append(in, pr)
+		}
+	}
+	return in
+}
+
+// periodSummary is the headline numbers for a date range
+type periodSummary struct {
+	Since, Until time.Time
+	PRs          int
+	Authors      int
+	// MedianTimeToMerge is meaningless if PRs is 0
+	MedianTimeToMerge time.Duration
+}
+
+// summarizePeriod returns the headline numbers for the PRs merged from since until until
+func summarizePeriod(prs []PR, since, until time.Time) periodSummary {
+	authors := make(map[string]bool)
+	var durations []time.Duration
+	for _, pr := range prs {
+		authors[pr.Author] = true
+		if d, err := pr.TimeToMerge(); err == nil {
+			durations = append(durations, d)
+		}
+	}
+	return periodSummary{
+		Since:             since,
+		Until:             until,
+		PRs:               len(prs),
+		Authors:           len(authors),
+		MedianTimeToMerge: newMergeTimes("all", durations).P50,
+	}
+}
+
+// formatDelta formats the change from before to after with this many
+// decimals, and the percentage change when before isn't 0
+func formatDelta(before, after float64, decimals int) string {
+	delta := fmt.Sprintf("%+.*f", decimals, after-before)
+	if before == 0 {
+		return delta
+	}
+	return fmt.Sprintf("%s (%+.0f%%)", delta, 100*(after-before)/before)
+}
+
+// printComparison writes the headline numbers for the two periods and the
+// change from the earlier one, with times in unit
+func printComparison(w io.Writer, current, previous periodSummary, unit string) {
+	format := func(p periodSummary) string {
+		return fmt.Sprintf("%s to %s", p.Since.Format("2006-01-02"), p.Until.Format("2006-01-02"))
+	}
+	fmt.Fprintf(w, "\nCompared to %s\n", format(previous))
+	tw := tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
+	fmt.Fprintf(tw, "\t%s\t%s\tChange\n", format(current), format(previous))
+	fmt.Fprintf(tw, "Merged PRs\t%d\t%d\t%s\n", current.PRs, previous.PRs, formatDelta(float64(previous.PRs), float64(current.PRs), 0))
+	fmt.Fprintf(tw, "Authors\t%d\t%d\t%s\n", current.Authors, previous.Authors, formatDelta(float64(previous.Authors), float64(current.Authors), 0))
+
+	ttm := func(p periodSummary) string {
+		if p.PRs == 0 {
+			return "-"
+		}
+		return formatDuration(p.MedianTimeToMerge, unit)
+	}
+	change := "-"
+	if current.PRs > 0 && previous.PRs > 0 {
+		perUnit := float64(timeToMergeUnits[unit])
+		change = formatDelta(float64(previous.MedianTimeToMerge)/perUnit, float64(current.MedianTimeToMerge)/perUnit, 2)
+	}
+	fmt.Fprintf(tw, "Median time to merge (%s)\t%s\t%s\t%s\n", unit, ttm(current), ttm(previous), change)
+	tw.Flush()
+}
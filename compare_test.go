@@ -0,0 +1,71 @@
+package main
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestComparisonRange(t *testing.T) {
+	since := time.Date(2024, 3, 1, 0, 0, 0, 0, time.UTC)
+	until := time.Date(2024, 3, 15, 0, 0, 0, 0, time.UTC)
+	start, end, err := comparisonRange("previous", since, until)
+	if err != nil || !start.Equal(time.Date(2024, 2, 16, 0, 0, 0, 0, time.UTC)) || !end.Equal(since) {
+		t.Errorf("previous = %v to %v, %v", start, end, err)
+	}
+	start, end, err = comparisonRange("2023-03-01", since, until)
+	if err != nil || !start.Equal(time.Date(2023, 3, 1, 0, 0, 0, 0, time.UTC)) || !end.Equal(time.Date(2023, 3, 15, 0, 0, 0, 0, time.UTC)) {
+		t.Errorf("2023-03-01 = %v to %v, %v", start, end, err)
+	}
+	if _, _, err := comparisonRange("last year", since, until); err == nil {
+		t.Error("last year accepted")
+	}
+}
+
+func TestFormatDelta(t *testing.T) {
+	for _, tc := range []struct {
+		before, after float64
+		decimals      int
+		want          string
+	}{
+		{10, 15, 0, "+5 (+50%)"},
+		{8, 6, 0, "-2 (-25%)"},
+		{0, 3, 0, "+3"},
+		{2, 1.5, 2, "-0.50 (-25%)"},
+	} {
+		if got := formatDelta(tc.before, tc.after, tc.decimals); got != tc.want {
+			t.Errorf("formatDelta(%v, %v) = %q, want %q", tc.before, tc.after, got, tc.want)
+		}
+	}
+}
+
+func TestRunStatsModeCompareTo(t *testing.T) {
+	// Three PRs by two authors in the last week, one the week before, and one on the boundary day
+	prs := makePRs(daysAgo(5), time.Hour, 3)
+	prs[0].Author, prs[1].Author, prs[2].Author = "alice", "bob", "alice"
+	earlier := makePRs(daysAgo(10), time.Hour, 1)
+	earlier[0].Number, earlier[0].URL, earlier[0].Author = "100", "https://github.com/acme/widgets/pull/100", "carol"
+	runner := &fakeRunner{prs: append(prs, earlier...)}
+	var out bytes.Buffer
+
+	opts := StatsOptions{List: testOptions(daysAgo(7), runner), Repos: []string{"acme/widgets"}, CompareTo: "previous", Output: &out}
+	opts.List.Until = daysAgo(0)
+	if err := runStatsMode(opts); err != nil {
+		t.Fatal(err)
+	}
+	for _, want := range []string{
+		"Compared to " + daysAgo(14).Format("2006-01-02") + " to " + daysAgo(7).Format("2006-01-02"),
+		"+2 (+200%)",
+		"+1 (+100%)",
+	} {
+		if !strings.Contains(out.String(), want) {
+			t.Errorf("comparison missing %q:\n%s", want, out.String())
+		}
+	}
+	for _, line := range strings.Split(out.String(), "\n") {
+		if fields := strings.Fields(line); len(fields) > 3 && fields[0] == "Merged" && (fields[2] != "3" || fields[3] != "1") {
+			t.Errorf("merged PRs = %v, want 3 then 1", fields)
+		}
+	}
+}
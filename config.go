@@ -0,0 +1,109 @@
+package main
+
+import (
+	"encoding/json"
+	"flag"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+)
+
+// Config is the optional configuration file. Profiles are named sets of flag
+// values, keyed by long flag name, for example:
+//
+//	{
+//	  "outDir": "~/reports/prs",
+//	  "profiles": {
+//	    "weekly-payments": {
+//	      "mode": "list",
+//	      "repo": "acme/payments",
+//	      "since": "7d",
+//	      "search": "label:payments",
+//	      "fields": "comments,reviewComments"
+//	    }
+//	  }
+//	}
+type Config struct {
+	// OutDir is the default directory list mode saves results in
+	OutDir   string                    `json:"outDir"`
+	Profiles map[string]map[string]any `json:"profiles"`
+}
+
+// defaultConfigPath returns the config file location, e.g. ~/.config/github-pr-grabber/config.json
+func defaultConfigPath() string {
+	dir, err := os.UserConfigDir()
+	if err != nil {
+		return ""
+	}
+	return filepath.Join(dir, "github-pr-grabber", "config.json")
+}
+
+// loadConfig reads the config file at path
+func loadConfig(path string) (*Config, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("error reading config file: %v", err)
+	}
+
+	var config Config
+	if err := json.Unmarshal(data, &config); err != nil {
+		return nil, fmt.Errorf("error parsing config file %s: %v", path, err)
+	}
+	return &config, nil
+}
+
+// loadStartupConfig loads the config file at path for a run. The file is
+// optional unless a profile is requested from it, so a missing one returns
+// nil. With lenient set, a file that can't be loaded also returns nil, for
+// doctor mode to report.
+func loadStartupConfig(path string, required, lenient bool) (*Config, error) {
+	if _, err := os.Stat(path); err != nil && !required {
+		return nil, nil
+	}
+	config, err := loadConfig(path)
+	if err != nil && lenient {
+		return nil, nil
+	}
+	return config, err
+}
+
+// applyProfile sets flags in flags, normally flag.CommandLine, from the named
+// profile. Flags given explicitly on the command line take precedence over the profile.
+func applyProfile(flags *flag.FlagSet, config *Config, name string) error {
+	profile, ok := config.Profiles[name]
+	if !ok {
+		var names []string
+		for n := range config.Profiles {
+			names = append(names, n)
+		}
+		sort.Strings(names)
+		return fmt.Errorf("profile %q not found (available: %s)", name, strings.Join(names, ", "))
+	}
+
+	explicit := make(map[string]bool)
+	flags.Visit(func(f *flag.Flag) {
+		explicit[f.Name] = true
+	})
+
+	for key, value := range profile {
+		if flags.Lookup(key) == nil {
+			return fmt.Errorf("profile %q sets unknown flag %q", name, key)
+		}
+		if explicit[key] {
+			continue
+		}
+		// A list sets a repeatable flag, such as post-header, once per value
+		values, ok := value.([]any)
+		if !ok {
+			values = []any{value}
+		}
+		for _, v := range values {
+			if err := flags.Set(key, fmt.Sprint(v)); err != nil {
+				return fmt.Errorf("profile %q has invalid value for %q: %v", name, key, err)
+			}
+		}
+	}
+	return nil
+}
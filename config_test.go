@@ -0,0 +1,94 @@
+package main
+
+import (
+	"flag"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestApplyProfile(t *testing.T) {
+	config := &Config{Profiles: map[string]map[string]any{
+		"weekly": {"repo": "acme/payments", "since": "7d", "limit": 50, "post-header": []any{"X-Team: payments", "X-Env: prod"}},
+		"typo":   {"repo": "acme/payments", "sinec": "7d"},
+		"bad":    {"limit": "lots"},
+	}}
+	tests := []struct {
+		name, profile string
+		args          []string
+		want          map[string]string
+		wantErr       string
+	}{
+		{name: "sets flags", profile: "weekly", want: map[string]string{"repo": "acme/payments", "since": "7d", "limit": "50"}},
+		{name: "command line wins", profile: "weekly", args: []string{"-since", "30d", "-limit=0"}, want: map[string]string{"repo": "acme/payments", "since": "30d", "limit": "0"}},
+		{name: "list sets a repeatable flag once per value", profile: "weekly", want: map[string]string{"post-header": "X-Env, X-Team"}},
+		{name: "unknown profile", profile: "monthly", wantErr: `profile "monthly" not found (available: bad, typo, weekly)`},
+		{name: "unknown key", profile: "typo", wantErr: `profile "typo" sets unknown flag "sinec"`},
+		{name: "invalid value", profile: "bad", wantErr: `profile "bad" has invalid value for "limit"`},
+	}
+	for _, tt := range tests {
+		flags := flag.NewFlagSet("test", flag.ContinueOnError)
+		flags.String("repo", "", "")
+		flags.String("since", "", "")
+		flags.Int("limit", 0, "")
+		flags.Var(make(headerFlags), "post-header", "")
+		if err := flags.Parse(tt.args); err != nil {
+			t.Fatal(err)
+		}
+
+		err := applyProfile(flags, config, tt.profile)
+		if tt.wantErr != "" {
+			if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+				t.Errorf("%s: err = %v, want %q", tt.name, err, tt.wantErr)
+			}
+			continue
+		}
+		if err != nil {
+			t.Fatalf("%s: %v", tt.name, err)
+		}
+		for name, want := range tt.want {
+			if got := flags.Lookup(name).Value.String(); got != want {
+				t.Errorf("%s: -%s = %q, want %q", tt.name, name, got, want)
+			}
+		}
+	}
+}
+
+func TestLoadStartupConfig(t *testing.T) {
+	dir := t.TempDir()
+	valid := filepath.Join(dir, "valid.json")
+	invalid := filepath.Join(dir, "invalid.json")
+	missing := filepath.Join(dir, "missing.json")
+	for path, data := range map[string]string{valid: `{"outDir": "reports", "profiles": {"weekly": {"since": "7d"}}}`, invalid: `{"profiles": `} {
+		if err := os.WriteFile(path, []byte(data), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	tests := []struct {
+		name              string
+		path              string
+		required, lenient bool
+		wantConfig        bool
+		wantErr           string
+	}{
+		{"valid file", valid, false, false, true, ""},
+		{"missing optional file", missing, false, false, false, ""},
+		{"missing file a profile needs", missing, true, false, false, "error reading config file"},
+		{"invalid file", invalid, false, false, false, "error parsing config file"},
+		{"invalid file for doctor", invalid, false, true, false, ""},
+	}
+	for _, tt := range tests {
+		config, err := loadStartupConfig(tt.path, tt.required, tt.lenient)
+		if tt.wantErr == "" && err != nil || tt.wantErr != "" && (err == nil || !strings.Contains(err.Error(), tt.wantErr)) {
+			t.Errorf("%s: err = %v, want %q", tt.name, err, tt.wantErr)
+		}
+		if (config != nil) != tt.wantConfig {
+			t.Errorf("%s: config = %+v, want one: %v", tt.name, config, tt.wantConfig)
+		}
+	}
+	if config, _ := loadStartupConfig(valid, false, false); config.OutDir != "reports" || config.Profiles["weekly"]["since"] != "7d" {
+		t.Errorf("loaded %+v", config)
+	}
+}
@@ -1,17 +1,21 @@
 package main
 
 import (
-	"bufio"
+	"bytes"
 	"encoding/csv"
 	"fmt"
 	"io"
+	"net/url"
 	"os"
+	"regexp"
 	"strings"
 )
 
 // PRURL represents a PR URL with its metadata
 type PRURL struct {
-	URL string
+	URL   string
+	Title string // empty if the CSV has no title column
+	Row   int    // 1-based data row in the CSV file, not counting the header
 }
 
 // CSVFormat represents the detected format of the CSV file
@@ -20,6 +24,7 @@ type CSVFormat struct {
 	OwnerColumn    int // -1 if not found
 	RepoColumn     int // -1 if not found
 	PRNumberColumn int // -1 if not found
+	TitleColumn    int // -1 if not found
 }
 
 // detectCSVFormat analyzes the CSV headers to determine which columns contain relevant information
@@ -29,6 +34,7 @@ func detectCSVFormat(headers []string) CSVFormat {
 		OwnerColumn:    -1,
 		RepoColumn:     -1,
 		PRNumberColumn: -1,
+		TitleColumn:    -1,
 	}
 
 	for i, header := range headers {
@@ -42,6 +48,8 @@ func detectCSVFormat(headers []string) CSVFormat {
 			format.RepoColumn = i
 		case "pr", "pr number", "pull request", "pull request number":
 			format.PRNumberColumn = i
+		case "title", "pr title", "pull request title":
+			format.TitleColumn = i
 		}
 	}
 
@@ -53,19 +61,10 @@ func buildGitHubURL(owner, repo, prNumber string) string {
 	return fmt.Sprintf("https://github.com/%s/%s/pull/%s", owner, repo, prNumber)
 }
 
-// detectDelimiter tries to determine if the file uses tabs or commas as delimiters
-func detectDelimiter(file *os.File) (rune, error) {
-	// Read the first line
-	reader := bufio.NewReader(file)
-	firstLine, err := reader.ReadString('\n')
-	if err != nil && err != io.EOF {
-		return 0, fmt.Errorf("error reading first line: %v", err)
-	}
-
-	// Reset file position for subsequent reads
-	if _, err := file.Seek(0, 0); err != nil {
-		return 0, fmt.Errorf("error resetting file position: %v", err)
-	}
+// detectDelimiter tries to determine if the data uses tabs or commas as delimiters
+func detectDelimiter(data []byte) rune {
+	// Look at the first line only
+	firstLine, _, _ := strings.Cut(string(data), "\n")
 
 	// Count tabs and commas
 	tabCount := strings.Count(firstLine, "\t")
@@ -73,53 +72,64 @@ func detectDelimiter(file *os.File) (rune, error) {
 
 	// If we have more tabs than commas, use tab as delimiter
 	if tabCount > commaCount {
-		return '\t', nil
+		return '\t'
 	}
 	// Otherwise use comma (even if counts are equal, comma is more common)
-	return ',', nil
+	return ','
 }
 
 // ParsePRURLsFromCSV reads a CSV file and returns a slice of PR URLs
 // The function detects the CSV format by analyzing headers and can handle:
 // 1. A direct URL column
 // 2. Separate owner, repo, and PR number columns
-// The file can be either tab or comma delimited
+// 3. No recognizable header, in which case any http(s) URLs on each line are used,
+// so plain URL lists and header-less lines (e.g. from grep) also work
+// The file can be either tab or comma delimited. A csvFile of "-" reads from stdin.
 func ParsePRURLsFromCSV(csvFile string) ([]PRURL, error) {
-	file, err := os.Open(csvFile)
-	if err != nil {
-		return nil, fmt.Errorf("error opening CSV file: %v", err)
+	var data []byte
+	var err error
+	if csvFile == "-" {
+		data, err = io.ReadAll(os.Stdin)
+	} else {
+		data, err = os.ReadFile(csvFile)
 	}
-	defer file.Close()
-
-	// Detect the delimiter
-	delimiter, err := detectDelimiter(file)
 	if err != nil {
-		return nil, fmt.Errorf("error detecting delimiter: %v", err)
+		return nil, fmt.Errorf("error opening CSV file: %v", err)
 	}
 
-	reader := csv.NewReader(file)
-	reader.Comma = delimiter
+	reader := csv.NewReader(bytes.NewReader(data))
+	reader.Comma = detectDelimiter(data)
+	// Lines piped in through filters like grep don't always have the same number of fields
+	reader.FieldsPerRecord = -1
 
 	records, err := reader.ReadAll()
 	if err != nil {
 		return nil, fmt.Errorf("error reading CSV file: %v", err)
 	}
 
-	if len(records) < 2 {
-		return nil, fmt.Errorf("CSV file must have at least a header row and one data row")
+	if len(records) == 0 {
+		return nil, fmt.Errorf("CSV file is empty")
 	}
 
 	// Detect CSV format from headers
 	format := detectCSVFormat(records[0])
 
-	// Validate that we have either a URL column or the necessary columns to build a URL
+	// Without a URL column or the columns needed to build one, fall back to finding URLs on each line
 	if format.URLColumn == -1 && (format.OwnerColumn == -1 || format.RepoColumn == -1 || format.PRNumberColumn == -1) {
-		return nil, fmt.Errorf("CSV must have either a URL column or owner, repo, and PR number columns")
+		prURLs := findURLsInRecords(records)
+		if len(prURLs) == 0 {
+			return nil, fmt.Errorf("CSV must have either a URL column or owner, repo, and PR number columns, or contain URLs")
+		}
+		return prURLs, nil
+	}
+
+	if len(records) < 2 {
+		return nil, fmt.Errorf("CSV file must have at least a header row and one data row")
 	}
 
 	var prURLs []PRURL
 	// Process data rows (skip header)
-	for _, record := range records[1:] {
+	for i, record := range records[1:] {
 		var url string
 		if format.URLColumn != -1 {
 			// Use direct URL if available
@@ -142,8 +152,79 @@ func ParsePRURLsFromCSV(csvFile string) ([]PRURL, error) {
 			continue
 		}
 
-		prURLs = append(prURLs, PRURL{URL: url})
+		var title string
+		if format.TitleColumn != -1 && format.TitleColumn < len(record) {
+			title = strings.TrimSpace(record[format.TitleColumn])
+		}
+
+		prURLs = append(prURLs, PRURL{URL: url, Title: title, Row: i + 1})
 	}
 
 	return prURLs, nil
 }
+
+// findURLsInRecords returns the first http(s) URL in each record. Rows are
+// numbered from the first line since there is no header.
+func findURLsInRecords(records [][]string) []PRURL {
+	var prURLs []PRURL
+	for i, record := range records {
+		for _, field := range record {
+			field = strings.TrimSpace(field)
+			if strings.HasPrefix(field, "https://") || strings.HasPrefix(field, "http://") {
+				prURLs = append(prURLs, PRURL{URL: field, Row: i + 1})
+				break
+			}
+		}
+	}
+	return prURLs
+}
+
+// prURLPattern matches the path of a PR URL, optionally followed by a tab like /files
+var prURLPattern = regexp.MustCompile(`^/[^/]+/[^/]+/pull/[0-9]+(/[a-z]+)?/?$`)
+
+// validatePRURL checks that a URL is a well-formed GitHub PR URL. Any host is
+// accepted so GitHub Enterprise URLs work.
+func validatePRURL(prURL string) error {
+	u, err := url.Parse(prURL)
+	if err != nil {
+		return fmt.Errorf("invalid URL: %v", err)
+	}
+	if u.Scheme != "https" && u.Scheme != "http" {
+		return fmt.Errorf("not an http(s) URL")
+	}
+	if u.Host == "" || !prURLPattern.MatchString(u.Path) {
+		return fmt.Errorf("not a pull request URL")
+	}
+	return nil
+}
+
+// prURLKey returns the host, owner/repo and number of a valid PR URL, so the
+// same PR matches with or without a trailing slash, a tab like /files, or a
+// different case in the owner and repo, which GitHub ignores
+func prURLKey(prURL string) string {
+	u, _ := url.Parse(prURL)
+	parts := strings.Split(strings.Trim(u.Path, "/"), "/")
+	return strings.ToLower(u.Host+"/"+parts[0]+"/"+parts[1]) + "/" + parts[3]
+}
+
+// validatePRURLs drops duplicate and malformed URLs, returning the remaining
+// PRs and a description of each skipped row
+func validatePRURLs(prURLs []PRURL) ([]PRURL, []string) {
+	var valid []PRURL
+	var skipped []string
+	seen := make(map[string]int)
+	for _, pr := range prURLs {
+		if err := validatePRURL(pr.URL); err != nil {
+			skipped = append(skipped, fmt.Sprintf("row %d: %s (%v)", pr.Row, pr.URL, err))
+			continue
+		}
+		key := prURLKey(pr.URL)
+		if row, ok := seen[key]; ok {
+			skipped = append(skipped, fmt.Sprintf("row %d: %s (duplicate of row %d)", pr.Row, pr.URL, row))
+			continue
+		}
+		seen[key] = pr.Row
+		valid = append(valid, pr)
+	}
+	return valid, skipped
+}
@@ -0,0 +1,36 @@
+package main
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestValidatePRURLsDropsDuplicates(t *testing.T) {
+	var prURLs []PRURL
+	for i, u := range []string{
+		"https://github.com/acme/widgets/pull/12",
+		"https://github.com/acme/widgets/pull/12/",
+		"https://github.com/acme/widgets/pull/12/files",
+		"https://github.com/Acme/Widgets/pull/12",
+		"https://github.com/acme/widgets/pull/123",
+		"https://github.example.com/acme/widgets/pull/12",
+		"https://github.com/acme/widgets/issues/12",
+	} {
+		prURLs = append(prURLs, PRURL{URL: u, Row: i + 1})
+	}
+
+	valid, skipped := validatePRURLs(prURLs)
+	var rows []int
+	for _, pr := range valid {
+		rows = append(rows, pr.Row)
+	}
+	if len(rows) != 3 || rows[0] != 1 || rows[1] != 5 || rows[2] != 6 {
+		t.Errorf("kept rows %v, want 1, 5 and 6", rows)
+	}
+	if len(skipped) != 4 || !strings.Contains(skipped[1], "row 3: https://github.com/acme/widgets/pull/12/files (duplicate of row 1)") {
+		t.Errorf("skipped = %q", skipped)
+	}
+	if !strings.Contains(skipped[3], "not a pull request URL") {
+		t.Errorf("issue URL skipped as %q", skipped[3])
+	}
+}
@@ -0,0 +1,71 @@
+package main
+
+import (
+	"fmt"
+	"time"
+)
+
+// cycleTime is when a PR reached each stage on its way to merging. FirstReview
+// and Approved are zero if the PR was merged without them.
+type cycleTime struct {
+	Opened      time.Time
+	FirstReview time.Time
+	// Approved is the last approval before the merge, the one it waited on
+	Approved time.Time
+	Merged   time.Time
+}
+
+// CycleTime returns when the PR was opened, first reviewed, last approved,
+// and merged. The reviews are counted as in the review latency report; see
+// reviewStages.
+func (pr PR) CycleTime() (cycleTime, error) {
+	var c cycleTime
+	var err error
+	if c.Opened, err = time.Parse(time.RFC3339, pr.CreatedAt); err != nil {
+		return c, fmt.Errorf("invalid createdAt %q: %v", pr.CreatedAt, err)
+	}
+	if c.Merged, err = time.Parse(time.RFC3339, pr.MergedAt); err != nil {
+		return c, fmt.Errorf("invalid mergedAt %q: %v", pr.MergedAt, err)
+	}
+	c.FirstReview, c.Approved, _ = pr.reviewStages(c.Merged)
+	return c, nil
+}
+
+// cycleTimeColumns returns the columns for the cycleTime field: the time
+// spent in each stage, in unit, left empty for stages the PR skipped
+func cycleTimeColumns(unit string) []column {
+	stage := func(header string, from, to func(cycleTime) time.Time) column {
+		return column{
+			Header: fmt.Sprintf("%s (%s)", header, unit),
+			Value: func(pr PR) string {
+				c, err := pr.CycleTime()
+				if err != nil || from(c).IsZero() || to(c).IsZero() {
+					return ""
+				}
+				return formatDuration(to(c).Sub(from(c)), unit)
+			},
+			Kind: numberCell,
+		}
+	}
+	opened := func(c cycleTime) time.Time { return c.Opened }
+	firstReview := func(c cycleTime) time.Time { return c.FirstReview }
+	approved := func(c cycleTime) time.Time { return c.Approved }
+	merged := func(c cycleTime) time.Time { return c.Merged }
+	return []column{
+		{Header: "First Review At", Value: func(pr PR) string { return pr.cycleTimestamp(firstReview) }},
+		{Header: "Approved At", Value: func(pr PR) string { return pr.cycleTimestamp(approved) }},
+		stage("Open To First Review", opened, firstReview),
+		stage("First Review To Approval", firstReview, approved),
+		stage("Approval To Merge", approved, merged),
+	}
+}
+
+// cycleTimestamp returns the time of one of the PR's stages, or "" if it
+// skipped the stage
+func (pr PR) cycleTimestamp(stage func(cycleTime) time.Time) string {
+	c, err := pr.CycleTime()
+	if err != nil || stage(c).IsZero() {
+		return ""
+	}
+	return stage(c).UTC().Format(time.RFC3339)
+}
@@ -0,0 +1,64 @@
+package main
+
+import (
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestCycleTimeColumns(t *testing.T) {
+	since := time.Date(2024, 3, 1, 0, 0, 0, 0, time.UTC)
+	// PR 1 opens at 09:00 and merges at 10:00 on March 1; PR 2 gets no reviews
+	prs := makePRs(since.Add(10*time.Hour), time.Hour, 2)
+	prs[0].Author = "alice"
+	runner := &fakeRunner{prs: prs, reviews: map[string][]string{"1": {
+		"alice\tCOMMENTED\t2024-03-01T09:05:00Z", // the author's own, ignored
+		"bob\tCOMMENTED\t2024-03-01T09:15:00Z",
+		"carol\tAPPROVED\t2024-03-01T09:30:00Z",
+		"bob\tAPPROVED\t2024-03-01T09:45:00Z",
+		"dave\tAPPROVED\t2024-03-01T11:00:00Z", // after the merge, ignored
+	}}}
+	opts := testOptions(since, runner)
+	opts.Until = since.AddDate(0, 0, 2)
+	opts.Fields = []string{"cycleTime"}
+	opts.TimeToMergeUnit = "minutes"
+
+	fetched, err := getMergedPRs(opts)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(fetched) != 2 {
+		t.Fatalf("fetched %d PRs, want 2", len(fetched))
+	}
+
+	columns := outputColumns(opts)
+	var headers []string
+	for _, c := range columns {
+		headers = append(headers, c.Header)
+	}
+	want := "First Review At,Approved At,Open To First Review (minutes),First Review To Approval (minutes),Approval To Merge (minutes)"
+	if got := strings.Join(headers, ","); !strings.HasSuffix(got, ","+want) {
+		t.Fatalf("headers = %s, want them to end with %s", got, want)
+	}
+
+	values := func(pr PR) string {
+		var row []string
+		for _, c := range columns[len(columns)-5:] {
+			row = append(row, c.Value(pr))
+		}
+		return strings.Join(row, ",")
+	}
+	if got := values(fetched[0]); got != "2024-03-01T09:15:00Z,2024-03-01T09:45:00Z,15.00,30.00,15.00" {
+		t.Errorf("PR 1 = %s", got)
+	}
+	if got := values(fetched[1]); got != ",,,," {
+		t.Errorf("unreviewed PR 2 = %s, want every stage empty", got)
+	}
+}
+
+func TestParseFieldsAcceptsCycleTime(t *testing.T) {
+	fields, err := parseFields("author,cycleTime")
+	if err != nil || strings.Join(fields, ",") != "author,cycleTime" {
+		t.Errorf("parseFields = %v, %v", fields, err)
+	}
+}
@@ -0,0 +1,200 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+)
+
+// doctorCheck is the outcome of one environment check. Optional checks only
+// warn, since the tool works without them.
+type doctorCheck struct {
+	Name     string
+	OK       bool
+	Optional bool
+	Detail   string
+	Fix      string
+}
+
+// runDoctor checks the environment list and open mode rely on, prints the
+// results with a suggested fix for each problem, and reports whether every
+// required check passed
+func runDoctor(configFile, outDir string) bool {
+	// Each gh check can only pass once the one before it does
+	checks := []doctorCheck{checkGHInstalled()}
+	if checks[0].OK {
+		checks = append(checks, checkGHAuth())
+		if checks[1].OK {
+			checks = append(checks, checkRateLimit())
+		}
+	}
+	checks = append(checks, checkOpener())
+	for _, browser := range supportedBrowsers {
+		checks = append(checks, checkBrowser(browser))
+	}
+	checks = append(checks, checkOutputDir(outDir), checkConfigFile(configFile))
+
+	healthy := true
+	for _, check := range checks {
+		status := "ok"
+		if !check.OK {
+			status = "FAIL"
+			if check.Optional {
+				status = "warn"
+			} else {
+				healthy = false
+			}
+		}
+		fmt.Printf("[%-4s] %-16s %s\n", status, check.Name, check.Detail)
+		if !check.OK && check.Fix != "" {
+			fmt.Printf("       fix: %s\n", check.Fix)
+		}
+	}
+
+	if healthy {
+		fmt.Println("\nEverything needed to run github-pr-grabber is in place.")
+	} else {
+		fmt.Println("\nSome required checks failed; see the fixes above.")
+	}
+	return healthy
+}
+
+// checkGHInstalled checks that the GitHub CLI is on the PATH
+func checkGHInstalled() doctorCheck {
+	check := doctorCheck{Name: "gh installed"}
+	path, err := exec.LookPath("gh")
+	if err != nil {
+		check.Detail = "gh was not found in PATH"
+		check.Fix = "install the GitHub CLI from https://github.com/cli/cli#installation"
+		return check
+	}
+	check.OK = true
+	check.Detail = fmt.Sprintf("%s (%s)", ghVersion(), path)
+	return check
+}
+
+// checkGHAuth checks that gh can authenticate with GitHub
+func checkGHAuth() doctorCheck {
+	check := doctorCheck{Name: "gh auth"}
+	if err := checkGHReady(); err != nil {
+		check.Detail = err.Error()
+		check.Fix = "run `gh auth login`, or set GH_TOKEN to a personal access token"
+		return check
+	}
+	check.OK = true
+	check.Detail = "authenticated"
+	if os.Getenv("GH_TOKEN") != "" || os.Getenv("GITHUB_TOKEN") != "" {
+		check.Detail = "using a token from GH_TOKEN/GITHUB_TOKEN"
+	}
+	return check
+}
+
+// checkRateLimit checks that the GitHub API is reachable and has requests left.
+// The search API used by list mode has its own, lower limit.
+func checkRateLimit() doctorCheck {
+	check := doctorCheck{Name: "GitHub API"}
+	core, search, err := fetchRateLimits(ghRunner{})
+	if err != nil {
+		check.Detail = fmt.Sprintf("could not reach the API: %v", err)
+		check.Fix = "check your network connection and proxy settings, or https://www.githubstatus.com"
+		return check
+	}
+
+	check.Detail = fmt.Sprintf("reachable, %d/%d core and %d/%d search requests remaining",
+		core.Remaining, core.Limit, search.Remaining, search.Limit)
+	if core.Remaining == 0 || search.Remaining == 0 {
+		check.Fix = "wait for the rate limit to reset; `gh api rate_limit` shows when"
+		return check
+	}
+	check.OK = true
+	return check
+}
+
+// checkOpener checks that the platform's default URL opener is available
+func checkOpener() doctorCheck {
+	check := doctorCheck{Name: "URL opener"}
+	cmd, err := openerCommand("", "https://github.com")
+	if err != nil {
+		check.Detail = err.Error()
+		check.Fix = "use -opener to set the command open mode runs for each URL"
+		return check
+	}
+	if _, err := exec.LookPath(cmd.Args[0]); err != nil {
+		check.Detail = fmt.Sprintf("%s was not found in PATH", cmd.Args[0])
+		check.Fix = fmt.Sprintf("install %s, or use -opener or -browser in open mode", cmd.Args[0])
+		return check
+	}
+	check.OK = true
+	check.Detail = strings.Join(cmd.Args[:len(cmd.Args)-1], " ")
+	return check
+}
+
+// checkBrowser checks whether a browser usable with -browser is installed
+func checkBrowser(browser string) doctorCheck {
+	check := doctorCheck{Name: "browser " + browser, Optional: true}
+	executable, err := browserExecutable(browser)
+	if err != nil {
+		check.Detail = err.Error()
+		return check
+	}
+	if filepath.IsAbs(executable) {
+		_, err = os.Stat(executable)
+	} else {
+		_, err = exec.LookPath(executable)
+	}
+	if err != nil {
+		check.Detail = fmt.Sprintf("%s was not found", executable)
+		check.Fix = fmt.Sprintf("install %s to use -browser %s, or keep using the default browser", browser, browser)
+		return check
+	}
+	check.OK = true
+	check.Detail = executable
+	return check
+}
+
+// checkOutputDir checks that list mode can create and write files in dir.
+// A missing dir isn't created; its nearest existing parent is checked instead.
+func checkOutputDir(dir string) doctorCheck {
+	check := doctorCheck{Name: "output dir"}
+	existing, err := nearestExistingDir(dir)
+	if err == nil {
+		err = checkWritable(existing)
+	}
+	if err != nil {
+		check.Detail = err.Error()
+		check.Fix = fmt.Sprintf("fix the permissions on %s, or choose another directory with -out-dir", dir)
+		return check
+	}
+	check.OK = true
+	check.Detail = fmt.Sprintf("%s is writable", dir)
+	if existing != dir {
+		check.Detail = fmt.Sprintf("%s will be created in %s, which is writable", dir, existing)
+	}
+	return check
+}
+
+// checkConfigFile checks that the config file, if there is one, can be parsed
+func checkConfigFile(path string) doctorCheck {
+	check := doctorCheck{Name: "config file", Optional: true}
+	if path == "" {
+		check.OK = true
+		check.Detail = "no config directory on this system"
+		return check
+	}
+	if _, err := os.Stat(path); os.IsNotExist(err) {
+		check.OK = true
+		check.Detail = fmt.Sprintf("none at %s", path)
+		return check
+	}
+	config, err := loadConfig(path)
+	if err != nil {
+		check.Detail = err.Error()
+		check.Fix = "fix the JSON in the config file, or remove it"
+		return check
+	}
+	check.OK = true
+	check.Detail = fmt.Sprintf("%s, %d profiles", path, len(config.Profiles))
+	return check
+}
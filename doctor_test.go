@@ -0,0 +1,60 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestDoctorReportsInvalidConfigFile(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "config.json")
+	if err := os.WriteFile(path, []byte(`{"profiles": {`), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	// Other modes fail on the config file; doctor starts without it
+	if _, err := loadStartupConfig(path, false, false); err == nil {
+		t.Error("loadStartupConfig accepted invalid JSON")
+	}
+	config, err := loadStartupConfig(path, true, true)
+	if config != nil || err != nil {
+		t.Fatalf("loadStartupConfig for doctor = %v, %v, want neither a config nor an error", config, err)
+	}
+
+	check := checkConfigFile(path)
+	if check.OK || !strings.Contains(check.Detail, "error parsing config file") || check.Fix == "" {
+		t.Errorf("checkConfigFile = %+v, want a failure with a fix", check)
+	}
+}
+
+func TestLoadStartupConfigMissingFile(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "config.json")
+	if config, err := loadStartupConfig(path, false, false); config != nil || err != nil {
+		t.Errorf("loadStartupConfig = %v, %v, want nil for an optional missing file", config, err)
+	}
+	if _, err := loadStartupConfig(path, true, false); err == nil {
+		t.Error("loadStartupConfig ignored a missing file a profile was requested from")
+	}
+}
+
+func TestCheckOutputDirDoesNotCreateIt(t *testing.T) {
+	parent := t.TempDir()
+	dir := filepath.Join(parent, "generated", "csv")
+
+	check := checkOutputDir(dir)
+	if !check.OK || !strings.Contains(check.Detail, "will be created in "+parent) {
+		t.Errorf("checkOutputDir = %+v, want it to check %s", check, parent)
+	}
+	if _, err := os.Stat(filepath.Join(parent, "generated")); !os.IsNotExist(err) {
+		t.Errorf("checkOutputDir created the output directory")
+	}
+
+	file := filepath.Join(parent, "file")
+	if err := os.WriteFile(file, nil, 0644); err != nil {
+		t.Fatal(err)
+	}
+	if check := checkOutputDir(filepath.Join(file, "csv")); check.OK {
+		t.Errorf("checkOutputDir = %+v under a file, want a failure", check)
+	}
+}
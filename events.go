@@ -0,0 +1,41 @@
+package main
+
+import (
+	"fmt"
+	"io"
+	"log/slog"
+	"os"
+)
+
+// events receives machine-readable progress events such as chunk_fetched or
+// pr_opened. It discards them unless -log-format json is used; the regular
+// human-readable output is printed separately either way.
+var events = slog.New(slog.NewTextHandler(io.Discard, nil))
+
+// jsonEvents is true when events are written to stderr as JSON
+var jsonEvents bool
+
+// warnf prints a human-readable warning to stderr, unless stderr is carrying
+// JSON events, in which case the warning should be logged as an event instead
+func warnf(format string, args ...any) {
+	if !jsonEvents {
+		fmt.Fprintf(os.Stderr, format, args...)
+	}
+}
+
+// setLogFormat configures event output. With "json", events are written as
+// JSON lines to stderr, and messages from the log package (including fatal
+// errors) go through the same handler so the stream stays parseable.
+func setLogFormat(format string) error {
+	switch format {
+	case "", "text":
+		return nil
+	case "json":
+		events = slog.New(slog.NewJSONHandler(os.Stderr, nil))
+		slog.SetDefault(events)
+		jsonEvents = true
+		return nil
+	default:
+		return fmt.Errorf("unknown log format %q: must be text or json", format)
+	}
+}
@@ -0,0 +1,110 @@
+package main
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+)
+
+// fixture is one recorded gh command and its response
+type fixture struct {
+	Args   []string `json:"args"`
+	Output string   `json:"output"`
+	Error  string   `json:"error,omitempty"`
+}
+
+// fixtureManifest describes a fixture directory. Queries cover dates up to the
+// recording time, so replays use it in place of the current time.
+type fixtureManifest struct {
+	RecordedAt time.Time `json:"recorded_at"`
+}
+
+// fixturePath returns the file a command's response is stored in, named after a hash of its arguments
+func fixturePath(dir string, args []string) string {
+	sum := sha256.Sum256([]byte(strings.Join(args, "\x00")))
+	return filepath.Join(dir, hex.EncodeToString(sum[:8])+".json")
+}
+
+// recordingRunner runs commands with Runner and saves each response to Dir
+type recordingRunner struct {
+	Dir    string
+	Runner CommandRunner
+}
+
+// newRecordingRunner creates dir and a manifest for recording gh responses into it
+func newRecordingRunner(dir string) (recordingRunner, error) {
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return recordingRunner{}, fmt.Errorf("error creating fixture directory: %v", err)
+	}
+	data, err := json.MarshalIndent(fixtureManifest{RecordedAt: time.Now().UTC()}, "", "  ")
+	if err != nil {
+		return recordingRunner{}, err
+	}
+	if err := os.WriteFile(filepath.Join(dir, "manifest.json"), append(data, '\n'), 0644); err != nil {
+		return recordingRunner{}, fmt.Errorf("error writing fixture manifest: %v", err)
+	}
+	return recordingRunner{Dir: dir, Runner: ghRunner{}}, nil
+}
+
+// Run runs the command and records its output, including failures, so replays see the same errors
+func (r recordingRunner) Run(args ...string) (string, error) {
+	output, runErr := r.Runner.Run(args...)
+
+	f := fixture{Args: args, Output: output}
+	if runErr != nil {
+		f.Error = runErr.Error()
+	}
+	data, err := json.MarshalIndent(f, "", "  ")
+	if err != nil {
+		return "", err
+	}
+	if err := os.WriteFile(fixturePath(r.Dir, args), append(data, '\n'), 0644); err != nil {
+		return "", fmt.Errorf("error writing fixture: %v", err)
+	}
+	return output, runErr
+}
+
+// replayRunner answers commands from responses recorded by recordingRunner,
+// without running gh
+type replayRunner struct {
+	Dir string
+}
+
+// loadReplayRunner opens a fixture directory and returns when it was recorded
+func loadReplayRunner(dir string) (replayRunner, time.Time, error) {
+	data, err := os.ReadFile(filepath.Join(dir, "manifest.json"))
+	if err != nil {
+		return replayRunner{}, time.Time{}, fmt.Errorf("error reading fixture manifest: %v", err)
+	}
+	var manifest fixtureManifest
+	if err := json.Unmarshal(data, &manifest); err != nil {
+		return replayRunner{}, time.Time{}, fmt.Errorf("error parsing fixture manifest: %v", err)
+	}
+	return replayRunner{Dir: dir}, manifest.RecordedAt, nil
+}
+
+// Run returns the recorded response to the command
+func (r replayRunner) Run(args ...string) (string, error) {
+	data, err := os.ReadFile(fixturePath(r.Dir, args))
+	if errors.Is(err, os.ErrNotExist) {
+		return "", fmt.Errorf("no recorded response in %s for %s", r.Dir, formatGHCommand(args...))
+	}
+	if err != nil {
+		return "", fmt.Errorf("error reading fixture: %v", err)
+	}
+
+	var f fixture
+	if err := json.Unmarshal(data, &f); err != nil {
+		return "", fmt.Errorf("error parsing fixture: %v", err)
+	}
+	if f.Error != "" {
+		return f.Output, errors.New(f.Error)
+	}
+	return f.Output, nil
+}
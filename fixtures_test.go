@@ -0,0 +1,64 @@
+package main
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestRecordAndReplay(t *testing.T) {
+	dir := t.TempDir()
+	recorder, err := newRecordingRunner(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	since := daysAgo(40)
+	fake := &fakeRunner{prs: makePRs(since.Add(time.Hour), 24*time.Hour, 40), fail: []string{since.Format("2006-01-02")}}
+	recorder.Runner = fake
+
+	recorded, err := getMergedPRs(testOptions(since, recorder))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	replay, recordedAt, err := loadReplayRunner(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	// Replaying as of the recording time asks for the same date ranges
+	opts := testOptions(since, replay)
+	opts.Until = recordedAt
+	replayed, err := getMergedPRs(opts)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(replayed) == 0 || len(replayed) != len(recorded) {
+		t.Fatalf("replayed %d PRs, want the %d recorded", len(replayed), len(recorded))
+	}
+	for i := range recorded {
+		if !reflect.DeepEqual(replayed[i], recorded[i]) {
+			t.Errorf("replayed PR %d = %+v, want %+v", i, replayed[i], recorded[i])
+		}
+	}
+
+	// The failed chunk's error is replayed too
+	if _, err := replay.Run(prListArgsFor(since, opts)...); err == nil || err.Error() != "HTTP 502" {
+		t.Errorf("replaying the failed chunk returned %v, want the recorded HTTP 502", err)
+	}
+}
+
+func TestReplayMissingFixture(t *testing.T) {
+	replay := replayRunner{Dir: t.TempDir()}
+	_, err := replay.Run("pr", "list", "--repo", "acme/widgets")
+	if err == nil || !strings.Contains(err.Error(), "gh pr list --repo acme/widgets") {
+		t.Fatalf("Run with no fixture returned %v, want an error naming the command", err)
+	}
+}
+
+// prListArgsFor returns the arguments of the first chunk's query
+func prListArgsFor(since time.Time, opts ListOptions) []string {
+	chunk := monthlyChunks(since, opts.until())[0]
+	args, _ := prListArgs(chunk.Start, chunk.End, opts, 1000)
+	return args
+}
@@ -2,10 +2,49 @@ package main
 
 import (
 	"fmt"
+	"os"
 	"os/exec"
 	"strings"
 )
 
+// checkGHReady verifies that the GitHub CLI is installed and authenticated, so
+// list mode fails fast with an actionable message instead of on every chunk
+func checkGHReady() error {
+	if _, err := exec.LookPath("gh"); err != nil {
+		return fmt.Errorf("the GitHub CLI (gh) was not found in PATH; install it from https://github.com/cli/cli#installation")
+	}
+
+	// gh uses these tokens directly, without needing gh auth login
+	if os.Getenv("GH_TOKEN") != "" || os.Getenv("GITHUB_TOKEN") != "" {
+		return nil
+	}
+
+	output, err := exec.Command("gh", "auth", "status").CombinedOutput()
+	if err != nil {
+		msg := strings.TrimSpace(string(output))
+		if msg == "" {
+			msg = err.Error()
+		}
+		return fmt.Errorf("the GitHub CLI is not authenticated; run `gh auth login` or set GH_TOKEN (gh auth status: %s)", msg)
+	}
+	return nil
+}
+
+// CommandRunner runs GitHub CLI commands, taking the arguments after "gh" and
+// returning the trimmed output. Tests substitute a fake so the fetch logic can
+// run without gh or the network.
+type CommandRunner interface {
+	Run(args ...string) (string, error)
+}
+
+// ghRunner is the CommandRunner that runs the installed GitHub CLI
+type ghRunner struct{}
+
+// Run executes a GitHub CLI command
+func (ghRunner) Run(args ...string) (string, error) {
+	return runGHCommand(args...)
+}
+
 // runGHCommand executes a GitHub CLI command and returns its output
 func runGHCommand(args ...string) (string, error) {
 	cmd := exec.Command("gh", args...)
@@ -15,3 +54,35 @@ func runGHCommand(args ...string) (string, error) {
 	}
 	return strings.TrimSpace(string(output)), nil
 }
+
+// formatGHCommand formats gh arguments as a command line that can be pasted into a shell
+func formatGHCommand(args ...string) string {
+	quoted := []string{"gh"}
+	for _, arg := range args {
+		if arg == "" || strings.ContainsAny(arg, " \t\n\"'\\$`|&;<>()[]{}*?!#~") {
+			arg = "'" + strings.ReplaceAll(arg, "'", `'\''`) + "'"
+		}
+		quoted = append(quoted, arg)
+	}
+	return strings.Join(quoted, " ")
+}
+
+// rateLimit is how many requests are left of an API rate limit
+type rateLimit struct {
+	Remaining int
+	Limit     int
+}
+
+// fetchRateLimits returns the core REST API and search API rate limits. Checking
+// them doesn't count against either limit.
+func fetchRateLimits(runner CommandRunner) (core, search rateLimit, err error) {
+	output, err := runner.Run("api", "rate_limit", "--jq",
+		`"\(.resources.core.remaining) \(.resources.core.limit) \(.resources.search.remaining) \(.resources.search.limit)"`)
+	if err != nil {
+		return core, search, err
+	}
+	if _, err := fmt.Sscan(output, &core.Remaining, &core.Limit, &search.Remaining, &search.Limit); err != nil {
+		return core, search, fmt.Errorf("unexpected rate limit response %q", output)
+	}
+	return core, search, nil
+}
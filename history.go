@@ -0,0 +1,122 @@
+package main
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"slices"
+
+	"github.com/charmbracelet/bubbles/textinput"
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+// maxHistoryEntries is how many previous values are kept for each prompt
+const maxHistoryEntries = 50
+
+// History holds previously entered values for the interactive prompts, keyed
+// by prompt name, most recent first. It is saved next to the config file.
+type History map[string][]string
+
+// historyFilePath returns the history file location, next to the config file
+func historyFilePath() string {
+	config := defaultConfigPath()
+	if config == "" {
+		return ""
+	}
+	return filepath.Join(filepath.Dir(config), "history.json")
+}
+
+// loadHistory reads the saved history, returning an empty history if there is none
+func loadHistory() History {
+	history := make(History)
+	if path := historyFilePath(); path != "" {
+		if data, err := os.ReadFile(path); err == nil {
+			// A corrupt history file isn't worth failing over; start afresh
+			json.Unmarshal(data, &history)
+		}
+	}
+	return history
+}
+
+// add records value as the most recent entry for key
+func (h History) add(key, value string) {
+	if value == "" {
+		return
+	}
+	entries := slices.DeleteFunc(h[key], func(entry string) bool { return entry == value })
+	entries = append([]string{value}, entries...)
+	if len(entries) > maxHistoryEntries {
+		entries = entries[:maxHistoryEntries]
+	}
+	h[key] = entries
+}
+
+// save writes the history to disk
+func (h History) save() error {
+	path := historyFilePath()
+	if path == "" {
+		return nil
+	}
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return err
+	}
+	data, err := json.MarshalIndent(h, "", "  ")
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(path, data, 0644)
+}
+
+// historyInput is a text input where up and down step through previous values,
+// like a shell prompt. Other editing keys are handled by textinput.
+type historyInput struct {
+	textinput.Model
+	entries []string
+	pos     int    // index into entries being shown, -1 while editing a new value
+	draft   string // the new value being typed before browsing started
+}
+
+func newHistoryInput(prompt, placeholder string, entries []string) historyInput {
+	input := textinput.New()
+	input.Prompt = prompt
+	input.Placeholder = placeholder
+	return historyInput{Model: input, entries: entries, pos: -1}
+}
+
+func (h historyInput) Update(msg tea.Msg) (historyInput, tea.Cmd) {
+	if keyMsg, ok := msg.(tea.KeyMsg); ok {
+		switch keyMsg.String() {
+		case "up", "ctrl+p":
+			if h.pos+1 < len(h.entries) {
+				if h.pos == -1 {
+					h.draft = h.Value()
+				}
+				h.pos++
+				h.SetValue(h.entries[h.pos])
+				h.CursorEnd()
+			}
+			return h, nil
+		case "down", "ctrl+n":
+			if h.pos >= 0 {
+				h.pos--
+				if h.pos == -1 {
+					h.SetValue(h.draft)
+				} else {
+					h.SetValue(h.entries[h.pos])
+				}
+				h.CursorEnd()
+			}
+			return h, nil
+		}
+	}
+
+	var cmd tea.Cmd
+	h.Model, cmd = h.Model.Update(msg)
+	return h, cmd
+}
+
+// setEntries replaces the history shown by the input and stops browsing it
+func (h *historyInput) setEntries(entries []string) {
+	h.entries = entries
+	h.pos = -1
+}
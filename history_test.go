@@ -0,0 +1,89 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"slices"
+	"testing"
+
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+func TestHistoryAdd(t *testing.T) {
+	h := make(History)
+	for _, value := range []string{"acme/widgets", "acme/gadgets", "", "acme/widgets"} {
+		h.add("repo", value)
+	}
+	// A repeated value moves to the front instead of appearing twice, and empty ones are skipped
+	if got := h["repo"]; !slices.Equal(got, []string{"acme/widgets", "acme/gadgets"}) {
+		t.Errorf("history = %q", got)
+	}
+
+	for i := 0; i < maxHistoryEntries+10; i++ {
+		h.add("since", fmt.Sprintf("%dd", i))
+	}
+	if got := h["since"]; len(got) != maxHistoryEntries || got[0] != fmt.Sprintf("%dd", maxHistoryEntries+9) {
+		t.Errorf("capped history has %d entries starting %q, want %d starting with the newest", len(got), got[0], maxHistoryEntries)
+	}
+}
+
+func TestHistorySaveAndLoad(t *testing.T) {
+	// os.UserConfigDir reads these, depending on the platform
+	t.Setenv("XDG_CONFIG_HOME", t.TempDir())
+	t.Setenv("HOME", t.TempDir())
+	t.Setenv("AppData", t.TempDir())
+
+	if got := loadHistory(); len(got) != 0 {
+		t.Errorf("history without a file = %v, want it empty", got)
+	}
+	h := make(History)
+	h.add("repo", "acme/gadgets")
+	h.add("repo", "acme/widgets")
+	if err := h.save(); err != nil {
+		t.Fatal(err)
+	}
+	if got := loadHistory(); !slices.Equal(got["repo"], []string{"acme/widgets", "acme/gadgets"}) {
+		t.Errorf("loaded history = %v", got)
+	}
+
+	if err := os.WriteFile(historyFilePath(), []byte("{not json"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if got := loadHistory(); len(got) != 0 {
+		t.Errorf("history from a corrupt file = %v, want it empty", got)
+	}
+}
+
+func TestHistoryInputNavigation(t *testing.T) {
+	input := newHistoryInput("> ", "", []string{"newest", "older"})
+	input.Focus()
+	input.SetValue("draft")
+	key := func(k tea.KeyType) {
+		input, _ = input.Update(tea.KeyMsg{Type: k})
+	}
+
+	for _, step := range []struct {
+		key  tea.KeyType
+		want string
+	}{
+		{tea.KeyUp, "newest"},
+		{tea.KeyUp, "older"},
+		{tea.KeyUp, "older"}, // stays on the oldest entry
+		{tea.KeyDown, "newest"},
+		{tea.KeyDown, "draft"}, // back to what was being typed
+		{tea.KeyDown, "draft"},
+		{tea.KeyCtrlP, "newest"},
+		{tea.KeyCtrlN, "draft"},
+	} {
+		key(step.key)
+		if got := input.Value(); got != step.want {
+			t.Fatalf("after %s the value is %q, want %q", step.key, got, step.want)
+		}
+	}
+
+	input.setEntries([]string{"replaced"})
+	key(tea.KeyUp)
+	if got := input.Value(); got != "replaced" {
+		t.Errorf("after setEntries, up shows %q, want replaced", got)
+	}
+}
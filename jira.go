@@ -0,0 +1,65 @@
+package main
+
+import (
+	"fmt"
+	"regexp"
+	"slices"
+	"strings"
+)
+
+// jiraKeyPattern matches Jira issue keys such as PROJ-123. It also matches
+// things like UTF-8, which -jira-projects or notJiraProjects filters out.
+var jiraKeyPattern = regexp.MustCompile(`\b[A-Z][A-Z0-9_]+-[1-9][0-9]*\b`)
+
+// jiraCandidatesJQ picks the possible Jira keys out of a PR's branch name and
+// body in the pr list query, so the bodies themselves aren't sent back
+var jiraCandidatesJQ = fmt.Sprintf(`([.headRefName, .body // ""] | join(" ") | [scan(%q)] | join(" "))`, jiraKeyPattern.String())
+
+// notJiraProjects is the prefixes of common key-shaped names like UTF-8 and
+// SHA-256, which aren't taken as Jira keys unless -jira-projects lists them
+var notJiraProjects = []string{"AES", "CVE", "GPT", "HTTP", "ISO", "PEP", "RFC", "SHA", "TLS", "UTF"}
+
+// findJiraKey returns the first Jira key in texts, searched in order, or ""
+// if there isn't one. If projects is set, keys from other projects are
+// skipped; otherwise keys from notJiraProjects are.
+func findJiraKey(projects []string, texts ...string) string {
+	for _, text := range texts {
+		for _, key := range jiraKeyPattern.FindAllString(text, -1) {
+			project, _, _ := strings.Cut(key, "-")
+			if slices.Contains(projects, project) || (len(projects) == 0 && !slices.Contains(notJiraProjects, project)) {
+				return key
+			}
+		}
+	}
+	return ""
+}
+
+// parseJiraProjects parses a comma-separated list of Jira project keys
+func parseJiraProjects(value string) ([]string, error) {
+	var projects []string
+	for _, project := range strings.Split(value, ",") {
+		project = strings.TrimSpace(project)
+		if project == "" {
+			continue
+		}
+		if !jiraKeyPattern.MatchString(project + "-1") {
+			return nil, fmt.Errorf("invalid Jira project %q: must be upper case letters and digits, like PROJ", project)
+		}
+		projects = append(projects, project)
+	}
+	return projects, nil
+}
+
+// jiraColumns returns the columns for the jira field: the PR's Jira key and
+// its link under baseURL, left empty without a base URL
+func jiraColumns(baseURL string) []column {
+	return []column{
+		{Header: "Jira Key", Value: func(pr PR) string { return pr.JiraKey }},
+		{Header: "Jira URL", Value: func(pr PR) string {
+			if pr.JiraKey == "" || baseURL == "" {
+				return ""
+			}
+			return strings.TrimRight(baseURL, "/") + "/browse/" + pr.JiraKey
+		}},
+	}
+}
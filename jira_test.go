@@ -0,0 +1,69 @@
+package main
+
+import (
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestFindJiraKey(t *testing.T) {
+	tests := []struct {
+		projects []string
+		texts    []string
+		want     string
+	}{
+		{nil, []string{"PROJ-12: Add retries", "feature/OPS-3"}, "PROJ-12"},
+		{nil, []string{"Add retries", "feature/OPS-3-retries"}, "OPS-3"},
+		{nil, []string{"Bump to v1.2-3", "no key here"}, ""},
+		{nil, []string{"Read files as UTF-8, not ISO-8859", "Switch to SHA-256 for OPS-4"}, "OPS-4"},
+		{[]string{"SHA"}, []string{"Switch to SHA-256"}, "SHA-256"},
+		{[]string{"OPS"}, []string{"Use UTF-8 everywhere", "Fixes OPS-9"}, "OPS-9"},
+		{[]string{"OPS"}, []string{"PROJ-12: Add retries"}, ""},
+	}
+	for _, tt := range tests {
+		if got := findJiraKey(tt.projects, tt.texts...); got != tt.want {
+			t.Errorf("findJiraKey(%v, %q) = %q, want %q", tt.projects, tt.texts, got, tt.want)
+		}
+	}
+}
+
+func TestParseJiraProjects(t *testing.T) {
+	projects, err := parseJiraProjects(" PROJ, OPS2 ,")
+	if err != nil || strings.Join(projects, ",") != "PROJ,OPS2" {
+		t.Errorf("parseJiraProjects = %v, %v", projects, err)
+	}
+	if _, err := parseJiraProjects("proj"); err == nil {
+		t.Error("parseJiraProjects accepted a lower case project")
+	}
+}
+
+func TestJiraField(t *testing.T) {
+	since := time.Date(2024, 3, 1, 0, 0, 0, 0, time.UTC)
+	prs := makePRs(since.Add(10*time.Hour), time.Hour, 3)
+	prs[0].Title = "PROJ-12: Add retries"
+	prs[1].JiraKey = "UTF-8 OPS-3" // found in the branch name and body
+	opts := testOptions(since, &fakeRunner{prs: prs})
+	opts.Until = since.AddDate(0, 0, 2)
+	opts.Fields = []string{"jira"}
+	opts.JiraURL = "https://acme.atlassian.net/"
+	opts.JiraProjects = []string{"PROJ", "OPS"}
+
+	fetched, err := getMergedPRs(opts)
+	if err != nil {
+		t.Fatal(err)
+	}
+	table := buildTable(fetched, opts)
+	if got := strings.Join(table.Header[len(table.Header)-2:], ","); got != "Jira Key,Jira URL" {
+		t.Fatalf("headers end with %s, want Jira Key,Jira URL", got)
+	}
+	want := []string{
+		"PROJ-12,https://acme.atlassian.net/browse/PROJ-12",
+		"OPS-3,https://acme.atlassian.net/browse/OPS-3",
+		",",
+	}
+	for i, row := range table.Rows {
+		if got := strings.Join(row[len(row)-2:], ","); got != want[i] {
+			t.Errorf("PR %s Jira columns = %s, want %s", fetched[i].Number, got, want[i])
+		}
+	}
+}
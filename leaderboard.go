@@ -0,0 +1,160 @@
+package main
+
+import (
+	"fmt"
+	"io"
+	"slices"
+	"sort"
+	"strconv"
+	"strings"
+	"text/tabwriter"
+	"time"
+)
+
+// leaderboardEntry is one contributor's place on the leaderboard
+type leaderboardEntry struct {
+	// Rank is shared by contributors with the same number of PRs
+	Rank    int
+	Author  string
+	PRs     int
+	Changes int
+	Repos   []string // alphabetical
+	// FirstTimeIn is the repos the contributor had no merged PRs in before
+	// the period, alphabetically
+	FirstTimeIn []string
+}
+
+// isBot reports whether login belongs to an app or bot account, which the
+// leaderboard leaves out
+func isBot(login string) bool {
+	return strings.HasPrefix(login, "app/") || strings.HasSuffix(login, "[bot]")
+}
+
+// priorPRsArgs builds the gh arguments that count author's PRs merged in repo before since
+func priorPRsArgs(repo, author string, since time.Time) []string {
+	return []string{
+		"pr", "list",
+		"--repo", repo,
+		"--search", fmt.Sprintf("author:%s merged:<%s", author, since.Format("2006-01-02")),
+		"--json", "number",
+		"--jq", "length",
+		"--limit", "1",
+	}
+}
+
+// fetchFirstTimers returns the contributors to each repo with no PRs merged
+// there before opts.Since, by repo, taking one search per author and repo.
+// Failures are reported and leave the author out.
+func fetchFirstTimers(opts ListOptions, prs []PR) map[string]map[string]bool {
+	authors := make(map[string]map[string]bool) // by repo
+	for _, pr := range prs {
+		if pr.Author == "" || isBot(pr.Author) {
+			continue
+		}
+		repo := repoFromURL(pr.URL)
+		if authors[repo] == nil {
+			authors[repo] = make(map[string]bool)
+		}
+		authors[repo][pr.Author] = true
+	}
+
+	firstTimers := make(map[string]map[string]bool)
+	for repo, names := range authors {
+		opts.printf("Checking %d contributors' earlier PRs in %s...\n", len(names), repo)
+		firstTimers[repo] = make(map[string]bool)
+		for author := range names {
+			output, err := opts.runGH(priorPRsArgs(repo, author, opts.Since)...)
+			if err != nil {
+				opts.printf("  Warning: Error checking earlier PRs by %s: %v\n", author, err)
+				events.Warn("prior_prs_failed", "repo", repo, "author", author, "error", err.Error())
+				continue
+			}
+			if strings.TrimSpace(output) == "0" {
+				firstTimers[repo][author] = true
+			}
+		}
+	}
+	return firstTimers
+}
+
+// computeLeaderboard ranks the contributors by PRs merged, then lines
+// changed, leaving out bots and unknown authors
+func computeLeaderboard(prs []PR, firstTimers map[string]map[string]bool) []leaderboardEntry {
+	byAuthor := make(map[string]*leaderboardEntry)
+	for _, pr := range prs {
+		if pr.Author == "" || isBot(pr.Author) {
+			continue
+		}
+		entry := byAuthor[pr.Author]
+		if entry == nil {
+			entry = &leaderboardEntry{Author: pr.Author}
+			byAuthor[pr.Author] = entry
+		}
+		entry.PRs++
+		entry.Changes += pr.Changes()
+		if repo := repoFromURL(pr.URL); !slices.Contains(entry.Repos, repo) {
+			entry.Repos = append(entry.Repos, repo)
+			if firstTimers[repo][pr.Author] {
+				entry.FirstTimeIn = append(entry.FirstTimeIn, repo)
+			}
+		}
+	}
+
+	leaderboard := make([]leaderboardEntry, 0, len(byAuthor))
+	for _, entry := range byAuthor {
+		sort.Strings(entry.Repos)
+		sort.Strings(entry.FirstTimeIn)
+		leaderboard = append(leaderboard, *entry)
+	}
+	sort.Slice(leaderboard, func(i, j int) bool {
+		a, b := leaderboard[i], leaderboard[j]
+		if a.PRs != b.PRs {
+			return a.PRs > b.PRs
+		}
+		if a.Changes != b.Changes {
+			return a.Changes > b.Changes
+		}
+		return a.Author < b.Author
+	})
+	for i := range leaderboard {
+		if i > 0 && leaderboard[i].PRs == leaderboard[i-1].PRs {
+			leaderboard[i].Rank = leaderboard[i-1].Rank
+		} else {
+			leaderboard[i].Rank = i + 1
+		}
+	}
+	return leaderboard
+}
+
+// leaderboardTable returns the leaderboard, for saving
+func leaderboardTable(leaderboard []leaderboardEntry) Table {
+	table := Table{
+		Header: []string{"Rank", "Author", "PRs", "Lines Changed", "Repos", "First Time In"},
+		Kinds:  []cellKind{numberCell, textCell, numberCell, numberCell},
+	}
+	for _, e := range leaderboard {
+		table.Rows = append(table.Rows, []string{
+			strconv.Itoa(e.Rank), e.Author, strconv.Itoa(e.PRs), strconv.Itoa(e.Changes),
+			strings.Join(e.Repos, ","), strings.Join(e.FirstTimeIn, ","),
+		})
+	}
+	return table
+}
+
+// printLeaderboard writes the leaderboard, marking first-time contributors
+func printLeaderboard(w io.Writer, leaderboard []leaderboardEntry) {
+	firstTimers := 0
+	fmt.Fprintln(w, "\nContributor leaderboard")
+	tw := tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(tw, "Rank\tAuthor\tPRs\tLines changed\t")
+	for _, e := range leaderboard {
+		note := ""
+		if len(e.FirstTimeIn) > 0 {
+			firstTimers++
+			note = "first time in " + strings.Join(e.FirstTimeIn, ", ")
+		}
+		fmt.Fprintf(tw, "%d\t%s\t%d\t%d\t%s\n", e.Rank, e.Author, e.PRs, e.Changes, note)
+	}
+	tw.Flush()
+	fmt.Fprintf(w, "%d contributors, %d of them first-timers\n", len(leaderboard), firstTimers)
+}
@@ -0,0 +1,63 @@
+package main
+
+import (
+	"bytes"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestComputeLeaderboard(t *testing.T) {
+	pr := func(repo, author string, changes int) PR {
+		return PR{Author: author, URL: "https://github.com/" + repo + "/pull/1", Additions: changes}
+	}
+	prs := []PR{
+		pr("acme/widgets", "alice", 10),
+		pr("acme/widgets", "alice", 10),
+		pr("acme/widgets", "bob", 50),
+		pr("acme/api", "carol", 5),
+		pr("acme/api", "carol", 5),
+		pr("acme/api", "bob", 1),
+		pr("acme/widgets", "dependabot[bot]", 3),
+		pr("acme/widgets", "app/renovate", 3),
+		pr("acme/widgets", "", 3),
+	}
+	firstTimers := map[string]map[string]bool{"acme/api": {"bob": true}, "acme/widgets": {"alice": true}}
+
+	leaderboard := computeLeaderboard(prs, firstTimers)
+	var got []string
+	for _, e := range leaderboard {
+		got = append(got, strings.Join([]string{e.Author, strconv.Itoa(e.Rank), strings.Join(e.FirstTimeIn, "+")}, ":"))
+	}
+	// bob and alice tie on PRs, and bob has the most lines changed; carol shares their rank
+	if want := "bob:1:acme/api,alice:1:acme/widgets,carol:1:"; strings.Join(got, ",") != want {
+		t.Errorf("leaderboard = %v, want %s", got, want)
+	}
+	if bob := leaderboard[0]; bob.PRs != 2 || bob.Changes != 51 || strings.Join(bob.Repos, ",") != "acme/api,acme/widgets" {
+		t.Errorf("bob = %+v", bob)
+	}
+}
+
+func TestRunStatsModeLeaderboard(t *testing.T) {
+	prs := makePRs(daysAgo(5), time.Hour, 3)
+	prs[0].Author, prs[1].Author, prs[2].Author = "alice", "alice", "bob"
+	earlier := makePRs(daysAgo(30), time.Hour, 1)
+	earlier[0].Number, earlier[0].URL, earlier[0].Author = "100", "https://github.com/acme/widgets/pull/100", "alice"
+	var out bytes.Buffer
+
+	opts := StatsOptions{List: testOptions(daysAgo(7), &fakeRunner{prs: append(prs, earlier...)}), Repos: []string{"acme/widgets"}, Leaderboard: true, Output: &out}
+	if err := runStatsMode(opts); err != nil {
+		t.Fatal(err)
+	}
+	for _, want := range []string{"Contributor leaderboard", "first time in acme/widgets", "2 contributors, 1 of them first-timers"} {
+		if !strings.Contains(out.String(), want) {
+			t.Errorf("printed stats missing %q:\n%s", want, out.String())
+		}
+	}
+	for _, line := range strings.Split(out.String(), "\n") {
+		if strings.Contains(line, "alice") && strings.Contains(line, "first time") {
+			t.Errorf("alice has earlier PRs: %s", line)
+		}
+	}
+}
@@ -0,0 +1,196 @@
+package main
+
+import (
+	"fmt"
+	"io"
+	"sort"
+	"strconv"
+	"strings"
+	"text/tabwriter"
+	"time"
+)
+
+// release is a published, non-prerelease GitHub release
+type release struct {
+	Tag         string
+	PublishedAt time.Time
+}
+
+// releasesJQ turns the releases API response into one tab-separated line per
+// release, leaving out drafts and prereleases
+const releasesJQ = `.[] | select((.draft or .prerelease) | not) | [.tag_name, .published_at] | @tsv`
+
+// fetchReleases fills in Release and ReleasedAt for each PR with the first
+// release published at or after its merge. The PRs must all be from opts.Repo.
+func fetchReleases(opts ListOptions, prs []PR) error {
+	opts.printf("Fetching releases for %s...\n", opts.Repo)
+	output, err := opts.runGH("api", fmt.Sprintf("repos/%s/releases", opts.Repo), "--paginate", "--jq", releasesJQ)
+	if err != nil {
+		return fmt.Errorf("error fetching releases: %v", err)
+	}
+	assignReleases(prs, parseReleases(output))
+	return nil
+}
+
+// parseReleases parses the output of the releases query, oldest first
+func parseReleases(output string) []release {
+	var releases []release
+	for _, line := range strings.Split(output, "\n") {
+		tag, published, ok := strings.Cut(line, "\t")
+		if !ok {
+			continue
+		}
+		at, err := time.Parse(time.RFC3339, published)
+		if err != nil {
+			continue
+		}
+		releases = append(releases, release{Tag: tag, PublishedAt: at})
+	}
+	sort.Slice(releases, func(i, j int) bool { return releases[i].PublishedAt.Before(releases[j].PublishedAt) })
+	return releases
+}
+
+// assignReleases sets each PR's release to the first one published at or
+// after its merge. This assumes PRs merge to the branch releases are cut
+// from; PRs merged after the latest release are left unreleased.
+func assignReleases(prs []PR, releases []release) {
+	for i := range prs {
+		merged, err := time.Parse(time.RFC3339, prs[i].MergedAt)
+		if err != nil {
+			continue
+		}
+		j := sort.Search(len(releases), func(j int) bool { return !releases[j].PublishedAt.Before(merged) })
+		if j < len(releases) {
+			prs[i].Release = releases[j].Tag
+			prs[i].ReleasedAt = releases[j].PublishedAt.Format(time.RFC3339)
+		}
+	}
+}
+
+// LeadTime returns the time from the start of work on the PR to its merge.
+// Work starts at the first commit, or when the PR was opened if that's
+// earlier or the commits weren't fetched.
+func (pr PR) LeadTime() (time.Duration, error) {
+	started, err := time.Parse(time.RFC3339, pr.CreatedAt)
+	if err != nil {
+		return 0, fmt.Errorf("invalid createdAt %q: %v", pr.CreatedAt, err)
+	}
+	if first, err := time.Parse(time.RFC3339, pr.FirstCommitAt); err == nil && first.Before(started) {
+		started = first
+	}
+	merged, err := time.Parse(time.RFC3339, pr.MergedAt)
+	if err != nil {
+		return 0, fmt.Errorf("invalid mergedAt %q: %v", pr.MergedAt, err)
+	}
+	return merged.Sub(started), nil
+}
+
+// leadTimes summarizes one repo's lead time for changes
+type leadTimes struct {
+	Group string
+	// ToMerge is the lead time from the first commit to the merge
+	ToMerge mergeTimes
+	// Released is the number of PRs in a release; MergeToRelease and
+	// ToRelease are measured over those PRs only
+	Released       int
+	MergeToRelease mergeTimes
+	ToRelease      mergeTimes
+}
+
+// computeLeadTimes measures lead time for changes by repo, alphabetically
+func computeLeadTimes(prs []PR) []leadTimes {
+	type durations struct{ toMerge, mergeToRelease, toRelease []time.Duration }
+	byRepo := make(map[string]*durations)
+	for _, pr := range prs {
+		lead, err := pr.LeadTime()
+		if err != nil {
+			continue
+		}
+		repo := repoFromURL(pr.URL)
+		if byRepo[repo] == nil {
+			byRepo[repo] = &durations{}
+		}
+		d := byRepo[repo]
+		d.toMerge = append(d.toMerge, lead)
+
+		merged, _ := time.Parse(time.RFC3339, pr.MergedAt)
+		if released, err := time.Parse(time.RFC3339, pr.ReleasedAt); err == nil {
+			d.mergeToRelease = append(d.mergeToRelease, released.Sub(merged))
+			d.toRelease = append(d.toRelease, lead+released.Sub(merged))
+		}
+	}
+
+	report := make([]leadTimes, 0, len(byRepo))
+	for repo, d := range byRepo {
+		report = append(report, leadTimes{
+			Group:          repo,
+			ToMerge:        newMergeTimes(repo, d.toMerge),
+			Released:       len(d.toRelease),
+			MergeToRelease: newMergeTimes(repo, d.mergeToRelease),
+			ToRelease:      newMergeTimes(repo, d.toRelease),
+		})
+	}
+	sort.Slice(report, func(i, j int) bool { return report[i].Group < report[j].Group })
+	return report
+}
+
+// leadTimeTable returns the lead times by repo, for saving, with times in
+// unit. The release columns are only included if releases were fetched.
+func leadTimeTable(report []leadTimes, unit string, releases bool) Table {
+	metrics := []string{"Lead Time"}
+	if releases {
+		metrics = append(metrics, "Merge To Release", "Lead Time To Release")
+	}
+	table := Table{Header: []string{"Repo", "PRs"}}
+	if releases {
+		table.Header = append(table.Header, "PRs Released")
+	}
+	for _, metric := range metrics {
+		for _, p := range []string{"p50", "p75", "p90"} {
+			table.Header = append(table.Header, fmt.Sprintf("%s %s (%s)", metric, p, unit))
+		}
+	}
+
+	for _, l := range report {
+		row := []string{l.Group, strconv.Itoa(l.ToMerge.PRs)}
+		times := []mergeTimes{l.ToMerge}
+		if releases {
+			row = append(row, strconv.Itoa(l.Released))
+			times = append(times, l.MergeToRelease, l.ToRelease)
+		}
+		for _, t := range times {
+			if t.PRs == 0 {
+				row = append(row, "", "", "")
+				continue
+			}
+			row = append(row, formatDuration(t.P50, unit), formatDuration(t.P75, unit), formatDuration(t.P90, unit))
+		}
+		table.Rows = append(table.Rows, row)
+	}
+	table.Kinds = textThenNumbers(1, len(table.Header))
+	return table
+}
+
+// printLeadTimes writes the lead time table with times in unit
+func printLeadTimes(w io.Writer, report []leadTimes, unit string, releases bool) {
+	fmt.Fprintf(w, "\nLead time for changes, first commit to merge (%s)\n", unit)
+	tw := tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
+	header := "Repo\tPRs\tp50\tp75\tp90"
+	if releases {
+		header += "\tReleased\tTo release p50\tp90"
+	}
+	fmt.Fprintln(tw, header)
+	for _, l := range report {
+		fmt.Fprintf(tw, "%s\t%d\t%s\t%s\t%s", l.Group, l.ToMerge.PRs,
+			formatDuration(l.ToMerge.P50, unit), formatDuration(l.ToMerge.P75, unit), formatDuration(l.ToMerge.P90, unit))
+		if releases {
+			toRelease := "-\t-"
+			if l.Released > 0 {
+				toRelease = formatDuration(l.ToRelease.P50, unit) + "\t" + formatDuration(l.ToRelease.P90, unit)
+			}
+			fmt.Fprintf(tw, "\t%d\t%s", l.Released, toRelease)
+		}
+		fmt.Fprintln(tw)
+	}
+	tw.Flush()
+}
@@ -0,0 +1,100 @@
+package main
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestLeadTime(t *testing.T) {
+	pr := PR{CreatedAt: "2024-03-04T10:00:00Z", MergedAt: "2024-03-05T10:00:00Z"}
+	for _, tc := range []struct {
+		firstCommit string
+		want        time.Duration
+	}{
+		{"", 24 * time.Hour},
+		{"2024-03-03T10:00:00Z", 48 * time.Hour},
+		{"2024-03-04T12:00:00Z", 24 * time.Hour}, // committed after opening, such as a draft PR
+	} {
+		pr.FirstCommitAt = tc.firstCommit
+		if got, err := pr.LeadTime(); err != nil || got != tc.want {
+			t.Errorf("first commit %q: LeadTime() = %v, %v, want %v", tc.firstCommit, got, err, tc.want)
+		}
+	}
+}
+
+func TestAssignReleases(t *testing.T) {
+	releases := parseReleases("v1.1.0\t2024-03-10T00:00:00Z\nv1.0.0\t2024-03-01T00:00:00Z\nmalformed\n")
+	if len(releases) != 2 || releases[0].Tag != "v1.0.0" {
+		t.Fatalf("parseReleases = %+v, want v1.0.0 then v1.1.0", releases)
+	}
+	prs := []PR{
+		{MergedAt: "2024-02-20T00:00:00Z"},
+		{MergedAt: "2024-03-01T00:00:00Z"}, // merged as the release was published
+		{MergedAt: "2024-03-05T00:00:00Z"},
+		{MergedAt: "2024-03-12T00:00:00Z"},
+	}
+	assignReleases(prs, releases)
+	var got []string
+	for _, pr := range prs {
+		got = append(got, pr.Release)
+	}
+	if strings.Join(got, ",") != "v1.0.0,v1.0.0,v1.1.0," {
+		t.Errorf("releases = %v", got)
+	}
+}
+
+func TestComputeLeadTimes(t *testing.T) {
+	prs := []PR{
+		{URL: "https://github.com/acme/widgets/pull/1", FirstCommitAt: "2024-03-03T00:00:00Z", CreatedAt: "2024-03-04T00:00:00Z", MergedAt: "2024-03-05T00:00:00Z", ReleasedAt: "2024-03-06T00:00:00Z"},
+		{URL: "https://github.com/acme/widgets/pull/2", CreatedAt: "2024-03-04T00:00:00Z", MergedAt: "2024-03-04T12:00:00Z"},
+		{URL: "https://github.com/acme/api/pull/3", CreatedAt: "2024-03-04T00:00:00Z", MergedAt: "invalid"},
+	}
+	report := computeLeadTimes(prs)
+	if len(report) != 1 || report[0].Group != "acme/widgets" {
+		t.Fatalf("computeLeadTimes = %+v, want acme/widgets only", report)
+	}
+	widgets := report[0]
+	if widgets.ToMerge.PRs != 2 || widgets.ToMerge.P50 != 12*time.Hour || widgets.ToMerge.P90 != 48*time.Hour {
+		t.Errorf("lead time = %+v", widgets.ToMerge)
+	}
+	if widgets.Released != 1 || widgets.MergeToRelease.P50 != 24*time.Hour || widgets.ToRelease.P50 != 72*time.Hour {
+		t.Errorf("released = %d, merge to release %+v, to release %+v", widgets.Released, widgets.MergeToRelease, widgets.ToRelease)
+	}
+
+	table := leadTimeTable(report, "hours", false)
+	if len(table.Header) != 5 || table.Rows[0][2] != "12.00" {
+		t.Errorf("table without releases = %+v", table)
+	}
+	if table := leadTimeTable(report, "hours", true); len(table.Header) != 12 {
+		t.Errorf("table with releases has headers %v", table.Header)
+	}
+}
+
+func TestRunStatsModeLeadTime(t *testing.T) {
+	prs := makePRs(daysAgo(5), time.Hour, 2)
+	for i := range prs {
+		prs[i].FirstCommitAt = daysAgo(6).Format(time.RFC3339)
+	}
+	runner := &fakeRunner{prs: prs, releases: []string{"v2.0.0\t" + daysAgo(1).Format(time.RFC3339)}}
+	outFile := filepath.Join(t.TempDir(), "lead-time.csv")
+	var out bytes.Buffer
+
+	opts := StatsOptions{List: testOptions(daysAgo(7), runner), Repos: []string{"acme/widgets"}, LeadTime: true, LeadTimeReleases: true, LeadTimeOutFile: outFile, Output: &out}
+	if err := runStatsMode(opts); err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(out.String(), "Lead time for changes") {
+		t.Errorf("printed stats missing lead time:\n%s", out.String())
+	}
+	data, err := os.ReadFile(outFile)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(string(data), "PRs Released") || !strings.Contains(string(data), "acme/widgets,2,2,") {
+		t.Errorf("saved lead time:\n%s", data)
+	}
+}
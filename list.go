@@ -1,41 +1,315 @@
 package main
 
 import (
-	"encoding/csv"
 	"fmt"
+	"io"
 	"os"
+	"strconv"
 	"strings"
 	"time"
 )
 
 // PR represents a pull request with its key information
 type PR struct {
-	Number   string
-	Title    string
-	MergedAt string
-	URL      string
+	Number    string
+	Title     string
+	MergedAt  string
+	CreatedAt string
+	URL       string
+	Additions int
+	Deletions int
+	// Optional fields, only populated when requested via ListOptions.Fields
+	Comments       int
+	ReviewComments int
+	Author         string
+	Labels         []string
+	FirstCommitAt  string // when the PR's earliest commit was authored
+	JiraKey        string // the first Jira key in the title, branch name, or body
+	// Reviews is only populated by stats mode's review latency report and
+	// the cycleTime field
+	Reviews []Review
+	// Release and ReleasedAt are the first release published after the merge,
+	// only populated by stats mode's lead time report
+	Release    string
+	ReleasedAt string
 }
 
-// fetchPRsForDateRange fetches PRs for a specific date range and returns them along with the count
-func fetchPRsForDateRange(startDate, endDate time.Time, repo, searchTerm string) ([]PR, int, error) {
+// TimeToMerge returns how long the PR was open before it was merged
+func (pr PR) TimeToMerge() (time.Duration, error) {
+	created, err := time.Parse(time.RFC3339, pr.CreatedAt)
+	if err != nil {
+		return 0, fmt.Errorf("invalid createdAt %q: %v", pr.CreatedAt, err)
+	}
+	merged, err := time.Parse(time.RFC3339, pr.MergedAt)
+	if err != nil {
+		return 0, fmt.Errorf("invalid mergedAt %q: %v", pr.MergedAt, err)
+	}
+	return merged.Sub(created), nil
+}
+
+// timeToMergeUnits maps the names accepted by -ttm-unit to their durations
+var timeToMergeUnits = map[string]time.Duration{
+	"minutes": time.Minute,
+	"hours":   time.Hour,
+	"days":    24 * time.Hour,
+}
+
+// column describes a CSV column and how to render its value for a PR
+type column struct {
+	Header string
+	Value  func(PR) string
+	// Kind types the value in JSON output; the zero value is text
+	Kind cellKind
+}
+
+// optionalFields maps the names accepted by -fields to their column definitions
+var optionalFields = map[string]column{
+	"comments": {
+		Header: "Comments",
+		Value:  func(pr PR) string { return strconv.Itoa(pr.Comments) },
+		Kind:   numberCell,
+	},
+	"reviewComments": {
+		Header: "Review Comments",
+		Value:  func(pr PR) string { return strconv.Itoa(pr.ReviewComments) },
+		Kind:   numberCell,
+	},
+	"author": {
+		Header: "Author",
+		Value:  func(pr PR) string { return pr.Author },
+	},
+	"labels": {
+		Header: "Labels",
+		Value:  func(pr PR) string { return strings.Join(pr.Labels, ",") },
+	},
+	"firstCommit": {
+		Header: "First Commit",
+		Value:  func(pr PR) string { return pr.FirstCommitAt },
+	},
+}
+
+// multiColumnFields maps the names accepted by -fields that add several
+// columns to a function returning them, with times in unit
+var multiColumnFields = map[string]func(opts ListOptions, unit string) []column{
+	"cycleTime": func(opts ListOptions, unit string) []column { return cycleTimeColumns(unit) },
+	"jira":      func(opts ListOptions, unit string) []column { return jiraColumns(opts.JiraURL) },
+}
+
+// parseFields parses a comma-separated list of optional field names
+func parseFields(value string) ([]string, error) {
+	var fields []string
+	for _, name := range strings.Split(value, ",") {
+		name = strings.TrimSpace(name)
+		if name == "" {
+			continue
+		}
+		_, single := optionalFields[name]
+		_, multi := multiColumnFields[name]
+		if !single && !multi {
+			return nil, fmt.Errorf("unknown field %q", name)
+		}
+		fields = append(fields, name)
+	}
+	return fields, nil
+}
+
+// Changes returns the total number of lines changed in the PR
+func (pr PR) Changes() int {
+	return pr.Additions + pr.Deletions
+}
+
+// ListOptions holds the parameters for a list mode run
+type ListOptions struct {
+	Since      time.Time
+	Until      time.Time // zero means now
+	Repo       string
+	SearchTerm string
+	Limit      int // 0 means no limit
+	MinChanges int // 0 means no minimum
+	MaxChanges int // 0 means no maximum
+	Fields     []string
+	// JiraURL is the Jira site the jira field links keys to, such as
+	// https://acme.atlassian.net; JiraProjects limits the keys to those projects
+	JiraURL      string
+	JiraProjects []string
+	// TimeToMergeUnit is one of the keys of timeToMergeUnits; empty means hours
+	TimeToMergeUnit string
+	// OutDir is the directory results are saved to; empty means defaultOutputDir
+	OutDir string
+	// Format is the name of a registered Writer to save results with; empty means csv
+	Format string
+	// Force overwrites an existing results file instead of saving alongside it
+	Force bool
+	// DryRun prints the planned queries and output path instead of fetching
+	DryRun bool
+	// Progress receives progress messages; nil means stdout
+	Progress io.Writer
+	// Runner runs the gh commands that fetch PRs; nil means the installed gh
+	Runner CommandRunner
+}
+
+// runGH runs a gh command with opts.Runner
+func (o ListOptions) runGH(args ...string) (string, error) {
+	runner := o.Runner
+	if runner == nil {
+		runner = ghRunner{}
+	}
+	output, err := runner.Run(args...)
+	metrics.observeGHCommand(err)
+	return output, err
+}
+
+// printf writes a progress message to opts.Progress
+func (o ListOptions) printf(format string, args ...any) {
+	w := o.Progress
+	if w == nil {
+		w = os.Stdout
+	}
+	fmt.Fprintf(w, format, args...)
+}
+
+// until returns when the search ends
+func (o ListOptions) until() time.Time {
+	if o.Until.IsZero() {
+		return time.Now()
+	}
+	return o.Until
+}
+
+// outputDir returns the directory results are saved to
+func (o ListOptions) outputDir() string {
+	if o.OutDir == "" {
+		return defaultOutputDir
+	}
+	return o.OutDir
+}
+
+// limitReached reports whether the configured result limit has been hit
+func (o ListOptions) limitReached(count int) bool {
+	return o.Limit > 0 && count >= o.Limit
+}
+
+// outputColumns returns the CSV columns for a run: the standard columns,
+// the computed time to merge, and then any requested optional fields
+func outputColumns(opts ListOptions) []column {
+	unit := opts.TimeToMergeUnit
+	if unit == "" {
+		unit = "hours"
+	}
+
+	columns := []column{
+		{Header: "PR Number", Value: func(pr PR) string { return pr.Number }, Kind: numberCell},
+		{Header: "Title", Value: func(pr PR) string { return pr.Title }},
+		{Header: "Merged At", Value: func(pr PR) string { return pr.MergedAt }},
+		{Header: "URL", Value: func(pr PR) string { return pr.URL }},
+		{
+			Header: fmt.Sprintf("Time To Merge (%s)", unit),
+			Value: func(pr PR) string {
+				d, err := pr.TimeToMerge()
+				if err != nil {
+					return ""
+				}
+				return strconv.FormatFloat(float64(d)/float64(timeToMergeUnits[unit]), 'f', 2, 64)
+			},
+			Kind: numberCell,
+		},
+	}
+	for _, name := range opts.Fields {
+		if multi, ok := multiColumnFields[name]; ok {
+			columns = append(columns, multi(opts, unit)...)
+			continue
+		}
+		columns = append(columns, optionalFields[name])
+	}
+	return columns
+}
+
+// hasField reports whether the given optional field was requested
+func (o ListOptions) hasField(name string) bool {
+	for _, field := range o.Fields {
+		if field == name {
+			return true
+		}
+	}
+	return false
+}
+
+// filtersSize reports whether a size range was set
+func (o ListOptions) filtersSize() bool {
+	return o.MinChanges > 0 || o.MaxChanges > 0
+}
+
+// matchesSize reports whether the PR's lines changed fall within the configured range
+func (o ListOptions) matchesSize(pr PR) bool {
+	if o.MinChanges > 0 && pr.Changes() < o.MinChanges {
+		return false
+	}
+	if o.MaxChanges > 0 && pr.Changes() > o.MaxChanges {
+		return false
+	}
+	return true
+}
+
+// prListArgs builds the gh arguments that list merged PRs for a date range,
+// along with the number of tab-separated fields each output line will have
+func prListArgs(startDate, endDate time.Time, opts ListOptions, limit int) ([]string, int) {
 	startStr := startDate.Format("2006-01-02")
 	endStr := endDate.Format("2006-01-02")
 
 	// Build search query for this date range
 	searchQuery := fmt.Sprintf("merged:%s..%s", startStr, endStr)
-	if searchTerm != "" {
-		searchQuery += " " + searchTerm
+	if opts.SearchTerm != "" {
+		searchQuery += " " + opts.SearchTerm
 	}
 
-	// Get merged PRs for this date range
-	output, err := runGHCommand(
+	// Comment counts come back in the same query; review comments are not
+	// available from pr list and are fetched per PR afterwards
+	jsonFields := "number,title,mergedAt,url,additions,deletions,createdAt"
+	jqFields := ".number, .title, .mergedAt, .url, .additions, .deletions, .createdAt"
+	fieldCount := 7
+	if opts.hasField("comments") {
+		jsonFields += ",comments"
+		jqFields += ", (.comments | length)"
+		fieldCount++
+	}
+	// Cycle time needs the author to leave out their own reviews
+	if opts.hasField("author") || opts.hasField("cycleTime") {
+		jsonFields += ",author"
+		jqFields += ", .author.login"
+		fieldCount++
+	}
+	if opts.hasField("labels") {
+		jsonFields += ",labels"
+		jqFields += `, (.labels | map(.name) | join(","))`
+		fieldCount++
+	}
+	if opts.hasField("firstCommit") {
+		jsonFields += ",commits"
+		jqFields += `, ([.commits[].authoredDate] | min // "")`
+		fieldCount++
+	}
+	if opts.hasField("jira") {
+		jsonFields += ",headRefName,body"
+		jqFields += ", " + jiraCandidatesJQ
+		fieldCount++
+	}
+
+	return []string{
 		"pr", "list",
-		"--repo", repo,
+		"--repo", opts.Repo,
 		"--search", searchQuery,
-		"--json", "number,title,mergedAt,url",
-		"--jq", ".[] | [.number, .title, .mergedAt, .url] | @tsv",
-		"--limit", "1000",
-	)
+		"--json", jsonFields,
+		"--jq", fmt.Sprintf(".[] | [%s] | @tsv", jqFields),
+		"--limit", strconv.Itoa(limit),
+	}, fieldCount
+}
+
+// fetchPRsForDateRange fetches PRs for a specific date range and returns them along with the count
+func fetchPRsForDateRange(startDate, endDate time.Time, opts ListOptions, limit int) ([]PR, int, error) {
+	args, fieldCount := prListArgs(startDate, endDate, opts, limit)
+
+	// Get merged PRs for this date range
+	output, err := opts.runGH(args...)
 	if err != nil {
 		return nil, 0, err
 	}
@@ -47,23 +321,53 @@ func fetchPRsForDateRange(startDate, endDate time.Time, repo, searchTerm string)
 			continue
 		}
 		fields := strings.Split(line, "\t")
-		if len(fields) != 4 {
+		if len(fields) != fieldCount {
 			continue
 		}
+		additions, _ := strconv.Atoi(fields[4])
+		deletions, _ := strconv.Atoi(fields[5])
+
+		pr := PR{
+			Number:    fields[0],
+			Title:     fields[1],
+			MergedAt:  fields[2],
+			URL:       fields[3],
+			Additions: additions,
+			Deletions: deletions,
+			CreatedAt: fields[6],
+		}
+		// Optional fields follow the standard ones in the order prListArgs adds them
+		optional := fields[7:]
+		if opts.hasField("comments") {
+			pr.Comments, _ = strconv.Atoi(optional[0])
+			optional = optional[1:]
+		}
+		if opts.hasField("author") || opts.hasField("cycleTime") {
+			pr.Author = optional[0]
+			optional = optional[1:]
+		}
+		if opts.hasField("labels") {
+			if optional[0] != "" {
+				pr.Labels = strings.Split(optional[0], ",")
+			}
+			optional = optional[1:]
+		}
+		if opts.hasField("firstCommit") {
+			pr.FirstCommitAt = optional[0]
+			optional = optional[1:]
+		}
+		if opts.hasField("jira") {
+			pr.JiraKey = findJiraKey(opts.JiraProjects, pr.Title, optional[0])
+		}
 
-		prs = append(prs, PR{
-			Number:   fields[0],
-			Title:    fields[1],
-			MergedAt: fields[2],
-			URL:      fields[3],
-		})
+		prs = append(prs, pr)
 	}
 
 	return prs, len(prs), nil
 }
 
 // fetchPRsRecursive fetches PRs for a date range, recursively splitting if we hit the 1000 limit
-func fetchPRsRecursive(startDate, endDate time.Time, repo, searchTerm string, seenPRs map[string]bool, allPRs *[]PR, depth int) error {
+func fetchPRsRecursive(startDate, endDate time.Time, opts ListOptions, seenPRs map[string]bool, allPRs *[]PR, depth int) error {
 	// Prevent infinite recursion
 	if depth > 10 {
 		return fmt.Errorf("maximum recursion depth reached for date range %s to %s", startDate.Format("2006-01-02"), endDate.Format("2006-01-02"))
@@ -72,7 +376,15 @@ func fetchPRsRecursive(startDate, endDate time.Time, repo, searchTerm string, se
 	startStr := startDate.Format("2006-01-02")
 	endStr := endDate.Format("2006-01-02")
 
-	prs, count, err := fetchPRsForDateRange(startDate, endDate, repo, searchTerm)
+	// Only ask for as many PRs as we still need when a limit is set. With a
+	// size filter the results are filtered afterwards, so a smaller query
+	// could miss matches; the limit is then applied to the filtered PRs.
+	fetchLimit := 1000
+	if opts.Limit > 0 && !opts.filtersSize() && opts.Limit-len(*allPRs) < fetchLimit {
+		fetchLimit = opts.Limit - len(*allPRs)
+	}
+
+	prs, count, err := fetchPRsForDateRange(startDate, endDate, opts, fetchLimit)
 	if err != nil {
 		return fmt.Errorf("error fetching PRs for %s to %s: %v", startStr, endStr, err)
 	}
@@ -83,19 +395,24 @@ func fetchPRsRecursive(startDate, endDate time.Time, repo, searchTerm string, se
 		duration := endDate.Sub(startDate)
 		if duration < 24*time.Hour {
 			// Can't split further (less than a day), warn and continue
-			fmt.Printf("  Warning: Hit 1000 PR limit for %s to %s (less than 1 day, cannot split further)\n", startStr, endStr)
+			opts.printf("  Warning: Hit 1000 PR limit for %s to %s (less than 1 day, cannot split further)\n", startStr, endStr)
+			events.Warn("chunk_limit_hit", "start", startStr, "end", endStr, "depth", depth)
 		} else {
 			// Split in half and fetch both halves
 			midpoint := startDate.Add(duration / 2)
-			fmt.Printf("  Hit 1000 PR limit for %s to %s, splitting into smaller chunks...\n", startStr, endStr)
+			opts.printf("  Hit 1000 PR limit for %s to %s, splitting into smaller chunks...\n", startStr, endStr)
+			events.Info("chunk_split", "start", startStr, "end", endStr, "depth", depth)
 
 			// Fetch first half
-			if err := fetchPRsRecursive(startDate, midpoint, repo, searchTerm, seenPRs, allPRs, depth+1); err != nil {
+			if err := fetchPRsRecursive(startDate, midpoint, opts, seenPRs, allPRs, depth+1); err != nil {
 				return err
 			}
+			if opts.limitReached(len(*allPRs)) {
+				return nil
+			}
 
 			// Fetch second half (add 1 second to avoid overlap)
-			if err := fetchPRsRecursive(midpoint.Add(time.Second), endDate, repo, searchTerm, seenPRs, allPRs, depth+1); err != nil {
+			if err := fetchPRsRecursive(midpoint.Add(time.Second), endDate, opts, seenPRs, allPRs, depth+1); err != nil {
 				return err
 			}
 
@@ -103,9 +420,17 @@ func fetchPRsRecursive(startDate, endDate time.Time, repo, searchTerm string, se
 		}
 	}
 
-	// Add PRs that we haven't seen before
+	// Add PRs that we haven't seen before and that match the size filter.
+	// Filtering happens here rather than in the query so the 1000 limit check
+	// above still sees the unfiltered count.
 	newCount := 0
 	for _, pr := range prs {
+		if opts.limitReached(len(*allPRs)) {
+			break
+		}
+		if !opts.matchesSize(pr) {
+			continue
+		}
 		if !seenPRs[pr.URL] {
 			*allPRs = append(*allPRs, pr)
 			seenPRs[pr.URL] = true
@@ -114,75 +439,123 @@ func fetchPRsRecursive(startDate, endDate time.Time, repo, searchTerm string, se
 	}
 
 	if depth == 0 {
-		fmt.Printf("  Found %d PRs in this chunk (total so far: %d)\n", newCount, len(*allPRs))
+		opts.printf("  Found %d PRs in this chunk (total so far: %d)\n", newCount, len(*allPRs))
 	}
+	events.Info("chunk_fetched", "start", startStr, "end", endStr, "depth", depth, "count", count, "new", newCount, "total", len(*allPRs))
 
 	return nil
 }
 
+// dateRange is a span of time covered by one search query
+type dateRange struct {
+	Start time.Time
+	End   time.Time
+}
+
+// monthlyChunks splits the time from since until now into one-month ranges
+func monthlyChunks(since, now time.Time) []dateRange {
+	var chunks []dateRange
+	for currentStart := since; currentStart.Before(now); {
+		// Calculate end date for this chunk (one month later, or now if that's earlier)
+		currentEnd := currentStart.AddDate(0, 1, 0)
+		if currentEnd.After(now) {
+			currentEnd = now
+		}
+		chunks = append(chunks, dateRange{Start: currentStart, End: currentEnd})
+		currentStart = currentEnd
+	}
+	return chunks
+}
+
+// planMergedPRs prints the chunk plan and gh commands getMergedPRs would run,
+// without running them. Chunks that turn out to hit the 1000 result limit are
+// split further at run time, which can't be planned ahead.
+func planMergedPRs(opts ListOptions) {
+	chunks := monthlyChunks(opts.Since, opts.until())
+	opts.printf("Would fetch %d monthly chunks:\n", len(chunks))
+	for i, chunk := range chunks {
+		fetchLimit := 1000
+		if opts.Limit > 0 && !opts.filtersSize() && opts.Limit < fetchLimit {
+			fetchLimit = opts.Limit
+		}
+		args, _ := prListArgs(chunk.Start, chunk.End, opts, fetchLimit)
+		opts.printf("  Chunk %d: %s to %s\n", i+1, chunk.Start.Format("2006-01-02"), chunk.End.Format("2006-01-02"))
+		opts.printf("    %s\n", formatGHCommand(args...))
+	}
+	if opts.Limit > 0 {
+		opts.printf("Would stop once %d PRs have been collected\n", opts.Limit)
+	}
+	if opts.hasField("reviewComments") {
+		opts.printf("Would then run %s for each PR\n", formatGHCommand("api", fmt.Sprintf("repos/%s/pulls/<number>", opts.Repo), "--jq", ".review_comments"))
+	}
+	if opts.hasField("cycleTime") {
+		opts.printf("Would then run %s for each PR\n", formatGHCommand("api", fmt.Sprintf("repos/%s/pulls/<number>/reviews", opts.Repo), "--paginate", "--jq", reviewsJQ))
+	}
+}
+
 // getMergedPRs fetches merged PRs from GitHub for the specified repository and date range
 // To work around GitHub's 1000 result limit, this function splits the date range into
 // monthly chunks and fetches PRs for each chunk separately. If a chunk hits the limit,
-// it recursively splits that chunk into smaller pieces.
-func getMergedPRs(sinceDate time.Time, repo string, searchTerm string) ([]PR, error) {
-	now := time.Now()
+// it recursively splits that chunk into smaller pieces. If opts.Limit is set, fetching
+// stops as soon as that many PRs have been collected.
+func getMergedPRs(opts ListOptions) ([]PR, error) {
+	started := time.Now()
 	var allPRs []PR
+	var chunkErr error // the last chunk failure, for metrics
 
 	// Use a map to track seen PRs by URL to avoid duplicates
 	seenPRs := make(map[string]bool)
 
 	// Split the date range into monthly chunks to avoid hitting the 1000 result limit
-	currentStart := sinceDate
+	chunks := monthlyChunks(opts.Since, opts.until())
 	chunkCount := 0
 
-	for currentStart.Before(now) {
+	for _, chunk := range chunks {
 		chunkCount++
-		// Calculate end date for this chunk (one month later, or now if that's earlier)
-		currentEnd := currentStart.AddDate(0, 1, 0)
-		if currentEnd.After(now) {
-			currentEnd = now
-		}
+		startStr := chunk.Start.Format("2006-01-02")
+		endStr := chunk.End.Format("2006-01-02")
 
-		startStr := currentStart.Format("2006-01-02")
-		endStr := currentEnd.Format("2006-01-02")
-
-		fmt.Printf("Fetching PRs for chunk %d: %s to %s...\n", chunkCount, startStr, endStr)
+		opts.printf("Fetching PRs for chunk %d: %s to %s...\n", chunkCount, startStr, endStr)
 
 		// Fetch PRs for this chunk (with recursive splitting if needed)
-		if err := fetchPRsRecursive(currentStart, currentEnd, repo, searchTerm, seenPRs, &allPRs, 0); err != nil {
-			fmt.Printf("Warning: Error fetching PRs for %s to %s: %v\n", startStr, endStr, err)
+		if err := fetchPRsRecursive(chunk.Start, chunk.End, opts, seenPRs, &allPRs, 0); err != nil {
+			chunkErr = err
+			opts.printf("Warning: Error fetching PRs for %s to %s: %v\n", startStr, endStr, err)
+			events.Error("chunk_failed", "start", startStr, "end", endStr, "error", err.Error())
 		}
 
-		// Move to next chunk
-		currentStart = currentEnd
+		if opts.limitReached(len(allPRs)) {
+			opts.printf("Reached limit of %d PRs, stopping.\n", opts.Limit)
+			events.Info("limit_reached", "limit", opts.Limit)
+			break
+		}
 	}
 
-	fmt.Printf("\nTotal PRs fetched: %d\n", len(allPRs))
-	return allPRs, nil
-}
+	opts.printf("\nTotal PRs fetched: %d\n", len(allPRs))
+	events.Info("fetch_completed", "repo", opts.Repo, "chunks", chunkCount, "total", len(allPRs))
 
-// saveToCSV saves the PR list to a CSV file
-func saveToCSV(prs []PR, outputFile string) error {
-	file, err := os.Create(outputFile)
-	if err != nil {
-		return err
+	if opts.hasField("reviewComments") {
+		fetchReviewCommentCounts(opts, allPRs)
 	}
-	defer file.Close()
-
-	writer := csv.NewWriter(file)
-	defer writer.Flush()
-
-	// Write header
-	if err := writer.Write([]string{"PR Number", "Title", "Merged At", "URL"}); err != nil {
-		return err
+	if opts.hasField("cycleTime") {
+		fetchReviews(opts, allPRs)
 	}
 
-	// Write PR data
-	for _, pr := range prs {
-		if err := writer.Write([]string{pr.Number, pr.Title, pr.MergedAt, pr.URL}); err != nil {
-			return err
+	metrics.observeFetch(time.Since(started), len(allPRs), chunkErr)
+	return allPRs, nil
+}
+
+// fetchReviewCommentCounts fills in ReviewComments for each PR. The search API
+// used by pr list does not expose this, so it takes one API call per PR.
+func fetchReviewCommentCounts(opts ListOptions, prs []PR) {
+	opts.printf("Fetching review comment counts for %d PRs...\n", len(prs))
+	for i := range prs {
+		output, err := opts.runGH("api", fmt.Sprintf("repos/%s/pulls/%s", opts.Repo, prs[i].Number), "--jq", ".review_comments")
+		if err != nil {
+			opts.printf("  Warning: Error fetching review comments for PR #%s: %v\n", prs[i].Number, err)
+			events.Warn("review_comments_failed", "number", prs[i].Number, "error", err.Error())
+			continue
 		}
+		prs[i].ReviewComments, _ = strconv.Atoi(output)
 	}
-
-	return nil
 }
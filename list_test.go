@@ -0,0 +1,437 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"reflect"
+	"slices"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+)
+
+// fakeRunner answers gh pr list queries from a fixed set of PRs, the way the
+// search API does: merged:A..B matches PRs merged on dates A through B
+// inclusive, and at most --limit results come back.
+type fakeRunner struct {
+	prs []PR
+	// fail makes queries whose search starts with any of these ranges return an error
+	fail []string
+	// reviewComments answers gh api calls for review comment counts, by PR number
+	reviewComments map[string]int
+	// reviews answers gh api calls for reviews with output lines, by PR number
+	reviews map[string][]string
+	// releases answers gh api calls for releases with output lines
+	releases []string
+	// commitDates answers gh api calls for a ref's commit date, by ref
+	commitDates map[string]string
+
+	calls   int      // how many commands were run
+	queries []string // the --search value of each pr list call, in order
+	limits  []string // the --limit value of each pr list call, in order
+}
+
+func (f *fakeRunner) Run(args ...string) (string, error) {
+	f.calls++
+	if args[0] == "api" && strings.HasSuffix(args[1], "/reviews") {
+		number := strings.Split(args[1], "/")[4]
+		lines, ok := f.reviews[number]
+		if !ok {
+			return "", errors.New("not found")
+		}
+		return strings.Join(lines, "\n"), nil
+	}
+	if args[0] == "api" && strings.Contains(args[1], "/commits/") {
+		date, ok := f.commitDates[args[1][strings.LastIndex(args[1], "/")+1:]]
+		if !ok {
+			return "", errors.New("HTTP 422: No commit found")
+		}
+		return date, nil
+	}
+	if args[0] == "api" && strings.HasSuffix(args[1], "/releases") {
+		return strings.Join(f.releases, "\n"), nil
+	}
+	if args[0] == "api" {
+		number := args[1][strings.LastIndex(args[1], "/")+1:]
+		count, ok := f.reviewComments[number]
+		if !ok {
+			return "", errors.New("not found")
+		}
+		return strconv.Itoa(count), nil
+	}
+
+	search := flagValue(args, "--search")
+	f.queries = append(f.queries, search)
+	f.limits = append(f.limits, flagValue(args, "--limit"))
+	for _, prefix := range f.fail {
+		if strings.HasPrefix(search, "merged:"+prefix) {
+			return "", errors.New("HTTP 502")
+		}
+	}
+
+	// Open PRs are those without a merge time, created before the searched day
+	if flagValue(args, "--state") == "open" {
+		before := strings.TrimPrefix(search, "created:<")
+		var lines []string
+		for _, pr := range f.prs {
+			if pr.MergedAt == "" && pr.CreatedAt[:10] < before {
+				lines = append(lines, strings.Join([]string{pr.Number, pr.Title, pr.CreatedAt, pr.URL, strconv.Itoa(pr.Additions), strconv.Itoa(pr.Deletions), pr.Author, "false"}, "\t"))
+			}
+		}
+		return strings.Join(lines, "\n"), nil
+	}
+
+	// Searches for an author's earlier PRs answer with how many there are
+	if author, before, ok := strings.Cut(strings.TrimPrefix(search, "author:"), " merged:<"); ok {
+		count := 0
+		for _, pr := range f.prs {
+			if pr.Author == author && pr.MergedAt[:10] < before {
+				count++
+			}
+		}
+		return strconv.Itoa(count), nil
+	}
+
+	dates, _, _ := strings.Cut(strings.TrimPrefix(search, "merged:"), " ")
+	start, end, _ := strings.Cut(dates, "..")
+	limit, _ := strconv.Atoi(flagValue(args, "--limit"))
+	withComments := strings.Contains(flagValue(args, "--json"), "comments")
+	withAuthor := strings.Contains(flagValue(args, "--json"), "author")
+	withLabels := strings.Contains(flagValue(args, "--json"), "labels")
+	withCommits := strings.Contains(flagValue(args, "--json"), "commits")
+	withBody := strings.Contains(flagValue(args, "--json"), "body")
+
+	var lines []string
+	for _, pr := range f.prs {
+		if len(lines) == limit {
+			break
+		}
+		if day := pr.MergedAt[:10]; day < start || day > end {
+			continue
+		}
+		fields := []string{pr.Number, pr.Title, pr.MergedAt, pr.URL, strconv.Itoa(pr.Additions), strconv.Itoa(pr.Deletions), pr.CreatedAt}
+		if withComments {
+			fields = append(fields, strconv.Itoa(pr.Comments))
+		}
+		if withAuthor {
+			fields = append(fields, pr.Author)
+		}
+		if withLabels {
+			fields = append(fields, strings.Join(pr.Labels, ","))
+		}
+		if withCommits {
+			fields = append(fields, pr.FirstCommitAt)
+		}
+		// JiraKey stands in for the candidate keys jq finds in the branch and body
+		if withBody {
+			fields = append(fields, pr.JiraKey)
+		}
+		lines = append(lines, strings.Join(fields, "\t"))
+	}
+	return strings.Join(lines, "\n"), nil
+}
+
+// flagValue returns the argument following name
+func flagValue(args []string, name string) string {
+	if i := slices.Index(args, name); i >= 0 && i+1 < len(args) {
+		return args[i+1]
+	}
+	return ""
+}
+
+// makePRs returns count PRs merged at evenly spaced times from start, step apart
+func makePRs(start time.Time, step time.Duration, count int) []PR {
+	prs := make([]PR, count)
+	for i := range prs {
+		merged := start.Add(time.Duration(i) * step)
+		prs[i] = PR{
+			Number:    strconv.Itoa(i + 1),
+			Title:     fmt.Sprintf("PR %d", i+1),
+			MergedAt:  merged.Format(time.RFC3339),
+			CreatedAt: merged.Add(-time.Hour).Format(time.RFC3339),
+			URL:       fmt.Sprintf("https://github.com/acme/widgets/pull/%d", i+1),
+			Additions: i,
+			Deletions: 1,
+		}
+	}
+	return prs
+}
+
+// testOptions returns list options for acme/widgets that use runner and discard progress
+func testOptions(since time.Time, runner CommandRunner) ListOptions {
+	return ListOptions{Since: since, Repo: "acme/widgets", Progress: io.Discard, Runner: runner}
+}
+
+// daysAgo returns midnight UTC the given number of days before today
+func daysAgo(days int) time.Time {
+	return time.Now().UTC().Truncate(24*time.Hour).AddDate(0, 0, -days)
+}
+
+func TestMonthlyChunks(t *testing.T) {
+	since := time.Date(2024, 1, 15, 0, 0, 0, 0, time.UTC)
+	now := time.Date(2024, 4, 1, 12, 0, 0, 0, time.UTC)
+
+	chunks := monthlyChunks(since, now)
+	want := []dateRange{
+		{since, time.Date(2024, 2, 15, 0, 0, 0, 0, time.UTC)},
+		{time.Date(2024, 2, 15, 0, 0, 0, 0, time.UTC), time.Date(2024, 3, 15, 0, 0, 0, 0, time.UTC)},
+		{time.Date(2024, 3, 15, 0, 0, 0, 0, time.UTC), now},
+	}
+	if !slices.Equal(chunks, want) {
+		t.Fatalf("monthlyChunks = %v, want %v", chunks, want)
+	}
+
+	if chunks := monthlyChunks(now, now); len(chunks) != 0 {
+		t.Errorf("monthlyChunks with since == now = %v, want no chunks", chunks)
+	}
+}
+
+func TestGetMergedPRsDeduplicatesChunkBoundaries(t *testing.T) {
+	since := daysAgo(70)
+	// One PR a day, so the PRs on each chunk boundary date match both chunks' queries
+	runner := &fakeRunner{prs: makePRs(since.Add(12*time.Hour), 24*time.Hour, 70)}
+
+	prs, err := getMergedPRs(testOptions(since, runner))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(runner.queries) != 3 {
+		t.Errorf("ran %d queries, want one per monthly chunk (3): %v", len(runner.queries), runner.queries)
+	}
+	if len(prs) != 70 {
+		t.Fatalf("got %d PRs, want 70", len(prs))
+	}
+	seen := make(map[string]bool)
+	for _, pr := range prs {
+		if seen[pr.URL] {
+			t.Errorf("PR %s returned more than once", pr.URL)
+		}
+		seen[pr.URL] = true
+	}
+}
+
+func TestGetMergedPRsSplitsChunksAtResultLimit(t *testing.T) {
+	since := daysAgo(20)
+	// 2400 PRs in 20 days is more than one query can return
+	runner := &fakeRunner{prs: makePRs(since, 10*time.Minute, 2400)}
+
+	prs, err := getMergedPRs(testOptions(since, runner))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(prs) != 2400 {
+		t.Fatalf("got %d PRs, want all 2400", len(prs))
+	}
+	if len(runner.queries) < 3 {
+		t.Errorf("ran %d queries, want the full chunk then its halves: %v", len(runner.queries), runner.queries)
+	}
+}
+
+func TestGetMergedPRsStopsSplittingAtOneDay(t *testing.T) {
+	since := daysAgo(1)
+	// More than 1000 PRs merged on one day can't be split further by date
+	runner := &fakeRunner{prs: makePRs(since, time.Minute, 1200)}
+
+	prs, err := getMergedPRs(testOptions(since, runner))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(prs) != 1000 {
+		t.Errorf("got %d PRs, want the 1000 a single query returns", len(prs))
+	}
+}
+
+func TestGetMergedPRsLimit(t *testing.T) {
+	since := daysAgo(90)
+	runner := &fakeRunner{prs: makePRs(since.Add(time.Hour), 24*time.Hour, 90)}
+	opts := testOptions(since, runner)
+	opts.Limit = 25
+
+	prs, err := getMergedPRs(opts)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(prs) != 25 {
+		t.Errorf("got %d PRs, want the limit of 25", len(prs))
+	}
+	if len(runner.queries) != 1 {
+		t.Errorf("ran %d queries, want to stop after the first chunk: %v", len(runner.queries), runner.queries)
+	}
+	if runner.limits[0] != "25" {
+		t.Errorf("first query asked for %s results, want 25", runner.limits[0])
+	}
+}
+
+func TestGetMergedPRsSizeFilter(t *testing.T) {
+	since := daysAgo(10)
+	runner := &fakeRunner{prs: makePRs(since.Add(time.Hour), time.Hour, 100)}
+	opts := testOptions(since, runner)
+	// Changes are the PR index plus one deletion, so 1 to 100
+	opts.MinChanges = 11
+	opts.MaxChanges = 20
+
+	prs, err := getMergedPRs(opts)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(prs) != 10 {
+		t.Fatalf("got %d PRs, want the 10 with 11-20 lines changed", len(prs))
+	}
+	for _, pr := range prs {
+		if pr.Changes() < 11 || pr.Changes() > 20 {
+			t.Errorf("PR %s has %d lines changed, outside 11-20", pr.Number, pr.Changes())
+		}
+	}
+}
+
+func TestGetMergedPRsLimitWithSizeFilter(t *testing.T) {
+	since := daysAgo(10)
+	runner := &fakeRunner{prs: makePRs(since.Add(time.Hour), time.Hour, 100)}
+	opts := testOptions(since, runner)
+	// Only the last 51 PRs have 50 or more lines changed
+	opts.MinChanges = 50
+	opts.Limit = 5
+
+	prs, err := getMergedPRs(opts)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(prs) != 5 {
+		t.Fatalf("got %d PRs, want 5 of the 51 with 50 or more lines changed", len(prs))
+	}
+	for _, pr := range prs {
+		if pr.Changes() < 50 {
+			t.Errorf("PR %s has %d lines changed, under 50", pr.Number, pr.Changes())
+		}
+	}
+	for _, limit := range runner.limits {
+		if limit != "1000" {
+			t.Errorf("queries used --limit %s, want 1000 so the size filter sees every PR", limit)
+		}
+	}
+}
+
+func TestGetMergedPRsContinuesAfterChunkError(t *testing.T) {
+	since := daysAgo(70)
+	runner := &fakeRunner{
+		prs:  makePRs(since.Add(12*time.Hour), 24*time.Hour, 70),
+		fail: []string{since.Format("2006-01-02")},
+	}
+
+	prs, err := getMergedPRs(testOptions(since, runner))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(runner.queries) != 3 {
+		t.Errorf("ran %d queries, want every chunk tried: %v", len(runner.queries), runner.queries)
+	}
+	// The failed first chunk covers its first month, minus the boundary day the second chunk also matches
+	secondChunk := since.AddDate(0, 1, 0).Format("2006-01-02")
+	for _, pr := range prs {
+		if pr.MergedAt[:10] < secondChunk {
+			t.Errorf("PR %s merged %s came from the failed chunk", pr.Number, pr.MergedAt)
+		}
+	}
+	if len(prs) == 0 {
+		t.Error("got no PRs, want the later chunks' PRs")
+	}
+}
+
+func TestFetchPRsForDateRangeParsesFields(t *testing.T) {
+	merged := time.Date(2024, 3, 5, 10, 0, 0, 0, time.UTC)
+	pr := PR{
+		Number:    "42",
+		Title:     "Fix the frobnicator",
+		MergedAt:  merged.Format(time.RFC3339),
+		CreatedAt: merged.Add(-3 * time.Hour).Format(time.RFC3339),
+		URL:       "https://github.com/acme/widgets/pull/42",
+		Additions: 10,
+		Deletions: 4,
+		Comments:  7,
+		Author:    "octocat",
+		Labels:    []string{"bug", "p1"},
+	}
+	opts := testOptions(merged, &fakeRunner{prs: []PR{pr}})
+	opts.Fields = []string{"labels", "author", "comments"}
+
+	prs, count, err := fetchPRsForDateRange(merged, merged, opts, 1000)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if count != 1 || len(prs) != 1 {
+		t.Fatalf("got %d PRs (count %d), want 1", len(prs), count)
+	}
+	if !reflect.DeepEqual(prs[0], pr) {
+		t.Errorf("parsed %+v, want %+v", prs[0], pr)
+	}
+}
+
+func TestFetchPRsForDateRangeSkipsMalformedLines(t *testing.T) {
+	runner := runnerFunc(func(args ...string) (string, error) {
+		return "1\tonly two fields\n\n2\tTitle\t2024-03-05T10:00:00Z\thttps://github.com/acme/widgets/pull/2\t1\t2\t2024-03-05T09:00:00Z", nil
+	})
+	day := time.Date(2024, 3, 5, 0, 0, 0, 0, time.UTC)
+
+	prs, _, err := fetchPRsForDateRange(day, day, testOptions(day, runner), 1000)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(prs) != 1 || prs[0].Number != "2" {
+		t.Errorf("got %+v, want only PR 2", prs)
+	}
+}
+
+func TestFetchReviewCommentCounts(t *testing.T) {
+	prs := makePRs(time.Date(2024, 3, 5, 0, 0, 0, 0, time.UTC), time.Hour, 3)
+	runner := &fakeRunner{reviewComments: map[string]int{"1": 4, "3": 9}}
+
+	fetchReviewCommentCounts(testOptions(time.Time{}, runner), prs)
+
+	// PR 2's lookup fails and is left at zero
+	for i, want := range []int{4, 0, 9} {
+		if prs[i].ReviewComments != want {
+			t.Errorf("PR %s has %d review comments, want %d", prs[i].Number, prs[i].ReviewComments, want)
+		}
+	}
+}
+
+// runnerFunc adapts a function to a CommandRunner
+type runnerFunc func(args ...string) (string, error)
+
+func (f runnerFunc) Run(args ...string) (string, error) {
+	return f(args...)
+}
+
+func TestPlanMergedPRsRunsNothing(t *testing.T) {
+	runner := &fakeRunner{prs: makePRs(daysAgo(20), time.Hour, 3)}
+	var out strings.Builder
+	opts := testOptions(daysAgo(40), runner)
+	opts.Until = daysAgo(0)
+	opts.Progress = &out
+	opts.Limit = 5
+	opts.Fields = []string{"reviewComments"}
+
+	planMergedPRs(opts)
+	if runner.calls != 0 {
+		t.Errorf("dry run ran %d commands", runner.calls)
+	}
+	chunks := monthlyChunks(opts.Since, opts.until())
+	want := []string{
+		fmt.Sprintf("Would fetch %d monthly chunks:\n", len(chunks)),
+		fmt.Sprintf("  Chunk 1: %s to %s\n", chunks[0].Start.Format("2006-01-02"), chunks[0].End.Format("2006-01-02")),
+		"    gh pr list --repo acme/widgets --search merged:" + chunks[0].Start.Format("2006-01-02") + ".." + chunks[0].End.Format("2006-01-02") + " ",
+		"--limit 5",
+		"Would stop once 5 PRs have been collected\n",
+		"Would then run gh api 'repos/acme/widgets/pulls/<number>' --jq .review_comments for each PR\n",
+	}
+	for _, w := range want {
+		if !strings.Contains(out.String(), w) {
+			t.Errorf("plan is missing %q:\n%s", w, out.String())
+		}
+	}
+	if n := strings.Count(out.String(), "gh pr list"); n != len(chunks) {
+		t.Errorf("plan has %d gh pr list commands, want one per chunk (%d)", n, len(chunks))
+	}
+}
@@ -4,9 +4,13 @@ import (
 	"bufio"
 	"flag"
 	"fmt"
+	"io"
 	"log"
 	"os"
 	"path/filepath"
+	"regexp"
+	"slices"
+	"strconv"
 	"strings"
 	"time"
 )
@@ -21,67 +25,45 @@ func promptUser(prompt string) string {
 	return strings.TrimSpace(input)
 }
 
-func promptDate() (time.Time, error) {
-	for {
-		dateStr := promptUser("Enter start date (YYYY-MM-DD): ")
-		date, err := time.Parse("2006-01-02", dateStr)
-		if err != nil {
-			fmt.Println("Invalid date format. Please use YYYY-MM-DD")
-			continue
-		}
-		if date.After(time.Now()) {
-			fmt.Println("Error: The date cannot be in the future")
-			continue
-		}
-		return date, nil
+// runListMode fetches merged PRs for the given options, saves them, and sends
+// a summary to each notifier
+func runListMode(opts ListOptions, notifiers []Notifier) {
+	if opts.DryRun {
+		fmt.Println("Dry run: nothing will be fetched or written.")
 	}
-}
-
-func promptRepo() string {
-	for {
-		repo := promptUser("Enter repository (owner/repo): ")
-		if !strings.Contains(repo, "/") {
-			fmt.Println("Invalid repository format. Please use owner/repo")
-			continue
-		}
-		return repo
+	fmt.Printf("Fetching PRs merged since %s for %s...\n", opts.Since.Format("2006-01-02"), opts.Repo)
+	if opts.SearchTerm != "" {
+		fmt.Printf("Filtering for search term: %s\n", opts.SearchTerm)
+	}
+	if opts.Limit > 0 {
+		fmt.Printf("Limiting results to %d PRs\n", opts.Limit)
+	}
+	if opts.MinChanges > 0 || opts.MaxChanges > 0 {
+		fmt.Printf("Filtering for PR size: %s\n", describeSizeRange(opts.MinChanges, opts.MaxChanges))
 	}
-}
-
-func promptSearchTerm() string {
-	searchTerm := promptUser("Enter search term (optional, press Enter to skip): ")
-	return strings.TrimSpace(searchTerm)
-}
 
-func promptCSVFile() string {
-	for {
-		file := promptUser("Enter path to CSV file: ")
-		if _, err := os.Stat(file); os.IsNotExist(err) {
-			fmt.Println("File does not exist. Please enter a valid file path.")
-			continue
+	if opts.DryRun {
+		planMergedPRs(opts)
+		fmt.Printf("Would save results to %s\n", listOutputFile(opts))
+		if len(notifiers) > 0 {
+			fmt.Printf("Would send a summary to %d notification destinations\n", len(notifiers))
 		}
-		return file
+		return
 	}
-}
 
-func handleListMode() {
-	fmt.Println("\n=== List Mode ===")
-	fmt.Println("This mode will fetch PRs and save them to a CSV file.")
-
-	sinceDate, err := promptDate()
-	if err != nil {
-		log.Fatalf("Error with date input: %v", err)
+	// Check the output directory first so a bad one doesn't waste a fetch
+	if err := prepareOutputDir(opts.outputDir()); err != nil {
+		log.Fatalf("Error: %v", err)
 	}
 
-	repo := promptRepo()
-	searchTerm := promptSearchTerm()
-
-	fmt.Printf("\nFetching PRs merged since %s for %s...\n", sinceDate.Format("2006-01-02"), repo)
-	if searchTerm != "" {
-		fmt.Printf("Filtering for search term: %s\n", searchTerm)
+	// Replays answer from recorded responses and don't need gh at all
+	if _, replaying := opts.Runner.(replayRunner); !replaying {
+		if err := checkGHReady(); err != nil {
+			log.Fatalf("Error: %v", err)
+		}
 	}
 
-	prs, err := getMergedPRs(sinceDate, repo, searchTerm)
+	prs, err := getMergedPRs(opts)
 	if err != nil {
 		log.Fatalf("Error getting PRs: %v", err)
 	}
@@ -91,43 +73,130 @@ func handleListMode() {
 		return
 	}
 
-	// Create generated/csv directory if it doesn't exist
-	if err := os.MkdirAll("generated/csv", 0755); err != nil {
-		log.Fatalf("Error creating output directory: %v", err)
+	outputFile, err := writeListResults(prs, opts)
+	if err != nil {
+		log.Fatalf("Error saving results: %v", err)
 	}
+	fmt.Printf("Results saved to %s\n", outputFile)
+	events.Info("results_saved", "file", outputFile, "count", len(prs))
+	addOutput(outputFile)
+
+	notifyAll(notifiers, runSummary{Repo: opts.Repo, Since: opts.Since, PRs: prs, File: outputFile})
+}
 
-	csvFile := filepath.Join("generated/csv", fmt.Sprintf("merged_prs_%s_%s.csv",
-		strings.Replace(repo, "/", "_", -1),
-		sinceDate.Format("20060102")))
-	if searchTerm != "" {
-		csvFile = filepath.Join("generated/csv", fmt.Sprintf("%s_%s.csv",
-			strings.TrimSuffix(filepath.Base(csvFile), ".csv"),
-			strings.Replace(searchTerm, " ", "_", -1)))
+// setupRunner returns the CommandRunner for -record or -replay, and for
+// replays the recording time to use in place of now. Neither flag means the
+// installed gh, returned as nil.
+func setupRunner(recordDir, replayDir string) (CommandRunner, time.Time) {
+	switch {
+	case recordDir != "" && replayDir != "":
+		log.Fatalf("Use either -record or -replay, not both")
+	case recordDir != "":
+		runner, err := newRecordingRunner(recordDir)
+		if err != nil {
+			log.Fatalf("Error setting up -record: %v", err)
+		}
+		return runner, time.Time{}
+	case replayDir != "":
+		runner, until, err := loadReplayRunner(replayDir)
+		if err != nil {
+			log.Fatalf("Error setting up -replay: %v", err)
+		}
+		fmt.Printf("Replaying responses recorded at %s\n", until.Format(time.RFC3339))
+		return runner, until
 	}
+	return nil, time.Time{}
+}
 
-	if err := saveToCSV(prs, csvFile); err != nil {
-		log.Fatalf("Error saving to CSV: %v", err)
+// relativeDatePattern matches relative start dates such as 7d or 4w
+var relativeDatePattern = regexp.MustCompile(`^([0-9]+)([dw])$`)
+
+// parseSinceDate parses a start date in YYYY-MM-DD format, or relative to today
+// as a number of days or weeks such as 7d or 4w, which suits recurring profiles
+func parseSinceDate(value string) (time.Time, error) {
+	match := relativeDatePattern.FindStringSubmatch(value)
+	if match == nil {
+		return time.Parse("2006-01-02", value)
+	}
+
+	days, err := strconv.Atoi(match[1])
+	if err != nil {
+		return time.Time{}, err
+	}
+	if match[2] == "w" {
+		days *= 7
+	}
+	now := time.Now().UTC()
+	today := time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, time.UTC)
+	return today.AddDate(0, 0, -days), nil
+}
+
+// writeListResults saves the PRs from a list mode run and returns the file written
+func writeListResults(prs []PR, opts ListOptions) (string, error) {
+	// Create the output directory if it doesn't exist
+	if err := os.MkdirAll(opts.outputDir(), 0755); err != nil {
+		return "", fmt.Errorf("error creating output directory: %v", err)
+	}
+
+	outputFile := listOutputFile(opts)
+	if err := saveResults(prs, opts, outputFile); err != nil {
+		return "", fmt.Errorf("error saving results: %v", err)
 	}
-	fmt.Printf("Results saved to %s\n", csvFile)
+	return outputFile, nil
 }
 
-func handleOpenMode() {
-	fmt.Println("\n=== Open Mode ===")
-	fmt.Println("This mode will open PR URLs from a CSV file in your browser.")
+// listOutputFile returns the file a list mode run writes to. The repo and
+// search term are sanitized so the name is valid on Windows too. An existing
+// file is only replaced with opts.Force; otherwise a numbered name is used.
+func listOutputFile(opts ListOptions) string {
+	name := fmt.Sprintf("merged_prs_%s_%s", opts.Repo, opts.Since.Format("20060102"))
+	if opts.SearchTerm != "" {
+		name += "_" + opts.SearchTerm
+	}
+	ext := ".csv"
+	if writer, err := lookupWriter(opts.Format); err == nil {
+		ext = writer.Extension()
+	}
+	outputFile := filepath.Join(opts.outputDir(), sanitizeFilename(name)+ext)
+	if opts.Force {
+		return outputFile
+	}
+	return unusedPath(outputFile)
+}
 
-	csvFile := promptCSVFile()
+// describeSizeRange formats a lines-changed range for display
+func describeSizeRange(minChanges, maxChanges int) string {
+	switch {
+	case maxChanges == 0:
+		return fmt.Sprintf("at least %d lines changed", minChanges)
+	case minChanges == 0:
+		return fmt.Sprintf("at most %d lines changed", maxChanges)
+	default:
+		return fmt.Sprintf("%d to %d lines changed", minChanges, maxChanges)
+	}
+}
 
-	if err := openPRsFromCSV(csvFile); err != nil {
-		log.Fatalf("Error opening PRs: %v", err)
+// validateListFlags checks the -min-changes, -max-changes, and -ttm-unit
+// values shared by the modes that fetch merged PRs
+func validateListFlags(minChanges, maxChanges int, ttmUnit string) error {
+	if minChanges < 0 || maxChanges < 0 {
+		return fmt.Errorf("invalid size range: -min-changes and -max-changes must be 0 or greater")
+	}
+	if maxChanges > 0 && minChanges > maxChanges {
+		return fmt.Errorf("invalid size range: -min-changes %d is greater than -max-changes %d", minChanges, maxChanges)
 	}
+	if _, ok := timeToMergeUnits[ttmUnit]; !ok {
+		return fmt.Errorf("invalid -ttm-unit %q: must be minutes, hours, or days", ttmUnit)
+	}
+	return nil
 }
 
 func main() {
 	// Define flags with both long and short versions
-	mode := flag.String("mode", "", "Operation mode: 'list' to get PR list, 'open' to open URLs from CSV")
+	mode := flag.String("mode", "", "Operation mode: 'list' to get PR list, 'open' to open URLs from CSV, 'stats' to summarize merged PRs by author and week, 'report' to save an HTML dashboard of merged PRs, 'stale' to report PRs open longer than a threshold, 'release-notes' to write Markdown release notes, 'changelog' to write a CHANGELOG section from conventional-commit PR titles, 'watch' to poll for newly merged PRs, 'webhook' to receive merged PRs from GitHub webhooks, 'serve' to serve PR lists over HTTP, 'doctor' to check the environment")
 	modeShort := flag.String("m", "", "Shorthand for -mode")
 
-	sinceDateStr := flag.String("since", "", "Start date in YYYY-MM-DD format (for list mode)")
+	sinceDateStr := flag.String("since", "", "Start date in YYYY-MM-DD format, or relative to today like 7d or 4w (for list and stats mode)")
 	sinceDateStrShort := flag.String("s", "", "Shorthand for -since")
 
 	repo := flag.String("repo", "", "GitHub repository in owner/repo format (for list mode)")
@@ -136,13 +205,179 @@ func main() {
 	searchTerm := flag.String("search", "", "Optional search term (for list mode)")
 	searchTermShort := flag.String("q", "", "Shorthand for -search (query)")
 
-	urlsFile := flag.String("urls", "", "CSV file containing PR URLs (for open mode)")
+	limit := flag.Int("limit", 0, "Maximum number of PRs to fetch across all chunks, 0 for no limit (for list mode)")
+	minChanges := flag.Int("min-changes", 0, "Only include PRs with at least this many lines changed (for list mode)")
+	maxChanges := flag.Int("max-changes", 0, "Only include PRs with at most this many lines changed, 0 for no maximum (for list mode)")
+	fields := flag.String("fields", "", "Comma-separated optional columns to add: comments, reviewComments, author, labels, firstCommit, cycleTime, jira (for list mode)")
+	jiraURL := flag.String("jira-url", os.Getenv("JIRA_BASE_URL"), "Jira site to link the keys found by -fields jira to, such as https://acme.atlassian.net (default: $JIRA_BASE_URL)")
+	jiraProjectsFlag := flag.String("jira-projects", "", "Comma-separated Jira project keys, such as PROJ,OPS, to limit -fields jira to instead of anything shaped like a key")
+	ttmUnit := flag.String("ttm-unit", "hours", "Units for the time to merge column: minutes, hours, or days (for list and stats mode)")
+	outDir := flag.String("out-dir", "", "Directory to save results in, created if needed (for list and report mode, default: outDir from the config file, or generated/csv)")
+	format := flag.String("format", "csv", "Format to save results in: "+strings.Join(writerNames(), ", ")+", or template (for list mode)")
+	templateFile := flag.String("template", "", "text/template file to render results with; implies -format template (for list mode)")
+	force := flag.Bool("force", false, "Overwrite an existing results file with the same name instead of adding a -1, -2, ... suffix (for list and report mode)")
+	recordDir := flag.String("record", "", "Save every gh response to this directory for later -replay (for list and serve mode)")
+	replayDir := flag.String("replay", "", "Answer gh commands from responses saved with -record instead of running gh (for list and serve mode)")
+	addr := flag.String("addr", "", "Address to listen on (for serve mode, default 127.0.0.1:8080, and webhook mode, default :8080; in watch mode, serves /metrics only if set)")
+	serveToken := flag.String("serve-token", os.Getenv("SERVE_TOKEN"), "Bearer token /prs requests have to send in an Authorization header (for serve mode, default: $SERVE_TOKEN)")
+	serveMaxDays := flag.Int("serve-max-days", 366, "Most days back a /prs request's since can reach, or 0 for no cap (for serve mode)")
+	serveMaxLimit := flag.Int("serve-max-limit", 1000, "Most PRs a /prs request can ask for, and the limit for requests without one, or 0 for no cap (for serve mode)")
+	slackWebhook := flag.String("slack-webhook", os.Getenv("SLACK_WEBHOOK_URL"), "Slack incoming webhook URL to post a summary of the results to (for list, watch, and webhook mode, default: $SLACK_WEBHOOK_URL)")
+	teamsWebhook := flag.String("teams-webhook", os.Getenv("TEAMS_WEBHOOK_URL"), "Microsoft Teams incoming webhook URL to post a summary of the results to (for list, watch, and webhook mode, default: $TEAMS_WEBHOOK_URL)")
+	discordWebhook := flag.String("discord-webhook", os.Getenv("DISCORD_WEBHOOK_URL"), "Discord webhook URL to post a summary of the results to (for list, watch, and webhook mode, default: $DISCORD_WEBHOOK_URL)")
+	emailTo := flag.String("email-to", "", "Comma-separated addresses to email a summary of the results to, with the results attached (for list, watch, and webhook mode; needs -smtp-addr)")
+	emailFrom := flag.String("email-from", os.Getenv("SMTP_FROM"), "Sender address for -email-to (default: $SMTP_FROM, or $SMTP_USERNAME)")
+	smtpAddr := flag.String("smtp-addr", os.Getenv("SMTP_ADDR"), "SMTP server to send email through as host:port, authenticating with $SMTP_USERNAME and $SMTP_PASSWORD if set (default: $SMTP_ADDR)")
+	postResults := flag.String("post-results", "", "URL to POST the results to as JSON (for list, watch, and webhook mode)")
+	postPayload := flag.String("post-payload", "results", "What -post-results sends: results for every PR, or summary for the count and largest PRs")
+	postHeaders := headerFlags{}
+	flag.Var(postHeaders, "post-header", "Header to send with -post-results as \"Name: Value\", expanding $VARIABLES; can be repeated")
+	webhookSecret := flag.String("webhook-secret", os.Getenv("GITHUB_WEBHOOK_SECRET"), "Secret configured on the GitHub webhook, used to verify deliveries (for webhook mode, default: $GITHUB_WEBHOOK_SECRET)")
+	statsOut := flag.String("stats-out", "", "Also save the per-author stats to this file, as CSV, JSON, or Markdown by its extension (for stats mode)")
+	statsJSON := flag.String("stats-json", "", "Also save every stats aggregate to this file as one JSON document with a stable schema, for CI checks; - prints it instead of the tables (for stats mode)")
+	trendOut := flag.String("trend-out", "", "Also save the merged PR counts for each week or month to this file, like -stats-out (for stats mode)")
+	sizesOut := flag.String("sizes-out", "", "Also save the weekly PR size histograms to this file, like -stats-out (for stats mode)")
+	leaderboard := flag.Bool("leaderboard", false, "Also rank contributors and flag first-timers with no earlier merged PRs in the repo, taking one search per contributor (for stats mode)")
+	leaderboardOut := flag.String("leaderboard-out", "", "Also save the contributor leaderboard to this file, like -stats-out; implies -leaderboard (for stats mode)")
+	compareTo := flag.String("compare-to", "", "Also report the change in PRs, authors, and median time to merge from another range of the same length: previous, or its start date (for stats mode)")
+	chartsDir := flag.String("charts", "", "Also save the charts as image files in this directory (for stats and report mode)")
+	chartFormat := flag.String("chart-format", "svg", "File format for -charts: svg or png")
+	reviews := flag.Bool("reviews", false, "Also report time to first review and from approval to merge per repo and reviewer, taking one API call per PR (for stats mode)")
+	reviewsOut := flag.String("reviews-out", "", "Also save the review latency report to this file, like -stats-out; implies -reviews (for stats mode)")
+	leadTime := flag.Bool("lead-time", false, "Also report lead time for changes, from each PR's first commit to its merge, per repo (for stats mode)")
+	leadTimeReleases := flag.Bool("lead-time-releases", false, "Also measure lead time to the first release published after each merge; implies -lead-time (for stats mode)")
+	leadTimeOut := flag.String("lead-time-out", "", "Also save the lead time report to this file, like -stats-out; implies -lead-time (for stats mode)")
+	olderThan := flag.String("older-than", "7d", "Report PRs open longer than this, in days or weeks like 7d or 2w, or a duration like 36h (for stale mode)")
+	prState := flag.String("state", "merged", "PRs to report: merged for those that took longer than -older-than to merge, or open for those still open past it (for stale mode)")
+	staleOut := flag.String("stale-out", "", "Also save the stale PR report to this file, like -stats-out (for stale mode)")
+	fromRef := flag.String("from", "", "Tag, git ref, or YYYY-MM-DD date to start the release notes after, instead of -since (for release-notes and changelog mode)")
+	toRef := flag.String("to", "", "Tag, git ref, or YYYY-MM-DD date to end the release notes at (for release-notes and changelog mode, default: now)")
+	interval := flag.Duration("interval", 15*time.Minute, "Time between polls (for watch mode)")
+
+	urlsFile := flag.String("urls", "", "CSV file containing PR URLs, or - to read from stdin (for open mode)")
 	urlsFileShort := flag.String("u", "", "Shorthand for -urls")
+	opener := flag.String("opener", "", "Command used to open each URL, e.g. \"firefox --new-tab\" (for open mode, default: open/xdg-open/start)")
+	browser := flag.String("browser", "", "Open URLs in a specific browser instead of the default: chrome or firefox (for open mode)")
+	profile := flag.String("profile-dir", "", "Browser profile to open URLs in: Chrome profile directory or Firefox profile name (for open mode, needs -browser)")
+	newWindow := flag.Bool("new-window", false, "Open the URLs in a new browser window (for open mode, needs -browser)")
+	delay := flag.Duration("delay", time.Second, "Time to wait between opening URLs, e.g. 500ms or 0 (for open mode)")
+	reverse := flag.Bool("reverse", false, "Open URLs in reverse CSV order (for open mode)")
+	batch := flag.Int("batch", 0, "Pause after opening this many URLs, 0 for no batching (for open mode)")
+	batchWait := flag.Duration("batch-wait", 0, "Time to pause between batches or repo groups, 0 to wait for Enter (for open mode)")
+	restart := flag.Bool("restart", false, "Ignore progress saved by a previous session and start from the first row (for open mode)")
+	rows := flag.String("rows", "", "CSV data rows to open, e.g. 25-60, 25-, or -60 (for open mode)")
+	startAt := flag.Int("start-at", 0, "CSV data row to start opening from, same as -rows N- (for open mode)")
+	filter := flag.String("filter", "", "Only open PRs whose title or URL contains this text, ignoring case (for open mode)")
+	printOnly := flag.Bool("print", false, "Print the resolved URLs instead of opening them (for open mode)")
+	groupBy := flag.String("group-by", "", "Set to 'repo' to open one repo's PRs at a time, pausing between repos (for open mode)")
+	period := flag.String("period", "week", "Group merges over time by 'week' or 'month' (for stats and report mode)")
+	tab := flag.String("tab", "", "PR tab to open: conversation, files, commits, or checks (for open mode)")
 
 	interactive := flag.Bool("i", false, "Run in interactive mode")
+	showVersion := flag.Bool("version", false, "Print version and build information and exit")
+	profileName := flag.String("profile", "", "Name of a profile in the config file whose settings to use; explicit flags take precedence")
+	configFile := flag.String("config", defaultConfigPath(), "Path to the config file holding profiles")
+	upload := flag.String("upload", "", "Also upload the files a run saves, and any -record fixtures, to s3://bucket/prefix with the aws CLI or gs://bucket/prefix with the gcloud CLI (for list, stats, report, and stale mode)")
+	dryRun := flag.Bool("dry-run", false, "Print the queries, output paths, and actions a run would perform without doing them")
+	logFormat := flag.String("log-format", "text", "Set to 'json' to also write machine-readable progress events to stderr")
 
 	flag.Parse()
 
+	// Set up logging first, so every error after this, fatal ones included,
+	// comes out in the requested format
+	if err := setLogFormat(*logFormat); err != nil {
+		log.Fatalf("Invalid -log-format: %v", err)
+	}
+
+	if *showVersion {
+		printVersion()
+		return
+	}
+
+	// Doctor reports a broken config file itself instead of failing on it
+	doctorMode := flag.Arg(0) == "doctor" || *mode == "doctor" || *modeShort == "doctor"
+	config, err := loadStartupConfig(*configFile, *profileName != "", doctorMode)
+	if err != nil {
+		log.Fatalf("Error loading config: %v", err)
+	}
+	if *profileName != "" && config != nil {
+		if err := applyProfile(flag.CommandLine, config, *profileName); err != nil {
+			log.Fatalf("Error loading profile: %v", err)
+		}
+		// The profile may have set -log-format itself
+		if err := setLogFormat(*logFormat); err != nil {
+			log.Fatalf("Invalid -log-format: %v", err)
+		}
+	}
+
+	// Flags and profiles take precedence over the config file's default
+	if *outDir == "" && config != nil {
+		*outDir = config.OutDir
+	}
+	if *outDir != "" {
+		var err error
+		if *outDir, err = expandHome(*outDir); err != nil {
+			log.Fatalf("Invalid -out-dir: %v", err)
+		}
+	}
+
+	jiraProjects, err := parseJiraProjects(*jiraProjectsFlag)
+	if err != nil {
+		log.Fatalf("Invalid -jira-projects: %v", err)
+	}
+
+	var target *uploadTarget
+	if *upload != "" {
+		t, err := parseUploadTarget(*upload)
+		if err != nil {
+			log.Fatalf("Invalid -upload: %v", err)
+		}
+		if !*dryRun {
+			if err := checkUploadReady(t); err != nil {
+				log.Fatalf("Error: %v", err)
+			}
+		}
+		target = &t
+	}
+
+	// Notifiers are sent a summary of the PRs found by list, watch, and webhook mode
+	var notifiers []Notifier
+	if *slackWebhook != "" {
+		notifiers = append(notifiers, slackNotifier{WebhookURL: *slackWebhook})
+	}
+	if *teamsWebhook != "" {
+		notifiers = append(notifiers, teamsNotifier{WebhookURL: *teamsWebhook})
+	}
+	if *discordWebhook != "" {
+		notifiers = append(notifiers, discordNotifier{WebhookURL: *discordWebhook})
+	}
+	if *emailTo != "" {
+		email := emailNotifier{
+			Addr:     *smtpAddr,
+			From:     *emailFrom,
+			Username: os.Getenv("SMTP_USERNAME"),
+			Password: os.Getenv("SMTP_PASSWORD"),
+		}
+		for _, to := range strings.Split(*emailTo, ",") {
+			if to = strings.TrimSpace(to); to != "" {
+				email.To = append(email.To, to)
+			}
+		}
+		if email.From == "" {
+			email.From = email.Username
+		}
+		if email.Addr == "" || email.From == "" {
+			log.Fatalf("-email-to needs -smtp-addr and -email-from (or $SMTP_ADDR and $SMTP_FROM)")
+		}
+		notifiers = append(notifiers, email)
+	}
+	if *postResults != "" {
+		if *postPayload != "results" && *postPayload != "summary" {
+			log.Fatalf("Invalid -post-payload %q: must be results or summary", *postPayload)
+		}
+		notifiers = append(notifiers, resultsNotifier{URL: *postResults, Headers: postHeaders, SummaryOnly: *postPayload == "summary"})
+	}
+
 	// Use shorthand values if provided
 	if *modeShort != "" {
 		*mode = *modeShort
@@ -159,10 +394,14 @@ func main() {
 	if *urlsFileShort != "" {
 		*urlsFile = *urlsFileShort
 	}
+	// "github-pr-grabber doctor" reads better than -mode doctor
+	if flag.Arg(0) == "doctor" {
+		*mode = "doctor"
+	}
 
 	// If no flags are provided or interactive mode is requested, run interactively
 	if *interactive || (flag.NFlag() == 0 && !flag.Parsed()) {
-		runInteractiveMode()
+		runInteractiveMode(*outDir)
 		return
 	}
 
@@ -180,7 +419,36 @@ func main() {
 			os.Exit(1)
 		}
 
-		sinceDate, err := time.Parse("2006-01-02", *sinceDateStr)
+		if *limit < 0 {
+			log.Fatalf("Invalid limit %d: must be 0 or greater", *limit)
+		}
+		if err := validateListFlags(*minChanges, *maxChanges, *ttmUnit); err != nil {
+			log.Fatalf("Error: %v", err)
+		}
+
+		extraFields, err := parseFields(*fields)
+		if err != nil {
+			log.Fatalf("Invalid -fields value: %v", err)
+		}
+		if *templateFile != "" || *format == "template" {
+			if *templateFile == "" {
+				log.Fatalf("-format template needs -template")
+			}
+			if *format != "csv" && *format != "template" {
+				log.Fatalf("Use either -template or -format %s, not both", *format)
+			}
+			writer, err := newTemplateWriter(*templateFile)
+			if err != nil {
+				log.Fatalf("Invalid -template: %v", err)
+			}
+			registerWriter("template", writer)
+			*format = "template"
+		}
+		if _, err := lookupWriter(*format); err != nil {
+			log.Fatalf("Invalid -format: %v", err)
+		}
+
+		sinceDate, err := parseSinceDate(*sinceDateStr)
 		if err != nil {
 			log.Fatalf("Invalid date format: %v", err)
 		}
@@ -189,58 +457,461 @@ func main() {
 			log.Fatalf("Error: The date %s is in the future", sinceDate.Format("2006-01-02"))
 		}
 
-		fmt.Printf("Fetching PRs merged since %s for %s...\n", sinceDate.Format("2006-01-02"), *repo)
-		if *searchTerm != "" {
-			fmt.Printf("Filtering for search term: %s\n", *searchTerm)
+		runner, until := setupRunner(*recordDir, *replayDir)
+
+		runListMode(ListOptions{
+			Since:      sinceDate,
+			Until:      until,
+			Repo:       *repo,
+			SearchTerm: *searchTerm,
+			Limit:      *limit,
+			MinChanges: *minChanges,
+			MaxChanges: *maxChanges,
+			Fields:     extraFields,
+			OutDir:     *outDir,
+			Format:     *format,
+			Force:      *force,
+
+			JiraURL:         *jiraURL,
+			JiraProjects:    jiraProjects,
+			TimeToMergeUnit: *ttmUnit,
+			DryRun:          *dryRun,
+			Runner:          runner,
+		}, notifiers)
+
+	case "open":
+		if *urlsFile == "" {
+			fmt.Println("Usage for open mode:")
+			fmt.Println("  ./github-pr-grabber -mode open -urls <csv_file>")
+			fmt.Println("  or using shorthand flags:")
+			fmt.Println("  ./github-pr-grabber -m open -u <csv_file>")
+			fmt.Println("  or")
+			fmt.Println("  ./github-pr-grabber -i")
+			flag.PrintDefaults()
+			os.Exit(1)
+		}
+
+		if *delay < 0 {
+			log.Fatalf("Invalid delay %s: must be 0 or greater", *delay)
+		}
+		if *batch < 0 || *batchWait < 0 {
+			log.Fatalf("Invalid batching: -batch and -batch-wait must be 0 or greater")
+		}
+		if *browser != "" && !slices.Contains(supportedBrowsers, *browser) {
+			log.Fatalf("Invalid -browser %q: must be one of %s", *browser, strings.Join(supportedBrowsers, ", "))
+		}
+		if *browser != "" && *opener != "" {
+			log.Fatalf("Use either -browser or -opener, not both")
+		}
+		if *browser == "" && (*profile != "" || *newWindow) {
+			log.Fatalf("-profile-dir and -new-window need -browser")
+		}
+		if *groupBy != "" && *groupBy != "repo" {
+			log.Fatalf("Invalid -group-by %q: only 'repo' is supported", *groupBy)
 		}
+		if *urlsFile == "-" && (*batch > 0 || *groupBy != "") && *batchWait == 0 {
+			log.Fatalf("-batch and -group-by need -batch-wait when reading URLs from stdin, since stdin can't also be used to wait for Enter")
+		}
+
+		var rowRange RowRange
+		if *rows != "" && *startAt != 0 {
+			log.Fatalf("Use either -rows or -start-at, not both")
+		}
+		if *rows != "" {
+			var err error
+			if rowRange, err = parseRowRange(*rows); err != nil {
+				log.Fatalf("Invalid -rows value: %v", err)
+			}
+		}
+		if *startAt < 0 {
+			log.Fatalf("Invalid -start-at %d: must be 1 or greater", *startAt)
+		}
+		rowRange.Start = max(rowRange.Start, *startAt)
 
-		prs, err := getMergedPRs(sinceDate, *repo, *searchTerm)
+		if *tab != "" && !slices.Contains(prTabs, *tab) {
+			log.Fatalf("Invalid -tab %q: must be one of %s", *tab, strings.Join(prTabs, ", "))
+		}
+
+		if err := openPRsFromCSV(OpenOptions{
+			CSVFile: *urlsFile,
+			Opener:  *opener,
+			Delay:   *delay,
+			Reverse: *reverse,
+
+			Browser:   *browser,
+			Profile:   *profile,
+			NewWindow: *newWindow,
+
+			BatchSize: *batch,
+			BatchWait: *batchWait,
+			Rows:      rowRange,
+			Tab:       *tab,
+			Filter:    *filter,
+			PrintOnly: *printOnly,
+			DryRun:    *dryRun,
+			GroupBy:   *groupBy,
+			// Explicit row selections take precedence over saved progress, and
+			// printing shows every selected row
+			Resume: !*restart && !*printOnly && !*dryRun && *rows == "" && *startAt == 0,
+		}); err != nil {
+			log.Fatalf("Error opening PRs: %v", err)
+		}
+
+	case "watch":
+		if *repo == "" {
+			fmt.Println("Usage for watch mode:")
+			fmt.Println("  ./github-pr-grabber -mode watch -repo owner/repo[,owner/repo...] [-interval 15m] [-since YYYY-MM-DD]")
+			flag.PrintDefaults()
+			os.Exit(1)
+		}
+		if *interval < time.Minute {
+			log.Fatalf("Invalid -interval %s: must be at least 1m", *interval)
+		}
+		if err := validateListFlags(*minChanges, *maxChanges, *ttmUnit); err != nil {
+			log.Fatalf("Error: %v", err)
+		}
+		extraFields, err := parseFields(*fields)
 		if err != nil {
-			log.Fatalf("Error getting PRs: %v", err)
+			log.Fatalf("Invalid -fields value: %v", err)
+		}
+		var sinceDate time.Time
+		if *sinceDateStr != "" {
+			if sinceDate, err = parseSinceDate(*sinceDateStr); err != nil {
+				log.Fatalf("Invalid date format: %v", err)
+			}
 		}
 
-		if len(prs) == 0 {
-			fmt.Println("No PRs found for the specified criteria.")
-			os.Exit(0)
+		var repos []string
+		for _, r := range strings.Split(*repo, ",") {
+			if r = strings.TrimSpace(r); r != "" {
+				repos = append(repos, r)
+			}
 		}
 
-		// Create generated/csv directory if it doesn't exist
-		if err := os.MkdirAll("generated/csv", 0755); err != nil {
-			log.Fatalf("Error creating output directory: %v", err)
+		runner, until := setupRunner(*recordDir, *replayDir)
+		if _, replaying := runner.(replayRunner); !replaying && !*dryRun {
+			if err := checkGHReady(); err != nil {
+				log.Fatalf("Error: %v", err)
+			}
 		}
 
-		csvFile := filepath.Join("generated/csv", fmt.Sprintf("merged_prs_%s_%s.csv",
-			strings.Replace(*repo, "/", "_", -1),
-			sinceDate.Format("20060102")))
-		if *searchTerm != "" {
-			csvFile = filepath.Join("generated/csv", fmt.Sprintf("%s_%s.csv",
-				strings.TrimSuffix(filepath.Base(csvFile), ".csv"),
-				strings.Replace(*searchTerm, " ", "_", -1)))
+		// Only serve metrics when asked to, since watching doesn't otherwise need a port
+		var metricsAddr string
+		flag.Visit(func(f *flag.Flag) {
+			if f.Name == "addr" {
+				metricsAddr = *addr
+			}
+		})
+
+		list := ListOptions{
+			Since:      sinceDate,
+			Until:      until,
+			SearchTerm: *searchTerm,
+			MinChanges: *minChanges,
+			MaxChanges: *maxChanges,
+			Fields:     extraFields,
+			OutDir:     *outDir,
+
+			JiraURL:         *jiraURL,
+			JiraProjects:    jiraProjects,
+			TimeToMergeUnit: *ttmUnit,
+			DryRun:          *dryRun,
+			Progress:        io.Discard,
+			Runner:          runner,
+		}
+		if err := runWatchMode(WatchOptions{
+			List:  list,
+			Repos: repos,
+			OnNew: func(repo string, prs []PR) {
+				notifyAll(notifiers, runSummary{Repo: repo, PRs: prs, File: watchOutputFile(list, repo), Appended: true, List: list})
+			},
+			Interval:    *interval,
+			MetricsAddr: metricsAddr,
+		}); err != nil {
+			log.Fatalf("Error watching: %v", err)
+		}
+		if *dryRun && len(notifiers) > 0 {
+			fmt.Printf("Would send each poll's new PRs to %d notification destinations\n", len(notifiers))
 		}
 
-		if err := saveToCSV(prs, csvFile); err != nil {
-			log.Fatalf("Error saving to CSV: %v", err)
+	case "webhook":
+		if *webhookSecret == "" {
+			log.Fatalf("Webhook mode needs -webhook-secret or GITHUB_WEBHOOK_SECRET, so deliveries can be verified")
+		}
+		if err := validateListFlags(*minChanges, *maxChanges, *ttmUnit); err != nil {
+			log.Fatalf("Error: %v", err)
+		}
+		extraFields, err := parseFields(*fields)
+		if err != nil {
+			log.Fatalf("Invalid -fields value: %v", err)
+		}
+		if err := checkWebhookFields(extraFields); err != nil {
+			log.Fatalf("Invalid -fields value: %v", err)
 		}
-		fmt.Printf("Results saved to %s\n", csvFile)
 
-	case "open":
-		if *urlsFile == "" {
-			fmt.Println("Usage for open mode:")
-			fmt.Println("  ./github-pr-grabber -mode open -urls <csv_file>")
-			fmt.Println("  or using shorthand flags:")
-			fmt.Println("  ./github-pr-grabber -m open -u <csv_file>")
+		if *addr == "" {
+			*addr = ":8080"
+		}
+		list := ListOptions{
+			MinChanges: *minChanges,
+			MaxChanges: *maxChanges,
+			Fields:     extraFields,
+			OutDir:     *outDir,
+
+			JiraURL:         *jiraURL,
+			JiraProjects:    jiraProjects,
+			TimeToMergeUnit: *ttmUnit,
+			DryRun:          *dryRun,
+		}
+		if err := runWebhookMode(WebhookOptions{
+			Addr:   *addr,
+			Secret: *webhookSecret,
+			OnNew: func(repo string, prs []PR) {
+				notifyAll(notifiers, runSummary{Repo: repo, PRs: prs, File: watchOutputFile(list, repo), Appended: true, List: list})
+			},
+			List: list,
+		}); err != nil {
+			log.Fatalf("Error receiving webhooks: %v", err)
+		}
+		if *dryRun && len(notifiers) > 0 {
+			fmt.Printf("Would send each merged PR to %d notification destinations\n", len(notifiers))
+		}
+
+	case "serve":
+		runner, until := setupRunner(*recordDir, *replayDir)
+		if _, replaying := runner.(replayRunner); !replaying && !*dryRun {
+			if err := checkGHReady(); err != nil {
+				log.Fatalf("Error: %v", err)
+			}
+		}
+		if *serveMaxDays < 0 || *serveMaxLimit < 0 {
+			log.Fatalf("Invalid -serve-max-days or -serve-max-limit: must be 0 or greater")
+		}
+		// Only reachable from this machine unless -addr says otherwise
+		if *addr == "" {
+			*addr = "127.0.0.1:8080"
+		}
+		if err := runServeMode(ServeOptions{
+			Addr:         *addr,
+			Runner:       runner,
+			Until:        until,
+			Token:        *serveToken,
+			MaxDays:      *serveMaxDays,
+			MaxLimit:     *serveMaxLimit,
+			JiraURL:      *jiraURL,
+			JiraProjects: jiraProjects,
+			DryRun:       *dryRun,
+		}); err != nil {
+			log.Fatalf("Error serving: %v", err)
+		}
+
+	case "stats", "report":
+		if *sinceDateStr == "" || *repo == "" {
+			fmt.Printf("Usage for %s mode:\n", *mode)
+			if *mode == "report" {
+				fmt.Println("  ./github-pr-grabber -mode report -since YYYY-MM-DD -repo owner/repo[,owner/repo...] [-search term] [-period month]")
+			} else {
+				fmt.Println("  ./github-pr-grabber -mode stats -since YYYY-MM-DD -repo owner/repo[,owner/repo...] [-search term] [-stats-out stats.csv]")
+			}
+			flag.PrintDefaults()
+			os.Exit(1)
+		}
+		if *limit < 0 {
+			log.Fatalf("Invalid limit %d: must be 0 or greater", *limit)
+		}
+		if err := validateListFlags(*minChanges, *maxChanges, *ttmUnit); err != nil {
+			log.Fatalf("Error: %v", err)
+		}
+		if *groupBy != "" {
+			log.Fatalf("-group-by is for open mode; use -period week or -period month to group merges over time")
+		}
+		if _, ok := statsPeriods[*period]; !ok {
+			log.Fatalf("Invalid -period %q: must be week or month", *period)
+		}
+		if _, err := lookupChartFormat(*chartFormat); err != nil {
+			log.Fatalf("Invalid -chart-format: %v", err)
+		}
+		sinceDate, err := parseSinceDate(*sinceDateStr)
+		if err != nil {
+			log.Fatalf("Invalid date format: %v", err)
+		}
+		if sinceDate.After(time.Now()) {
+			log.Fatalf("Error: The date %s is in the future", sinceDate.Format("2006-01-02"))
+		}
+
+		var repos []string
+		for _, r := range strings.Split(*repo, ",") {
+			if r = strings.TrimSpace(r); r != "" {
+				repos = append(repos, r)
+			}
+		}
+
+		runner, until := setupRunner(*recordDir, *replayDir)
+		if _, replaying := runner.(replayRunner); !replaying && !*dryRun {
+			if err := checkGHReady(); err != nil {
+				log.Fatalf("Error: %v", err)
+			}
+		}
+
+		statsOpts := StatsOptions{
+			List: ListOptions{
+				Since:      sinceDate,
+				Until:      until,
+				SearchTerm: *searchTerm,
+				Limit:      *limit,
+				MinChanges: *minChanges,
+				MaxChanges: *maxChanges,
+				DryRun:     *dryRun,
+				Runner:     runner,
+
+				TimeToMergeUnit: *ttmUnit,
+			},
+			Repos:    repos,
+			OutFile:  *statsOut,
+			JSONFile: *statsJSON,
+
+			Reviews:        *reviews || *reviewsOut != "",
+			ReviewsOutFile: *reviewsOut,
+
+			LeadTime:         *leadTime || *leadTimeReleases || *leadTimeOut != "",
+			LeadTimeReleases: *leadTimeReleases,
+			LeadTimeOutFile:  *leadTimeOut,
+
+			Leaderboard:        *leaderboard || *leaderboardOut != "",
+			LeaderboardOutFile: *leaderboardOut,
+
+			SizesOutFile: *sizesOut,
+			TrendOutFile: *trendOut,
+			CompareTo:    *compareTo,
+			ChartsDir:    *chartsDir,
+			ChartFormat:  *chartFormat,
+			Period:       *period,
+		}
+		if *mode == "report" {
+			statsOpts.List.OutDir = *outDir
+			// The report doesn't show review latency, lead time, or the leaderboard
+			statsOpts.Reviews = false
+			statsOpts.LeadTime, statsOpts.LeadTimeReleases = false, false
+			statsOpts.Leaderboard, statsOpts.LeaderboardOutFile = false, ""
+			if err := runReportMode(ReportOptions{Stats: statsOpts, Force: *force}); err != nil {
+				log.Fatalf("Error creating report: %v", err)
+			}
+			break
+		}
+		if err := runStatsMode(statsOpts); err != nil {
+			log.Fatalf("Error computing stats: %v", err)
+		}
+
+	case "stale":
+		if *repo == "" || (*prState == "merged" && *sinceDateStr == "") {
+			fmt.Println("Usage for stale mode:")
+			fmt.Println("  ./github-pr-grabber -mode stale -since YYYY-MM-DD -repo owner/repo [-older-than 7d]")
 			fmt.Println("  or")
-			fmt.Println("  ./github-pr-grabber -i")
+			fmt.Println("  ./github-pr-grabber -mode stale -state open -repo owner/repo [-older-than 7d]")
 			flag.PrintDefaults()
 			os.Exit(1)
 		}
+		if strings.Contains(*repo, ",") {
+			log.Fatalf("Error: stale mode takes a single -repo")
+		}
+		if *prState != "merged" && *prState != "open" {
+			log.Fatalf("Invalid -state %q: must be merged or open", *prState)
+		}
+		age, err := parseAge(*olderThan)
+		if err != nil {
+			log.Fatalf("Invalid -older-than: %v", err)
+		}
+		if err := validateListFlags(*minChanges, *maxChanges, *ttmUnit); err != nil {
+			log.Fatalf("Error: %v", err)
+		}
+		var sinceDate time.Time
+		if *sinceDateStr != "" {
+			if sinceDate, err = parseSinceDate(*sinceDateStr); err != nil {
+				log.Fatalf("Invalid date format: %v", err)
+			}
+		}
 
-		if err := openPRsFromCSV(*urlsFile); err != nil {
-			log.Fatalf("Error opening PRs: %v", err)
+		runner, until := setupRunner(*recordDir, *replayDir)
+		if _, replaying := runner.(replayRunner); !replaying && !*dryRun {
+			if err := checkGHReady(); err != nil {
+				log.Fatalf("Error: %v", err)
+			}
+		}
+
+		staleOpts := StaleOptions{
+			List: ListOptions{
+				Since:      sinceDate,
+				Until:      until,
+				Repo:       *repo,
+				SearchTerm: *searchTerm,
+				Limit:      *limit,
+				DryRun:     *dryRun,
+				Runner:     runner,
+
+				TimeToMergeUnit: *ttmUnit,
+			},
+			Open:      *prState == "open",
+			OlderThan: age,
+			OutFile:   *staleOut,
+		}
+		if err := runStaleMode(staleOpts); err != nil {
+			log.Fatalf("Error finding stale PRs: %v", err)
+		}
+
+	case "release-notes", "changelog":
+		if (*fromRef == "" && *sinceDateStr == "") || *repo == "" {
+			fmt.Printf("Usage for %s mode:\n", *mode)
+			fmt.Printf("  ./github-pr-grabber -mode %s -repo owner/repo -from v1.2.0 [-to v1.3.0]\n", *mode)
+			fmt.Println("  or")
+			fmt.Printf("  ./github-pr-grabber -mode %s -repo owner/repo -since YYYY-MM-DD\n", *mode)
+			flag.PrintDefaults()
+			os.Exit(1)
+		}
+		if strings.Contains(*repo, ",") {
+			log.Fatalf("Error: %s mode takes a single -repo", *mode)
+		}
+		if *fromRef != "" && *sinceDateStr != "" {
+			log.Fatalf("Error: -from and -since can't be used together")
+		}
+		var sinceDate time.Time
+		if *sinceDateStr != "" {
+			var err error
+			if sinceDate, err = parseSinceDate(*sinceDateStr); err != nil {
+				log.Fatalf("Invalid date format: %v", err)
+			}
+		}
+
+		runner, until := setupRunner(*recordDir, *replayDir)
+		if _, replaying := runner.(replayRunner); !replaying && !*dryRun {
+			if err := checkGHReady(); err != nil {
+				log.Fatalf("Error: %v", err)
+			}
+		}
+
+		notesOpts := ReleaseNotesOptions{
+			List: ListOptions{
+				Since:      sinceDate,
+				Until:      until,
+				Repo:       *repo,
+				SearchTerm: *searchTerm,
+				DryRun:     *dryRun,
+				Runner:     runner,
+			},
+			From:      *fromRef,
+			To:        *toRef,
+			Changelog: *mode == "changelog",
+		}
+		if err := runReleaseNotesMode(notesOpts); err != nil {
+			log.Fatalf("Error writing release notes: %v", err)
+		}
+
+	case "doctor":
+		if !runDoctor(*configFile, ListOptions{OutDir: *outDir}.outputDir()) {
+			os.Exit(1)
 		}
 
 	default:
-		fmt.Println("Please specify a mode: 'list' or 'open'")
+		fmt.Println("Please specify a mode: 'list', 'open', 'stats', 'report', 'stale', 'release-notes', 'changelog', 'watch', 'webhook', 'serve', or 'doctor'")
 		fmt.Println("\nList mode usage:")
 		fmt.Println("  ./github-pr-grabber -mode list -since YYYY-MM-DD -repo owner/repo [-search term]")
 		fmt.Println("  or using shorthand flags:")
@@ -249,34 +920,38 @@ func main() {
 		fmt.Println("  ./github-pr-grabber -mode open -urls <csv_file>")
 		fmt.Println("  or using shorthand flags:")
 		fmt.Println("  ./github-pr-grabber -m open -u <csv_file>")
+		fmt.Println("\nStats mode usage:")
+		fmt.Println("  ./github-pr-grabber -mode stats -since YYYY-MM-DD -repo owner/repo[,owner/repo...] [-stats-out stats.csv]")
+		fmt.Println("\nReport mode usage:")
+		fmt.Println("  ./github-pr-grabber -mode report -since YYYY-MM-DD -repo owner/repo[,owner/repo...]")
+		fmt.Println("\nStale mode usage:")
+		fmt.Println("  ./github-pr-grabber -mode stale -since YYYY-MM-DD -repo owner/repo [-older-than 7d] [-state open]")
+		fmt.Println("\nRelease notes mode usage:")
+		fmt.Println("  ./github-pr-grabber -mode release-notes -repo owner/repo -from v1.2.0 [-to v1.3.0]")
+		fmt.Println("\nChangelog mode usage:")
+		fmt.Println("  ./github-pr-grabber -mode changelog -repo owner/repo -from v1.2.0 [-to v1.3.0]")
+		fmt.Println("\nWatch mode usage:")
+		fmt.Println("  ./github-pr-grabber -mode watch -repo owner/repo[,owner/repo...] [-interval 15m]")
+		fmt.Println("\nWebhook mode usage:")
+		fmt.Println("  ./github-pr-grabber -mode webhook -webhook-secret <secret> [-addr :8080]")
+		fmt.Println("\nServe mode usage:")
+		fmt.Println("  ./github-pr-grabber -mode serve [-addr 127.0.0.1:8080] [-serve-token <token>]")
+		fmt.Println("\nCheck that gh, the browser, and the output directory are set up:")
+		fmt.Println("  ./github-pr-grabber doctor")
 		fmt.Println("\nOr run in interactive mode:")
 		fmt.Println("  ./github-pr-grabber -i")
 		os.Exit(1)
 	}
-}
 
-func runInteractiveMode() {
-	fmt.Println("GitHub PR Grabber")
-	fmt.Println("=================")
-
-	for {
-		fmt.Println("\nSelect a mode:")
-		fmt.Println("1. List Mode - Fetch PRs and save to CSV")
-		fmt.Println("2. Open Mode - Open PRs from CSV in browser")
-		fmt.Println("3. Exit")
-
-		choice := promptUser("Enter your choice (1-3): ")
-
-		switch choice {
-		case "1":
-			handleListMode()
-		case "2":
-			handleOpenMode()
-		case "3":
-			fmt.Println("Goodbye!")
-			return
-		default:
-			fmt.Println("Invalid choice. Please enter 1, 2, or 3.")
+	// Uploads wait until the run is over, so a failed upload can't lose results
+	if target != nil {
+		if *recordDir != "" {
+			addOutput(*recordDir)
+		}
+		if *dryRun {
+			fmt.Printf("Would then upload the saved files to %s\n", target)
+		} else if err := uploadOutputs(*target, savedOutputs); err != nil {
+			log.Fatalf("Error uploading: %v", err)
 		}
 	}
 }
@@ -0,0 +1,138 @@
+package main
+
+import (
+	"fmt"
+	"io"
+	"net/http"
+	"sort"
+	"sync"
+	"time"
+)
+
+// rateLimitRefresh is how long a fetched rate limit is reported before /metrics checks it again
+const rateLimitRefresh = time.Minute
+
+// metricsRegistry collects the counters served at /metrics by long-running
+// modes, in the Prometheus text format
+type metricsRegistry struct {
+	mu sync.Mutex
+
+	prsFetched   int
+	ghCommands   map[string]int // by result, ok or error
+	fetches      map[string]int // by result
+	fetchSeconds float64
+
+	rateLimits       map[string]rateLimit // by API resource, core or search
+	rateLimitChecked time.Time
+}
+
+// metrics is updated by every fetch, whether or not anything serves it
+var metrics = &metricsRegistry{
+	ghCommands: make(map[string]int),
+	fetches:    make(map[string]int),
+}
+
+// resultLabel returns the result label value for err
+func resultLabel(err error) string {
+	if err != nil {
+		return "error"
+	}
+	return "ok"
+}
+
+// observeGHCommand counts one gh command
+func (m *metricsRegistry) observeGHCommand(err error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.ghCommands[resultLabel(err)]++
+}
+
+// observeFetch records one complete fetch of merged PRs
+func (m *metricsRegistry) observeFetch(duration time.Duration, count int, err error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.fetches[resultLabel(err)]++
+	m.fetchSeconds += duration.Seconds()
+	m.prsFetched += count
+}
+
+// refreshRateLimits updates the reported rate limits if they are older than
+// rateLimitRefresh. Failures are left for the next scrape to retry, and the
+// previous values are kept meanwhile.
+func (m *metricsRegistry) refreshRateLimits(runner CommandRunner) {
+	m.mu.Lock()
+	stale := time.Since(m.rateLimitChecked) > rateLimitRefresh
+	m.mu.Unlock()
+	if !stale {
+		return
+	}
+
+	core, search, err := fetchRateLimits(runner)
+	if err != nil {
+		events.Warn("rate_limit_failed", "error", err.Error())
+		return
+	}
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.rateLimits = map[string]rateLimit{"core": core, "search": search}
+	m.rateLimitChecked = time.Now()
+}
+
+// writeTo writes the metrics in the Prometheus text exposition format
+func (m *metricsRegistry) writeTo(w io.Writer) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	fmt.Fprintf(w, "# HELP github_pr_grabber_prs_fetched_total Merged PRs returned by fetches.\n")
+	fmt.Fprintf(w, "# TYPE github_pr_grabber_prs_fetched_total counter\n")
+	fmt.Fprintf(w, "github_pr_grabber_prs_fetched_total %d\n", m.prsFetched)
+
+	writeLabeled(w, "github_pr_grabber_gh_commands_total", "gh commands run, including API calls.", "counter", "result", m.ghCommands)
+	writeLabeled(w, "github_pr_grabber_fetches_total", "Fetches of merged PRs, each covering a full date range.", "counter", "result", m.fetches)
+
+	fetchCount := 0
+	for _, count := range m.fetches {
+		fetchCount += count
+	}
+	fmt.Fprintf(w, "# HELP github_pr_grabber_fetch_duration_seconds Time taken by fetches of merged PRs.\n")
+	fmt.Fprintf(w, "# TYPE github_pr_grabber_fetch_duration_seconds summary\n")
+	fmt.Fprintf(w, "github_pr_grabber_fetch_duration_seconds_sum %g\n", m.fetchSeconds)
+	fmt.Fprintf(w, "github_pr_grabber_fetch_duration_seconds_count %d\n", fetchCount)
+
+	if len(m.rateLimits) > 0 {
+		remaining := make(map[string]int)
+		limits := make(map[string]int)
+		for resource, limit := range m.rateLimits {
+			remaining[resource] = limit.Remaining
+			limits[resource] = limit.Limit
+		}
+		writeLabeled(w, "github_pr_grabber_rate_limit_remaining", "GitHub API requests left in the current rate limit window.", "gauge", "resource", remaining)
+		writeLabeled(w, "github_pr_grabber_rate_limit", "GitHub API requests allowed per rate limit window.", "gauge", "resource", limits)
+	}
+}
+
+// writeLabeled writes a metric with one sample per value of a single label, sorted by label value
+func writeLabeled(w io.Writer, name, help, metricType, label string, values map[string]int) {
+	fmt.Fprintf(w, "# HELP %s %s\n", name, help)
+	fmt.Fprintf(w, "# TYPE %s %s\n", name, metricType)
+	keys := make([]string, 0, len(values))
+	for key := range values {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	for _, key := range keys {
+		fmt.Fprintf(w, "%s{%s=%q} %d\n", name, label, key, values[key])
+	}
+}
+
+// metricsHandler serves /metrics, checking the rate limits with runner when they are stale
+func metricsHandler(runner CommandRunner) http.HandlerFunc {
+	if runner == nil {
+		runner = ghRunner{}
+	}
+	return func(w http.ResponseWriter, r *http.Request) {
+		metrics.refreshRateLimits(runner)
+		w.Header().Set("Content-Type", "text/plain; version=0.0.4; charset=utf-8")
+		metrics.writeTo(w)
+	}
+}
@@ -0,0 +1,37 @@
+package main
+
+import (
+	"errors"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestMetricsExposition(t *testing.T) {
+	m := &metricsRegistry{ghCommands: make(map[string]int), fetches: make(map[string]int)}
+	m.observeGHCommand(nil)
+	m.observeGHCommand(errors.New("HTTP 502"))
+	m.observeGHCommand(nil)
+	m.observeFetch(1500*time.Millisecond, 12, nil)
+	m.observeFetch(500*time.Millisecond, 3, errors.New("HTTP 502"))
+	m.refreshRateLimits(runnerFunc(func(args ...string) (string, error) {
+		return "4990 5000 29 30", nil
+	}))
+
+	var b strings.Builder
+	m.writeTo(&b)
+	for _, want := range []string{
+		"github_pr_grabber_prs_fetched_total 15\n",
+		`github_pr_grabber_gh_commands_total{result="error"} 1` + "\n",
+		`github_pr_grabber_gh_commands_total{result="ok"} 2` + "\n",
+		`github_pr_grabber_fetches_total{result="ok"} 1` + "\n",
+		"github_pr_grabber_fetch_duration_seconds_sum 2\n",
+		"github_pr_grabber_fetch_duration_seconds_count 2\n",
+		`github_pr_grabber_rate_limit_remaining{resource="search"} 29` + "\n",
+		`github_pr_grabber_rate_limit{resource="core"} 5000` + "\n",
+	} {
+		if !strings.Contains(b.String(), want) {
+			t.Errorf("metrics missing %q:\n%s", want, b.String())
+		}
+	}
+}
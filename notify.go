@@ -0,0 +1,433 @@
+package main
+
+import (
+	"bytes"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"io"
+	"mime"
+	"mime/multipart"
+	"mime/quotedprintable"
+	"net/http"
+	"net/smtp"
+	"net/textproto"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// runSummary describes the PRs a run found, for notifiers
+type runSummary struct {
+	Repo string
+	// Since is the start of the search; zero for watch and webhook updates
+	Since time.Time
+	PRs   []PR
+	// File is where the PRs were saved
+	File string
+	// Appended is set when File also holds earlier results, as in watch and
+	// webhook mode. List then gives the columns to write just these PRs with.
+	Appended bool
+	List     ListOptions
+}
+
+// title returns a one-line description of the summary
+func (s runSummary) title() string {
+	noun := "PRs"
+	if len(s.PRs) == 1 {
+		noun = "PR"
+	}
+	if s.Since.IsZero() {
+		return fmt.Sprintf("%d new merged %s in %s", len(s.PRs), noun, s.Repo)
+	}
+	return fmt.Sprintf("%d %s merged in %s since %s", len(s.PRs), noun, s.Repo, s.Since.Format("2006-01-02"))
+}
+
+// highlights returns up to n of the PRs with the most lines changed, largest first
+func (s runSummary) highlights(n int) []PR {
+	prs := append([]PR(nil), s.PRs...)
+	sort.SliceStable(prs, func(i, j int) bool { return prs[i].Changes() > prs[j].Changes() })
+	return prs[:min(n, len(prs))]
+}
+
+// Notifier sends a run summary somewhere, such as a chat channel
+type Notifier interface {
+	Notify(summary runSummary) error
+}
+
+// notifyAll sends the summary to every notifier, reporting failures as warnings
+// since the results have already been saved
+func notifyAll(notifiers []Notifier, summary runSummary) {
+	for _, n := range notifiers {
+		if err := n.Notify(summary); err != nil {
+			fmt.Printf("Warning: Error sending notification: %v\n", err)
+			events.Error("notification_failed", "notifier", fmt.Sprintf("%T", n), "error", err.Error())
+			continue
+		}
+		events.Info("notification_sent", "notifier", fmt.Sprintf("%T", n), "repo", summary.Repo, "count", len(summary.PRs))
+	}
+}
+
+// httpClient is used for outgoing notifications
+var httpClient = &http.Client{Timeout: 30 * time.Second}
+
+// postJSON POSTs body as JSON to url with any extra headers, returning an
+// error for non-2xx responses
+func postJSON(url string, body any, headers map[string]string) error {
+	data, err := json.Marshal(body)
+	if err != nil {
+		return err
+	}
+	req, err := http.NewRequest("POST", url, bytes.NewReader(data))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	for name, value := range headers {
+		req.Header.Set(name, value)
+	}
+
+	resp, err := httpClient.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		msg, _ := io.ReadAll(io.LimitReader(resp.Body, 512))
+		return fmt.Errorf("%s responded %s: %s", req.URL.Host, resp.Status, strings.TrimSpace(string(msg)))
+	}
+	return nil
+}
+
+// slackNotifier posts summaries to a Slack incoming webhook
+type slackNotifier struct {
+	WebhookURL string
+}
+
+// slackEscape escapes the characters Slack's mrkdwn treats as control characters
+func slackEscape(s string) string {
+	return strings.NewReplacer("&", "&amp;", "<", "&lt;", ">", "&gt;").Replace(s)
+}
+
+func (n slackNotifier) Notify(summary runSummary) error {
+	var b strings.Builder
+	fmt.Fprintf(&b, "*%s*\n", slackEscape(summary.title()))
+	if highlights := summary.highlights(5); len(highlights) > 0 {
+		b.WriteString("\nLargest changes:\n")
+		for _, pr := range highlights {
+			fmt.Fprintf(&b, "• <%s|#%s> %s (+%d/-%d)\n", pr.URL, pr.Number, slackEscape(pr.Title), pr.Additions, pr.Deletions)
+		}
+	}
+	if summary.File != "" {
+		fmt.Fprintf(&b, "\nSaved to `%s`", slackEscape(summary.File))
+	}
+
+	return postJSON(n.WebhookURL, map[string]any{
+		// text is the fallback shown in notifications
+		"text": summary.title(),
+		"blocks": []map[string]any{{
+			"type": "section",
+			"text": map[string]string{"type": "mrkdwn", "text": b.String()},
+		}},
+	}, nil)
+}
+
+// teamsNotifier posts summaries to a Microsoft Teams incoming webhook as an Adaptive Card
+type teamsNotifier struct {
+	WebhookURL string
+}
+
+// teamsEscape escapes the characters Adaptive Card markdown would format
+func teamsEscape(s string) string {
+	return strings.NewReplacer("\\", "\\\\", "[", "\\[", "]", "\\]", "*", "\\*", "_", "\\_").Replace(s)
+}
+
+func (n teamsNotifier) Notify(summary runSummary) error {
+	body := []map[string]any{{
+		"type":   "TextBlock",
+		"text":   teamsEscape(summary.title()),
+		"weight": "Bolder",
+		"size":   "Medium",
+		"wrap":   true,
+	}}
+	if highlights := summary.highlights(5); len(highlights) > 0 {
+		var b strings.Builder
+		for _, pr := range highlights {
+			fmt.Fprintf(&b, "- [#%s](%s) %s (+%d/-%d)\n", pr.Number, pr.URL, teamsEscape(pr.Title), pr.Additions, pr.Deletions)
+		}
+		body = append(body,
+			map[string]any{"type": "TextBlock", "text": "Largest changes:", "wrap": true},
+			map[string]any{"type": "TextBlock", "text": b.String(), "wrap": true},
+		)
+	}
+	if summary.File != "" {
+		body = append(body, map[string]any{"type": "TextBlock", "text": "Saved to " + teamsEscape(summary.File), "isSubtle": true, "wrap": true})
+	}
+
+	return postJSON(n.WebhookURL, map[string]any{
+		"type": "message",
+		"attachments": []map[string]any{{
+			"contentType": "application/vnd.microsoft.card.adaptive",
+			"content": map[string]any{
+				"$schema": "http://adaptivecards.io/schemas/adaptive-card.json",
+				"type":    "AdaptiveCard",
+				"version": "1.4",
+				"body":    body,
+			},
+		}},
+	}, nil)
+}
+
+// discordNotifier posts summaries to a Discord channel webhook as an embed
+type discordNotifier struct {
+	WebhookURL string
+}
+
+// discordEscape escapes the characters Discord's markdown would format
+func discordEscape(s string) string {
+	return strings.NewReplacer("\\", "\\\\", "*", "\\*", "_", "\\_", "~", "\\~", "`", "\\`", "|", "\\|", "[", "\\[", "]", "\\]").Replace(s)
+}
+
+func (n discordNotifier) Notify(summary runSummary) error {
+	var b strings.Builder
+	if highlights := summary.highlights(5); len(highlights) > 0 {
+		b.WriteString("Largest changes:\n")
+		for _, pr := range highlights {
+			fmt.Fprintf(&b, "- [#%s](<%s>) %s (+%d/-%d)\n", pr.Number, pr.URL, discordEscape(pr.Title), pr.Additions, pr.Deletions)
+		}
+	}
+	embed := map[string]any{
+		"title":       summary.title(),
+		"description": b.String(),
+		"color":       0x8250df, // GitHub's merged purple
+	}
+	if summary.File != "" {
+		embed["footer"] = map[string]string{"text": "Saved to " + summary.File}
+	}
+
+	return postJSON(n.WebhookURL, map[string]any{
+		"embeds": []map[string]any{embed},
+		// PR titles shouldn't be able to ping @everyone or anyone else
+		"allowed_mentions": map[string]any{"parse": []string{}},
+	}, nil)
+}
+
+// sendMail sends email; tests replace it to capture messages
+var sendMail = smtp.SendMail
+
+// emailNotifier emails summaries over SMTP, with the saved results attached
+type emailNotifier struct {
+	// Addr is the SMTP server as host:port; STARTTLS is used when the server offers it
+	Addr string
+	From string
+	To   []string
+	// Username and Password authenticate with the server if set
+	Username string
+	Password string
+}
+
+func (n emailNotifier) Notify(summary runSummary) error {
+	var body strings.Builder
+	body.WriteString(summary.title() + "\n")
+	if highlights := summary.highlights(10); len(highlights) > 0 {
+		body.WriteString("\nLargest changes:\n")
+		for _, pr := range highlights {
+			fmt.Fprintf(&body, "  #%s %s (+%d/-%d)\n    %s\n", pr.Number, pr.Title, pr.Additions, pr.Deletions, pr.URL)
+		}
+	}
+
+	attachmentName, attachment, err := summary.attachment(time.Now())
+	if err != nil {
+		return err
+	}
+	if attachmentName != "" {
+		fmt.Fprintf(&body, "\nThe results are attached as %s.\n", filepath.Base(attachmentName))
+		if summary.Appended {
+			fmt.Fprintf(&body, "Earlier results are in %s.\n", summary.File)
+		}
+	}
+
+	message, err := buildEmail(n.From, n.To, summary.title(), body.String(), attachmentName, attachment)
+	if err != nil {
+		return err
+	}
+
+	var auth smtp.Auth
+	if n.Username != "" {
+		host, _, _ := strings.Cut(n.Addr, ":")
+		auth = smtp.PlainAuth("", n.Username, n.Password, host)
+	}
+	return sendMail(n.Addr, auth, n.From, n.To, message)
+}
+
+// attachment returns the name and contents of the file to email with the
+// summary. Appended results are written out afresh, so each email only
+// carries the PRs it is about, named after the run at now.
+func (s runSummary) attachment(now time.Time) (string, []byte, error) {
+	if s.File == "" {
+		return "", nil, nil
+	}
+	if !s.Appended {
+		data, err := os.ReadFile(s.File)
+		if err != nil {
+			return "", nil, fmt.Errorf("error reading results to attach: %v", err)
+		}
+		return s.File, data, nil
+	}
+
+	var b bytes.Buffer
+	writer, err := lookupWriter("csv")
+	if err != nil {
+		return "", nil, err
+	}
+	if err := writer.Write(&b, buildTable(s.PRs, s.List)); err != nil {
+		return "", nil, fmt.Errorf("error writing results to attach: %v", err)
+	}
+	name := sanitizeFilename(fmt.Sprintf("new_%s_%s", s.Repo, now.UTC().Format("20060102_150405"))) + writer.Extension()
+	return name, b.Bytes(), nil
+}
+
+// buildEmail formats a MIME message with a plain text body and, if
+// attachmentName is set, the attachment
+func buildEmail(from string, to []string, subject, body, attachmentName string, attachment []byte) ([]byte, error) {
+	var b bytes.Buffer
+	fmt.Fprintf(&b, "From: %s\r\n", from)
+	fmt.Fprintf(&b, "To: %s\r\n", strings.Join(to, ", "))
+	fmt.Fprintf(&b, "Subject: %s\r\n", mime.QEncoding.Encode("utf-8", subject))
+	fmt.Fprintf(&b, "Date: %s\r\n", time.Now().Format(time.RFC1123Z))
+	b.WriteString("MIME-Version: 1.0\r\n")
+
+	writer := multipart.NewWriter(&b)
+	fmt.Fprintf(&b, "Content-Type: multipart/mixed; boundary=%q\r\n\r\n", writer.Boundary())
+
+	part, err := writer.CreatePart(textproto.MIMEHeader{
+		"Content-Type":              {"text/plain; charset=utf-8"},
+		"Content-Transfer-Encoding": {"quoted-printable"},
+	})
+	if err != nil {
+		return nil, err
+	}
+	qp := quotedprintable.NewWriter(part)
+	if _, err := qp.Write([]byte(body)); err != nil {
+		return nil, err
+	}
+	if err := qp.Close(); err != nil {
+		return nil, err
+	}
+
+	if attachmentName != "" {
+		contentType := mime.TypeByExtension(filepath.Ext(attachmentName))
+		if contentType == "" {
+			contentType = "application/octet-stream"
+		}
+		part, err := writer.CreatePart(textproto.MIMEHeader{
+			"Content-Type":              {contentType},
+			"Content-Transfer-Encoding": {"base64"},
+			"Content-Disposition":       {mime.FormatMediaType("attachment", map[string]string{"filename": filepath.Base(attachmentName)})},
+		})
+		if err != nil {
+			return nil, err
+		}
+		// Base64 lines are limited to 76 characters
+		encoded := base64.StdEncoding.EncodeToString(attachment)
+		for len(encoded) > 76 {
+			fmt.Fprintf(part, "%s\r\n", encoded[:76])
+			encoded = encoded[76:]
+		}
+		fmt.Fprintf(part, "%s\r\n", encoded)
+	}
+
+	if err := writer.Close(); err != nil {
+		return nil, err
+	}
+	return b.Bytes(), nil
+}
+
+// headerFlags collects repeated -post-header flags
+type headerFlags map[string]string
+
+func (h headerFlags) String() string {
+	names := make([]string, 0, len(h))
+	for name := range h {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return strings.Join(names, ", ")
+}
+
+// Set parses a "Name: Value" header, expanding environment variables in the
+// value so secrets can be kept out of config files
+func (h headerFlags) Set(s string) error {
+	name, value, ok := strings.Cut(s, ":")
+	if !ok || strings.TrimSpace(name) == "" {
+		return fmt.Errorf("header %q must be Name: Value", s)
+	}
+	h[http.CanonicalHeaderKey(strings.TrimSpace(name))] = os.ExpandEnv(strings.TrimSpace(value))
+	return nil
+}
+
+// resultsPR is how a PR appears in results posted with -post-results. The
+// number is a JSON number, as in -format json output.
+type resultsPR struct {
+	Number         int      `json:"number"`
+	Title          string   `json:"title"`
+	URL            string   `json:"url"`
+	CreatedAt      string   `json:"createdAt"`
+	MergedAt       string   `json:"mergedAt"`
+	Additions      int      `json:"additions"`
+	Deletions      int      `json:"deletions"`
+	Comments       int      `json:"comments"`
+	ReviewComments int      `json:"reviewComments"`
+	Author         string   `json:"author,omitempty"`
+	Labels         []string `json:"labels,omitempty"`
+}
+
+// resultsPayload is the body posted with -post-results
+type resultsPayload struct {
+	Repo string `json:"repo"`
+	// Since is omitted for watch and webhook updates
+	Since string `json:"since,omitempty"`
+	Count int    `json:"count"`
+	File  string `json:"file,omitempty"`
+	// PRs holds every PR, or only the largest for a summary
+	PRs []resultsPR `json:"prs"`
+}
+
+// resultsNotifier POSTs the results as JSON to an arbitrary endpoint
+type resultsNotifier struct {
+	URL     string
+	Headers map[string]string
+	// SummaryOnly posts the count and the largest PRs instead of every PR
+	SummaryOnly bool
+}
+
+func (n resultsNotifier) Notify(summary runSummary) error {
+	prs := summary.PRs
+	if n.SummaryOnly {
+		prs = summary.highlights(5)
+	}
+	payload := resultsPayload{Repo: summary.Repo, Count: len(summary.PRs), File: summary.File, PRs: make([]resultsPR, len(prs))}
+	if !summary.Since.IsZero() {
+		payload.Since = summary.Since.Format("2006-01-02")
+	}
+	for i, pr := range prs {
+		number, _ := strconv.Atoi(pr.Number)
+		payload.PRs[i] = resultsPR{
+			Number:         number,
+			Title:          pr.Title,
+			URL:            pr.URL,
+			CreatedAt:      pr.CreatedAt,
+			MergedAt:       pr.MergedAt,
+			Additions:      pr.Additions,
+			Deletions:      pr.Deletions,
+			Comments:       pr.Comments,
+			ReviewComments: pr.ReviewComments,
+			Author:         pr.Author,
+			Labels:         pr.Labels,
+		}
+	}
+	return postJSON(n.URL, payload, n.Headers)
+}
@@ -0,0 +1,276 @@
+package main
+
+import (
+	"bytes"
+	"encoding/base64"
+	"encoding/json"
+	"io"
+	"mime"
+	"mime/multipart"
+	"net/http"
+	"net/http/httptest"
+	"net/mail"
+	"net/smtp"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+// captureServer records the JSON bodies posted to it
+func captureServer(t *testing.T, status int) (*httptest.Server, *[]map[string]any) {
+	t.Helper()
+	var bodies []map[string]any
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		data, _ := io.ReadAll(r.Body)
+		var body map[string]any
+		if err := json.Unmarshal(data, &body); err != nil {
+			t.Errorf("posted invalid JSON: %v\n%s", err, data)
+		}
+		bodies = append(bodies, body)
+		w.WriteHeader(status)
+	}))
+	t.Cleanup(server.Close)
+	return server, &bodies
+}
+
+func testSummary() runSummary {
+	prs := makePRs(time.Date(2024, 3, 5, 0, 0, 0, 0, time.UTC), time.Hour, 8)
+	prs[2].Title = "Use <b> & friends"
+	return runSummary{Repo: "acme/widgets", Since: time.Date(2024, 3, 1, 0, 0, 0, 0, time.UTC), PRs: prs, File: "generated/csv/out.csv"}
+}
+
+func TestSlackNotifier(t *testing.T) {
+	server, bodies := captureServer(t, http.StatusOK)
+	if err := (slackNotifier{WebhookURL: server.URL}).Notify(testSummary()); err != nil {
+		t.Fatal(err)
+	}
+	if len(*bodies) != 1 {
+		t.Fatalf("posted %d messages, want 1", len(*bodies))
+	}
+	body := (*bodies)[0]
+	if body["text"] != "8 PRs merged in acme/widgets since 2024-03-01" {
+		t.Errorf("text = %q", body["text"])
+	}
+	text := body["blocks"].([]any)[0].(map[string]any)["text"].(map[string]any)["text"].(string)
+	// The largest PRs come first, and only five are listed
+	if !strings.Contains(text, "<https://github.com/acme/widgets/pull/8|#8>") || strings.Contains(text, "|#3>") {
+		t.Errorf("highlights are not the five largest PRs:\n%s", text)
+	}
+	if !strings.Contains(text, "generated/csv/out.csv") {
+		t.Errorf("message doesn't mention the output file:\n%s", text)
+	}
+}
+
+func TestSlackEscape(t *testing.T) {
+	if got := slackEscape("Use <b> & friends"); got != "Use &lt;b&gt; &amp; friends" {
+		t.Errorf("slackEscape = %q", got)
+	}
+}
+
+func TestTeamsNotifier(t *testing.T) {
+	server, bodies := captureServer(t, http.StatusOK)
+	if err := (teamsNotifier{WebhookURL: server.URL}).Notify(testSummary()); err != nil {
+		t.Fatal(err)
+	}
+	if len(*bodies) != 1 {
+		t.Fatalf("posted %d messages, want 1", len(*bodies))
+	}
+	attachment := (*bodies)[0]["attachments"].([]any)[0].(map[string]any)
+	if attachment["contentType"] != "application/vnd.microsoft.card.adaptive" {
+		t.Errorf("contentType = %q", attachment["contentType"])
+	}
+	var texts []string
+	for _, block := range attachment["content"].(map[string]any)["body"].([]any) {
+		texts = append(texts, block.(map[string]any)["text"].(string))
+	}
+	card := strings.Join(texts, "\n")
+	if texts[0] != "8 PRs merged in acme/widgets since 2024-03-01" {
+		t.Errorf("title = %q", texts[0])
+	}
+	if !strings.Contains(card, "[#8](https://github.com/acme/widgets/pull/8)") || strings.Contains(card, "[#3]") {
+		t.Errorf("highlights are not the five largest PRs:\n%s", card)
+	}
+	if !strings.Contains(card, `generated/csv/out.csv`) {
+		t.Errorf("card doesn't mention the output file:\n%s", card)
+	}
+}
+
+func TestTeamsEscape(t *testing.T) {
+	if got := teamsEscape("Fix [docs] for *all*"); got != `Fix \[docs\] for \*all\*` {
+		t.Errorf("teamsEscape = %q", got)
+	}
+}
+
+func TestDiscordNotifier(t *testing.T) {
+	server, bodies := captureServer(t, http.StatusNoContent)
+	if err := (discordNotifier{WebhookURL: server.URL}).Notify(testSummary()); err != nil {
+		t.Fatal(err)
+	}
+	if len(*bodies) != 1 {
+		t.Fatalf("posted %d messages, want 1", len(*bodies))
+	}
+	body := (*bodies)[0]
+	if parse := body["allowed_mentions"].(map[string]any)["parse"].([]any); len(parse) != 0 {
+		t.Errorf("allowed_mentions.parse = %v, want none", parse)
+	}
+	embed := body["embeds"].([]any)[0].(map[string]any)
+	if embed["title"] != "8 PRs merged in acme/widgets since 2024-03-01" {
+		t.Errorf("title = %q", embed["title"])
+	}
+	description := embed["description"].(string)
+	if !strings.Contains(description, "[#8](<https://github.com/acme/widgets/pull/8>)") || strings.Contains(description, "[#3]") {
+		t.Errorf("highlights are not the five largest PRs:\n%s", description)
+	}
+	if embed["footer"].(map[string]any)["text"] != "Saved to generated/csv/out.csv" {
+		t.Errorf("footer = %v", embed["footer"])
+	}
+}
+
+func TestDiscordEscape(t *testing.T) {
+	if got := discordEscape("Fix ~~it~~ for `all`"); got != "Fix \\~\\~it\\~\\~ for \\`all\\`" {
+		t.Errorf("discordEscape = %q", got)
+	}
+}
+
+func TestPostJSONReportsErrors(t *testing.T) {
+	server, _ := captureServer(t, http.StatusForbidden)
+	if err := postJSON(server.URL, map[string]string{}, nil); err == nil || !strings.Contains(err.Error(), "403") {
+		t.Errorf("postJSON to a failing endpoint returned %v, want a 403 error", err)
+	}
+}
+
+func TestEmailNotifier(t *testing.T) {
+	summary := testSummary()
+	summary.File = filepath.Join(t.TempDir(), "out.csv")
+	if err := os.WriteFile(summary.File, []byte("Number,Title\n1,First\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	var sent []byte
+	var sentTo []string
+	original := sendMail
+	sendMail = func(addr string, auth smtp.Auth, from string, to []string, msg []byte) error {
+		sent, sentTo = msg, to
+		return nil
+	}
+	t.Cleanup(func() { sendMail = original })
+
+	notifier := emailNotifier{Addr: "smtp.example.com:587", From: "bot@example.com", To: []string{"a@example.com", "b@example.com"}}
+	if err := notifier.Notify(summary); err != nil {
+		t.Fatal(err)
+	}
+	if len(sentTo) != 2 {
+		t.Errorf("sent to %v, want both recipients", sentTo)
+	}
+
+	msg, err := mail.ReadMessage(bytes.NewReader(sent))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if subject, _ := new(mime.WordDecoder).DecodeHeader(msg.Header.Get("Subject")); subject != summary.title() {
+		t.Errorf("subject = %q, want %q", subject, summary.title())
+	}
+	_, params, err := mime.ParseMediaType(msg.Header.Get("Content-Type"))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	parts := multipart.NewReader(msg.Body, params["boundary"])
+	body, err := parts.NextPart()
+	if err != nil {
+		t.Fatal(err)
+	}
+	text, _ := io.ReadAll(body) // quoted-printable is decoded by the reader
+	if !strings.Contains(string(text), "Largest changes") || !strings.Contains(string(text), "out.csv") {
+		t.Errorf("body missing highlights or attachment name:\n%s", text)
+	}
+
+	attachment, err := parts.NextPart()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if attachment.FileName() != "out.csv" {
+		t.Errorf("attachment name = %q", attachment.FileName())
+	}
+	encoded, _ := io.ReadAll(attachment)
+	data, err := base64.StdEncoding.DecodeString(strings.ReplaceAll(string(encoded), "\r\n", ""))
+	if err != nil || string(data) != "Number,Title\n1,First\n" {
+		t.Errorf("attachment = %q, %v", data, err)
+	}
+}
+
+func TestEmailNotifierMissingFile(t *testing.T) {
+	summary := testSummary()
+	summary.File = filepath.Join(t.TempDir(), "missing.csv")
+	if err := (emailNotifier{Addr: "localhost:25", From: "a@example.com", To: []string{"b@example.com"}}).Notify(summary); err == nil {
+		t.Error("expected an error for a missing results file")
+	}
+}
+
+func TestResultsNotifier(t *testing.T) {
+	var got resultsPayload
+	var auth string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		auth = r.Header.Get("Authorization")
+		if err := json.NewDecoder(r.Body).Decode(&got); err != nil {
+			t.Errorf("posted invalid JSON: %v", err)
+		}
+	}))
+	defer server.Close()
+
+	headers := headerFlags{}
+	t.Setenv("PRS_TOKEN", "s3cret")
+	if err := headers.Set("authorization: Bearer $PRS_TOKEN"); err != nil {
+		t.Fatal(err)
+	}
+	if err := headers.Set("no colon"); err == nil {
+		t.Error("expected an error for a header without a colon")
+	}
+
+	summary := testSummary()
+	if err := (resultsNotifier{URL: server.URL, Headers: headers}).Notify(summary); err != nil {
+		t.Fatal(err)
+	}
+	if auth != "Bearer s3cret" {
+		t.Errorf("Authorization = %q", auth)
+	}
+	if got.Repo != "acme/widgets" || got.Since != "2024-03-01" || got.Count != 8 || len(got.PRs) != 8 {
+		t.Errorf("payload = %+v", got)
+	}
+	if got.PRs[0].URL != summary.PRs[0].URL || got.PRs[0].Number != 1 {
+		t.Errorf("first PR = %+v, want results in order", got.PRs[0])
+	}
+
+	got = resultsPayload{}
+	if err := (resultsNotifier{URL: server.URL, SummaryOnly: true}).Notify(summary); err != nil {
+		t.Fatal(err)
+	}
+	if got.Count != 8 || len(got.PRs) != 5 {
+		t.Errorf("summary has count %d with %d PRs, want 8 with 5", got.Count, len(got.PRs))
+	}
+}
+
+func TestSummaryAttachmentAppended(t *testing.T) {
+	summary := testSummary()
+	summary.PRs = summary.PRs[6:]
+	summary.File = filepath.Join(t.TempDir(), "watch_acme_widgets.csv")
+	summary.Appended = true
+	// The cumulative file has every PR seen so far; only the new ones are attached
+	if err := os.WriteFile(summary.File, []byte("PR Number,Title\n1,Old\n2,Older\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	name, data, err := summary.attachment(time.Date(2024, 3, 6, 9, 30, 0, 0, time.UTC))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if name != "new_acme_widgets_20240306_093000.csv" {
+		t.Errorf("attachment name = %q, want one named after the run", name)
+	}
+	lines := strings.Split(strings.TrimSpace(string(data)), "\n")
+	if len(lines) != 3 || !strings.HasPrefix(lines[0], "PR Number,Title,Merged At") || !strings.HasPrefix(lines[1], "7,") || !strings.HasPrefix(lines[2], "8,") {
+		t.Errorf("attachment:\n%s\nwant the header and PRs 7 and 8", data)
+	}
+}
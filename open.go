@@ -2,25 +2,315 @@ package main
 
 import (
 	"fmt"
+	"net/url"
 	"os/exec"
+	"slices"
+	"strconv"
+	"strings"
 	"time"
 )
 
+// RowRange selects CSV data rows by 1-based row number; zero bounds are open-ended
+type RowRange struct {
+	Start int
+	End   int
+}
+
+// parseRowRange parses a range like "25-60", "25-", "-60", or "25"
+func parseRowRange(value string) (RowRange, error) {
+	var r RowRange
+	startStr, endStr, isRange := strings.Cut(value, "-")
+	if startStr == "" && endStr == "" {
+		return r, fmt.Errorf("invalid row range %q: give a start row, an end row, or both", value)
+	}
+	if !isRange {
+		endStr = startStr
+	}
+
+	var err error
+	if startStr != "" {
+		if r.Start, err = strconv.Atoi(startStr); err != nil || r.Start < 1 {
+			return r, fmt.Errorf("invalid start row %q", startStr)
+		}
+	}
+	if endStr != "" {
+		if r.End, err = strconv.Atoi(endStr); err != nil || r.End < 1 {
+			return r, fmt.Errorf("invalid end row %q", endStr)
+		}
+	}
+	if r.End > 0 && r.Start > r.End {
+		return r, fmt.Errorf("start row %d is after end row %d", r.Start, r.End)
+	}
+	return r, nil
+}
+
+// contains reports whether row falls within the range
+func (r RowRange) contains(row int) bool {
+	return row >= r.Start && (r.End == 0 || row <= r.End)
+}
+
+// prTabs lists the PR tabs that can be opened directly with -tab
+var prTabs = []string{"conversation", "files", "commits", "checks"}
+
+// tabURL returns the URL of the given tab of a PR. The conversation tab is the PR URL itself.
+// Any tab already on the URL is replaced, and a query or fragment is kept.
+func tabURL(prURL, tab string) string {
+	if tab == "" {
+		return prURL
+	}
+	u, err := url.Parse(prURL)
+	if err != nil {
+		return prURL
+	}
+	base := strings.TrimSuffix(u.Path, "/")
+	for _, t := range prTabs {
+		base = strings.TrimSuffix(base, "/"+t)
+	}
+	u.Path, u.RawPath = base, ""
+	if tab != "conversation" {
+		u.Path += "/" + tab
+	}
+	return u.String()
+}
+
+// matchesFilter reports whether the PR's title or URL contains filter, ignoring case
+func matchesFilter(pr PRURL, filter string) bool {
+	filter = strings.ToLower(filter)
+	return strings.Contains(strings.ToLower(pr.Title), filter) || strings.Contains(strings.ToLower(pr.URL), filter)
+}
+
+// OpenOptions holds the parameters for an open mode run
+type OpenOptions struct {
+	CSVFile string
+	// Opener is a command used to open each URL, with the URL appended as the
+	// last argument. Empty means use the platform default.
+	Opener string
+	// Browser, Profile, and NewWindow open URLs in a specific browser instead of
+	// the default one; see browserCommand
+	Browser   string
+	Profile   string
+	NewWindow bool
+	// Delay is how long to wait between opening URLs
+	Delay time.Duration
+	// Reverse opens the URLs in the opposite order to the CSV file
+	Reverse bool
+	// BatchSize pauses after every BatchSize URLs; 0 opens everything in one go
+	BatchSize int
+	// BatchWait is how long to pause between batches; 0 waits for Enter
+	BatchWait time.Duration
+	// Rows limits which CSV rows are opened
+	Rows RowRange
+	// Tab is the PR tab to land on, one of prTabs; empty means conversation
+	Tab string
+	// Filter only opens PRs whose title or URL contains it, case-insensitively
+	Filter string
+	// PrintOnly lists the URLs that would be opened instead of opening them
+	PrintOnly bool
+	// GroupBy is "repo" to open one repo's PRs at a time, pausing between repos
+	GroupBy string
+	// DryRun prints the command that would open each URL instead of running it
+	DryRun bool
+	// Resume skips rows opened by a previous session of the same CSV file
+	Resume bool
+}
+
+// tracksProgress reports whether progress is saved to a sidecar state file. There's
+// no file to keep it next to when reading stdin, and the saved last row doesn't
+// describe progress once grouping has reordered the rows.
+func (o OpenOptions) tracksProgress() bool {
+	return o.CSVFile != "-" && o.GroupBy == ""
+}
+
+// pause waits between batches or repo groups, either for opts.BatchWait or until the user presses Enter
+func pause(opts OpenOptions, message string) {
+	if opts.BatchWait > 0 {
+		fmt.Printf("\n%s, waiting %s before continuing...\n", message, opts.BatchWait)
+		time.Sleep(opts.BatchWait)
+		return
+	}
+	promptUser(fmt.Sprintf("\n%s, press Enter to continue...", message))
+}
+
+// repoFromURL returns the owner/repo part of a GitHub PR URL, or "" if it can't be determined
+func repoFromURL(prURL string) string {
+	u, err := url.Parse(prURL)
+	if err != nil {
+		return ""
+	}
+	parts := strings.Split(strings.Trim(u.Path, "/"), "/")
+	if len(parts) < 2 {
+		return ""
+	}
+	return parts[0] + "/" + parts[1]
+}
+
+// groupByRepo reorders the PRs so each repo's PRs are together, keeping repos
+// in order of first appearance and PRs in their original order within a repo
+func groupByRepo(prURLs []PRURL) []PRURL {
+	var repos []string
+	groups := make(map[string][]PRURL)
+	for _, pr := range prURLs {
+		repo := repoFromURL(pr.URL)
+		if _, ok := groups[repo]; !ok {
+			repos = append(repos, repo)
+		}
+		groups[repo] = append(groups[repo], pr)
+	}
+
+	grouped := make([]PRURL, 0, len(prURLs))
+	for _, repo := range repos {
+		grouped = append(grouped, groups[repo]...)
+	}
+	return grouped
+}
+
+// startOpen starts a command that opens a URL; tests replace it to capture URLs
+var startOpen = func(cmd *exec.Cmd) error {
+	return cmd.Start()
+}
+
 // openPRsFromCSV opens PR URLs from a CSV file in the default browser
-func openPRsFromCSV(csvFile string) error {
-	prURLs, err := ParsePRURLsFromCSV(csvFile)
+func openPRsFromCSV(opts OpenOptions) error {
+	prURLs, err := ParsePRURLsFromCSV(opts.CSVFile)
 	if err != nil {
 		return err
 	}
 
+	// Report skipped rows on stderr so -print output stays usable in pipelines
+	prURLs, skipped := validatePRURLs(prURLs)
+	for _, msg := range skipped {
+		warnf("Skipping %s\n", msg)
+		events.Warn("row_skipped", "reason", msg)
+	}
+	if len(skipped) > 0 {
+		warnf("Skipped %d rows\n", len(skipped))
+	}
+
+	var selected []PRURL
+	for _, pr := range prURLs {
+		if opts.Rows.contains(pr.Row) && matchesFilter(pr, opts.Filter) {
+			selected = append(selected, pr)
+		}
+	}
+	prURLs = selected
+
+	if len(prURLs) == 0 {
+		fmt.Println("No PRs to open in the selected rows matching the filter.")
+		return nil
+	}
+
+	if opts.Resume && opts.tracksProgress() {
+		if prURLs, err = skipOpenedRows(opts, prURLs); err != nil {
+			return err
+		}
+		if len(prURLs) == 0 {
+			fmt.Printf("All selected PRs were opened in a previous session. Use -restart to open them again.\n")
+			return nil
+		}
+	}
+
+	if opts.Reverse {
+		slices.Reverse(prURLs)
+	}
+
+	if opts.GroupBy == "repo" {
+		prURLs = groupByRepo(prURLs)
+	}
+
+	if opts.PrintOnly {
+		for _, pr := range prURLs {
+			fmt.Println(tabURL(pr.URL, opts.Tab))
+		}
+		return nil
+	}
+
+	if opts.DryRun {
+		for i, pr := range prURLs {
+			cmd, err := openCommand(opts, tabURL(pr.URL, opts.Tab), i == 0)
+			if err != nil {
+				return err
+			}
+			fmt.Printf("Would open row %d: %s\n", pr.Row, strings.Join(cmd.Args, " "))
+		}
+		return nil
+	}
+
+	// Failed rows are paced like the others, so a broken opener doesn't spin
+	// through the file, but progress isn't saved past the first one so the
+	// next session retries it
+	sincePause := 0 // URLs tried since the last pause
+	failedRow := 0  // the first row that failed to open
 	for i, pr := range prURLs {
-		fmt.Printf("\nOpening PR %d/%d: %s\n", i+1, len(prURLs), pr.URL)
-		if err := exec.Command("open", pr.URL).Start(); err != nil {
+		url := tabURL(pr.URL, opts.Tab)
+		fmt.Printf("\nOpening PR %d/%d (row %d): %s\n", i+1, len(prURLs), pr.Row, url)
+		cmd, err := openCommand(opts, url, i == 0)
+		if err != nil {
+			return err
+		}
+		if err := startOpen(cmd); err != nil {
 			fmt.Printf("Error opening URL: %v\n", err)
+			events.Error("open_failed", "row", pr.Row, "url", url, "error", err.Error())
+			if failedRow == 0 {
+				failedRow = pr.Row
+			}
+		} else {
+			events.Info("pr_opened", "row", pr.Row, "url", url)
+			if opts.tracksProgress() && failedRow == 0 {
+				if err := saveOpenState(opts.CSVFile, openState{LastRow: pr.Row, Reverse: opts.Reverse}); err != nil {
+					fmt.Printf("Warning: Error saving progress: %v\n", err)
+				}
+			}
+		}
+		sincePause++
+		if i == len(prURLs)-1 {
+			break
+		}
+		if opts.GroupBy == "repo" {
+			if repo, next := repoFromURL(pr.URL), repoFromURL(prURLs[i+1].URL); next != repo {
+				pause(opts, fmt.Sprintf("Finished %s, next is %s", repo, next))
+				sincePause = 0
+				continue
+			}
+		}
+		if opts.BatchSize > 0 && sincePause%opts.BatchSize == 0 {
+			pause(opts, fmt.Sprintf("Opened %d/%d PRs", i+1, len(prURLs)))
+			sincePause = 0
 			continue
 		}
-		time.Sleep(time.Second)
+		time.Sleep(opts.Delay)
+	}
+
+	if opts.tracksProgress() {
+		if failedRow != 0 {
+			fmt.Printf("\nRow %d failed to open, so the next session resumes from it.\n", failedRow)
+		} else if err := clearOpenState(opts.CSVFile); err != nil {
+			fmt.Printf("Warning: Error removing progress file: %v\n", err)
+		}
 	}
 
 	return nil
 }
+
+// skipOpenedRows drops the rows a previous session already opened, based on the saved state
+func skipOpenedRows(opts OpenOptions, prURLs []PRURL) ([]PRURL, error) {
+	state, err := loadOpenState(opts.CSVFile)
+	if err != nil {
+		return nil, err
+	}
+	if state == nil {
+		return prURLs, nil
+	}
+	if state.Reverse != opts.Reverse {
+		fmt.Println("Ignoring saved progress from a session that used a different -reverse setting.")
+		return prURLs, nil
+	}
+
+	var remaining []PRURL
+	for _, pr := range prURLs {
+		if (!opts.Reverse && pr.Row > state.LastRow) || (opts.Reverse && pr.Row < state.LastRow) {
+			remaining = append(remaining, pr)
+		}
+	}
+	fmt.Printf("Resuming after row %d from a previous session (use -restart to start over).\n", state.LastRow)
+	return remaining, nil
+}
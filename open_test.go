@@ -0,0 +1,267 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"slices"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestTabURL(t *testing.T) {
+	tests := []struct {
+		url, tab, want string
+	}{
+		{"https://github.com/acme/widgets/pull/1", "", "https://github.com/acme/widgets/pull/1"},
+		{"https://github.com/acme/widgets/pull/1", "files", "https://github.com/acme/widgets/pull/1/files"},
+		{"https://github.com/acme/widgets/pull/1/", "commits", "https://github.com/acme/widgets/pull/1/commits"},
+		{"https://github.com/acme/widgets/pull/1/files", "checks", "https://github.com/acme/widgets/pull/1/checks"},
+		{"https://github.com/acme/widgets/pull/1/files/", "conversation", "https://github.com/acme/widgets/pull/1"},
+		{"https://github.com/acme/widgets/pull/1?w=1", "files", "https://github.com/acme/widgets/pull/1/files?w=1"},
+		{"https://github.com/acme/widgets/pull/1#issuecomment-5", "files", "https://github.com/acme/widgets/pull/1/files#issuecomment-5"},
+		{"https://github.com/acme/widgets/pull/1/commits?w=1#top", "conversation", "https://github.com/acme/widgets/pull/1?w=1#top"},
+	}
+	for _, tt := range tests {
+		if got := tabURL(tt.url, tt.tab); got != tt.want {
+			t.Errorf("tabURL(%s, %q) = %s, want %s", tt.url, tt.tab, got, tt.want)
+		}
+	}
+}
+
+func TestParseRowRange(t *testing.T) {
+	tests := []struct {
+		value   string
+		want    RowRange
+		wantErr string
+	}{
+		{value: "25-60", want: RowRange{Start: 25, End: 60}},
+		{value: "25-", want: RowRange{Start: 25}},
+		{value: "-60", want: RowRange{End: 60}},
+		{value: "25", want: RowRange{Start: 25, End: 25}},
+		{value: "60-25", wantErr: "after end row"},
+		{value: "0", wantErr: "invalid start row"},
+		{value: "0-5", wantErr: "invalid start row"},
+		{value: "5-0", wantErr: "invalid end row"},
+		{value: "abc", wantErr: "invalid start row"},
+		{value: "1-x", wantErr: "invalid end row"},
+		{value: "-", wantErr: "invalid row range"},
+	}
+	for _, tt := range tests {
+		got, err := parseRowRange(tt.value)
+		if tt.wantErr != "" {
+			if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+				t.Errorf("parseRowRange(%q) = %+v, %v, want an error containing %q", tt.value, got, err, tt.wantErr)
+			}
+			continue
+		}
+		if err != nil || got != tt.want {
+			t.Errorf("parseRowRange(%q) = %+v, %v, want %+v", tt.value, got, err, tt.want)
+		}
+	}
+}
+
+// writeOpenCSV writes a CSV of PR URLs for rows 1 through count and returns its path
+func writeOpenCSV(t *testing.T, count int) string {
+	t.Helper()
+	lines := []string{"URL"}
+	for i := 1; i <= count; i++ {
+		lines = append(lines, fmt.Sprintf("https://github.com/acme/widgets/pull/%d", i))
+	}
+	path := filepath.Join(t.TempDir(), "prs.csv")
+	if err := os.WriteFile(path, []byte(strings.Join(lines, "\n")+"\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	return path
+}
+
+// rowsOf returns the row numbers of prURLs
+func rowsOf(prURLs []PRURL) []int {
+	var rows []int
+	for _, pr := range prURLs {
+		rows = append(rows, pr.Row)
+	}
+	return rows
+}
+
+func TestSkipOpenedRows(t *testing.T) {
+	var prURLs []PRURL
+	for row := 1; row <= 5; row++ {
+		prURLs = append(prURLs, PRURL{URL: fmt.Sprintf("https://github.com/acme/widgets/pull/%d", row), Row: row})
+	}
+	tests := []struct {
+		name    string
+		state   *openState
+		reverse bool
+		want    []int
+	}{
+		{"no saved state", nil, false, []int{1, 2, 3, 4, 5}},
+		{"skips through LastRow", &openState{LastRow: 3}, false, []int{4, 5}},
+		{"reverse skips rows after LastRow", &openState{LastRow: 3, Reverse: true}, true, []int{1, 2}},
+		{"reverse mismatch ignores the state", &openState{LastRow: 3, Reverse: true}, false, []int{1, 2, 3, 4, 5}},
+		{"finished session leaves nothing", &openState{LastRow: 5}, false, nil},
+	}
+	for _, tt := range tests {
+		csvFile := filepath.Join(t.TempDir(), "prs.csv")
+		if tt.state != nil {
+			if err := saveOpenState(csvFile, *tt.state); err != nil {
+				t.Fatal(err)
+			}
+		}
+		remaining, err := skipOpenedRows(OpenOptions{CSVFile: csvFile, Reverse: tt.reverse}, prURLs)
+		if err != nil {
+			t.Fatalf("%s: %v", tt.name, err)
+		}
+		if got := rowsOf(remaining); !slices.Equal(got, tt.want) {
+			t.Errorf("%s: remaining rows %v, want %v", tt.name, got, tt.want)
+		}
+	}
+}
+
+func TestSkipOpenedRowsCorruptState(t *testing.T) {
+	csvFile := filepath.Join(t.TempDir(), "prs.csv")
+	if err := os.WriteFile(stateFilePath(csvFile), []byte("{not json"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := skipOpenedRows(OpenOptions{CSVFile: csvFile}, []PRURL{{Row: 1}}); err == nil || !strings.Contains(err.Error(), "error parsing state file") {
+		t.Errorf("err = %v, want the corrupt state file reported", err)
+	}
+}
+
+// captureOpens replaces startOpen for the rest of the test, recording each
+// URL opened; URLs ending in one of fail fail to open
+func captureOpens(t *testing.T, fail ...string) *[]string {
+	t.Helper()
+	var opened []string
+	original := startOpen
+	t.Cleanup(func() { startOpen = original })
+	startOpen = func(cmd *exec.Cmd) error {
+		url := cmd.Args[len(cmd.Args)-1]
+		for _, suffix := range fail {
+			if strings.HasSuffix(url, suffix) {
+				return errors.New("exec: not found")
+			}
+		}
+		opened = append(opened, url)
+		return nil
+	}
+	return &opened
+}
+
+func TestOpenPRsFromCSVResumes(t *testing.T) {
+	csvFile := writeOpenCSV(t, 4)
+	if err := saveOpenState(csvFile, openState{LastRow: 2}); err != nil {
+		t.Fatal(err)
+	}
+	opened := captureOpens(t)
+
+	if err := openPRsFromCSV(OpenOptions{CSVFile: csvFile, Opener: "open-url", Resume: true}); err != nil {
+		t.Fatal(err)
+	}
+	want := []string{"https://github.com/acme/widgets/pull/3", "https://github.com/acme/widgets/pull/4"}
+	if !slices.Equal(*opened, want) {
+		t.Errorf("opened %q, want only rows 3 and 4", *opened)
+	}
+	if _, err := os.Stat(stateFilePath(csvFile)); !os.IsNotExist(err) {
+		t.Errorf("state file left after opening everything: %v", err)
+	}
+	if err := clearOpenState(csvFile); err != nil {
+		t.Errorf("clearing state that isn't there: %v", err)
+	}
+}
+
+func TestOpenPRsFromCSVFailedRow(t *testing.T) {
+	csvFile := writeOpenCSV(t, 4)
+	opened := captureOpens(t, "/pull/2")
+	opts := OpenOptions{CSVFile: csvFile, Opener: "open-url", Resume: true, Delay: 20 * time.Millisecond}
+
+	started := time.Now()
+	if err := openPRsFromCSV(opts); err != nil {
+		t.Fatal(err)
+	}
+	// Three delays: the failed row waits like the others
+	if elapsed := time.Since(started); elapsed < 60*time.Millisecond {
+		t.Errorf("opening 4 rows took %s, want a delay after each but the last", elapsed)
+	}
+	if len(*opened) != 3 {
+		t.Errorf("opened %q, want rows 1, 3, and 4", *opened)
+	}
+	state, err := loadOpenState(csvFile)
+	if err != nil || state == nil || state.LastRow != 1 {
+		t.Fatalf("saved state = %+v, %v, want LastRow 1 so row 2 is retried", state, err)
+	}
+
+	*opened = nil
+	if err := openPRsFromCSV(opts); err != nil {
+		t.Fatal(err)
+	}
+	if len(*opened) != 2 || !strings.HasSuffix((*opened)[0], "/pull/3") {
+		t.Errorf("resumed session opened %q, want it to retry row 2 and reopen rows 3 and 4", *opened)
+	}
+}
+
+func TestGroupByRepo(t *testing.T) {
+	var prURLs []PRURL
+	for i, repo := range []string{"acme/widgets", "acme/gadgets", "acme/widgets", "acme/bolts", "acme/gadgets"} {
+		prURLs = append(prURLs, PRURL{URL: fmt.Sprintf("https://github.com/%s/pull/%d", repo, i+1), Row: i + 1})
+	}
+	// Repos in order of first appearance, not sorted, and rows in order within each
+	if got := rowsOf(groupByRepo(prURLs)); !slices.Equal(got, []int{1, 3, 2, 5, 4}) {
+		t.Errorf("grouped rows %v, want 1, 3, 2, 5, 4", got)
+	}
+	if got := groupByRepo(nil); len(got) != 0 {
+		t.Errorf("groupByRepo(nil) = %v", got)
+	}
+}
+
+// captureStdout returns what f prints to stdout
+func captureStdout(t *testing.T, f func()) string {
+	t.Helper()
+	r, w, err := os.Pipe()
+	if err != nil {
+		t.Fatal(err)
+	}
+	original := os.Stdout
+	os.Stdout = w
+	done := make(chan []byte)
+	go func() {
+		data, _ := io.ReadAll(r)
+		done <- data
+	}()
+	f()
+	os.Stdout = original
+	w.Close()
+	return string(<-done)
+}
+
+func TestOpenPRsFromCSVPausesBetweenRepos(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "prs.csv")
+	csv := "URL\nhttps://github.com/acme/widgets/pull/1\nhttps://github.com/acme/gadgets/pull/2\nhttps://github.com/acme/widgets/pull/3\n"
+	if err := os.WriteFile(path, []byte(csv), 0644); err != nil {
+		t.Fatal(err)
+	}
+	opened := captureOpens(t)
+
+	out := captureStdout(t, func() {
+		if err := openPRsFromCSV(OpenOptions{CSVFile: path, Opener: "open-url", GroupBy: "repo", BatchWait: time.Millisecond}); err != nil {
+			t.Error(err)
+		}
+	})
+	if len(*opened) != 3 || !strings.HasSuffix((*opened)[1], "/widgets/pull/3") {
+		t.Errorf("opened %q, want both widgets PRs before the gadgets one", *opened)
+	}
+	if n := strings.Count(out, "waiting"); n != 1 {
+		t.Errorf("paused %d times, want once between the two repos:\n%s", n, out)
+	}
+	pauseAt := strings.Index(out, "Finished acme/widgets, next is acme/gadgets")
+	if pauseAt < 0 || pauseAt < strings.Index(out, "/widgets/pull/3") || pauseAt > strings.Index(out, "/gadgets/pull/2") {
+		t.Errorf("want the pause between the repos:\n%s", out)
+	}
+	if _, err := os.Stat(stateFilePath(path)); !os.IsNotExist(err) {
+		t.Errorf("grouped session saved progress: %v", err)
+	}
+}
@@ -0,0 +1,307 @@
+package main
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"encoding/xml"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"text/template"
+	"unicode"
+)
+
+// Table is the rendered output of a list mode run: a header row and one row per
+// PR, along with the PRs themselves for formats that need more than the columns
+type Table struct {
+	Header []string
+	Rows   [][]string
+	PRs    []PR
+	// Kinds is how each column's values are typed in JSON; columns past the
+	// end are text
+	Kinds []cellKind
+}
+
+// cellKind is the type of a column's values, for formats with typed values
+type cellKind int
+
+const (
+	textCell cellKind = iota
+	numberCell
+	boolCell
+)
+
+// textThenNumbers returns the kinds for a table of total columns whose first
+// text columns are text and the rest numbers
+func textThenNumbers(text, total int) []cellKind {
+	kinds := make([]cellKind, total)
+	for i := text; i < total; i++ {
+		kinds[i] = numberCell
+	}
+	return kinds
+}
+
+// kind returns the kind of column i
+func (t Table) kind(i int) cellKind {
+	if i < len(t.Kinds) {
+		return t.Kinds[i]
+	}
+	return textCell
+}
+
+// Writer writes a Table in one export format
+type Writer interface {
+	// Extension is the file extension for the format, including the dot
+	Extension() string
+	Write(w io.Writer, table Table) error
+}
+
+// writers maps the names accepted by -format to their Writer
+var writers = map[string]Writer{}
+
+// registerWriter makes a Writer available under name for -format. Registering
+// a name twice replaces the earlier Writer.
+func registerWriter(name string, w Writer) {
+	writers[name] = w
+}
+
+func init() {
+	registerWriter("csv", csvWriter{})
+	registerWriter("json", jsonWriter{})
+	registerWriter("md", markdownWriter{})
+}
+
+// writerNames returns the registered format names, sorted
+func writerNames() []string {
+	names := make([]string, 0, len(writers))
+	for name := range writers {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// lookupWriter returns the Writer for a format name; empty means csv
+func lookupWriter(format string) (Writer, error) {
+	if format == "" {
+		format = "csv"
+	}
+	w, ok := writers[format]
+	if !ok {
+		return nil, fmt.Errorf("unknown format %q, must be one of %s", format, strings.Join(writerNames(), ", "))
+	}
+	return w, nil
+}
+
+// buildTable renders the PRs with the columns selected by opts
+func buildTable(prs []PR, opts ListOptions) Table {
+	columns := outputColumns(opts)
+	table := Table{Header: make([]string, len(columns)), Kinds: make([]cellKind, len(columns)), PRs: prs}
+	for i, col := range columns {
+		table.Header[i] = col.Header
+		table.Kinds[i] = col.Kind
+	}
+	for _, pr := range prs {
+		record := make([]string, len(columns))
+		for i, col := range columns {
+			record[i] = col.Value(pr)
+		}
+		table.Rows = append(table.Rows, record)
+	}
+	return table
+}
+
+// saveResults saves the PR list to outputFile in the format selected by opts
+func saveResults(prs []PR, opts ListOptions, outputFile string) error {
+	writer, err := lookupWriter(opts.Format)
+	if err != nil {
+		return err
+	}
+
+	file, err := os.Create(outputFile)
+	if err != nil {
+		return err
+	}
+	if err := writer.Write(file, buildTable(prs, opts)); err != nil {
+		file.Close()
+		return err
+	}
+	return file.Close()
+}
+
+// csvWriter writes comma-separated values, which open mode can read back
+type csvWriter struct{}
+
+func (csvWriter) Extension() string { return ".csv" }
+
+func (csvWriter) Write(w io.Writer, table Table) error {
+	writer := csv.NewWriter(w)
+	if err := writer.Write(table.Header); err != nil {
+		return err
+	}
+	if err := writer.WriteAll(table.Rows); err != nil {
+		return err
+	}
+	return writer.Error()
+}
+
+// jsonWriter writes an array with one object per row, in column order. Keys
+// are the headers in camelCase, such as timeToMergeHours, and numbers and
+// booleans are typed by the table's Kinds, with empty ones as null.
+type jsonWriter struct{}
+
+func (jsonWriter) Extension() string { return ".json" }
+
+func (jsonWriter) Write(w io.Writer, table Table) error {
+	keys := make([][]byte, len(table.Header))
+	for i, header := range table.Header {
+		key, err := json.Marshal(jsonKey(header))
+		if err != nil {
+			return err
+		}
+		keys[i] = key
+	}
+
+	var b strings.Builder
+	b.WriteString("[")
+	for i, row := range table.Rows {
+		if i > 0 {
+			b.WriteString(",")
+		}
+		b.WriteString("\n  {")
+		for j, value := range row {
+			if j > 0 {
+				b.WriteString(", ")
+			}
+			typed, err := typedValue(value, table.kind(j))
+			if err != nil {
+				return fmt.Errorf("column %q: %v", table.Header[j], err)
+			}
+			val, err := json.Marshal(typed)
+			if err != nil {
+				return err
+			}
+			fmt.Fprintf(&b, "%s: %s", keys[j], val)
+		}
+		b.WriteString("}")
+	}
+	b.WriteString("\n]\n")
+	_, err := io.WriteString(w, b.String())
+	return err
+}
+
+// jsonKey turns a column header into a camelCase key, so "Time To Merge
+// (hours)" becomes timeToMergeHours and "PR Number" becomes prNumber
+func jsonKey(header string) string {
+	words := strings.FieldsFunc(header, func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsDigit(r)
+	})
+	var b strings.Builder
+	for i, word := range words {
+		word = strings.ToLower(word)
+		if i > 0 {
+			word = strings.ToUpper(word[:1]) + word[1:]
+		}
+		b.WriteString(word)
+	}
+	return b.String()
+}
+
+// typedValue converts a cell to the Go value for its kind
+func typedValue(value string, kind cellKind) (any, error) {
+	if kind == textCell {
+		return value, nil
+	}
+	if value == "" {
+		return nil, nil
+	}
+	if kind == boolCell {
+		b, err := strconv.ParseBool(value)
+		if err != nil {
+			return nil, fmt.Errorf("%q is not a boolean", value)
+		}
+		return b, nil
+	}
+	n, err := strconv.ParseFloat(value, 64)
+	if err != nil {
+		return nil, fmt.Errorf("%q is not a number", value)
+	}
+	return n, nil
+}
+
+// markdownWriter writes a GitHub-flavored Markdown table
+type markdownWriter struct{}
+
+func (markdownWriter) Extension() string { return ".md" }
+
+func (markdownWriter) Write(w io.Writer, table Table) error {
+	var b strings.Builder
+	writeRow := func(cells []string) {
+		b.WriteString("|")
+		for _, cell := range cells {
+			cell = strings.ReplaceAll(cell, "|", `\|`)
+			cell = strings.ReplaceAll(cell, "\n", " ")
+			b.WriteString(" " + cell + " |")
+		}
+		b.WriteString("\n")
+	}
+
+	writeRow(table.Header)
+	b.WriteString(strings.Repeat("| --- ", len(table.Header)) + "|\n")
+	for _, row := range table.Rows {
+		writeRow(row)
+	}
+	_, err := io.WriteString(w, b.String())
+	return err
+}
+
+// templateFuncs are the helpers available to -template files, mostly for escaping values
+var templateFuncs = template.FuncMap{
+	"join":  strings.Join,
+	"lower": strings.ToLower,
+	"upper": strings.ToUpper,
+	"json": func(v any) (string, error) {
+		data, err := json.Marshal(v)
+		return string(data), err
+	},
+	"xml": func(s string) (string, error) {
+		var b strings.Builder
+		err := xml.EscapeText(&b, []byte(s))
+		return b.String(), err
+	},
+}
+
+// templateWriter renders a Table through a user-supplied text/template
+type templateWriter struct {
+	tmpl *template.Template
+	ext  string
+}
+
+// newTemplateWriter parses the template file at path. Output files take the
+// extension before .tmpl, so report.adoc.tmpl writes .adoc files.
+func newTemplateWriter(path string) (templateWriter, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return templateWriter{}, fmt.Errorf("error reading template: %v", err)
+	}
+	tmpl, err := template.New(filepath.Base(path)).Funcs(templateFuncs).Parse(string(data))
+	if err != nil {
+		return templateWriter{}, fmt.Errorf("error parsing template: %v", err)
+	}
+
+	ext := filepath.Ext(strings.TrimSuffix(path, ".tmpl"))
+	if ext == "" || ext == filepath.Ext(path) {
+		ext = ".txt"
+	}
+	return templateWriter{tmpl: tmpl, ext: ext}, nil
+}
+
+func (t templateWriter) Extension() string { return t.ext }
+
+func (t templateWriter) Write(w io.Writer, table Table) error {
+	return t.tmpl.Execute(w, table)
+}
@@ -0,0 +1,110 @@
+package main
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+var testTable = Table{
+	Header: []string{"PR Number", "Title"},
+	Rows:   [][]string{{"1", `Fix "quoting" | pipes`}, {"2", "Line\nbreak"}},
+}
+
+func TestWriters(t *testing.T) {
+	tests := []struct {
+		format string
+		want   string
+	}{
+		{"csv", "PR Number,Title\n1,\"Fix \"\"quoting\"\" | pipes\"\n2,\"Line\nbreak\"\n"},
+		{"md", "| PR Number | Title |\n| --- | --- |\n| 1 | Fix \"quoting\" \\| pipes |\n| 2 | Line break |\n"},
+	}
+	for _, tt := range tests {
+		writer, err := lookupWriter(tt.format)
+		if err != nil {
+			t.Fatal(err)
+		}
+		var b strings.Builder
+		if err := writer.Write(&b, testTable); err != nil {
+			t.Fatal(err)
+		}
+		if b.String() != tt.want {
+			t.Errorf("%s output:\n%s\nwant:\n%s", tt.format, b.String(), tt.want)
+		}
+	}
+}
+
+func TestJSONWriter(t *testing.T) {
+	table := Table{
+		Header: []string{"PR Number", "Title", "Time To Merge (hours)", "Draft"},
+		Rows:   [][]string{{"1", `Fix "quoting" | pipes`, "2.50", "true"}, {"2", "Line\nbreak", "", "false"}},
+		Kinds:  []cellKind{numberCell, textCell, numberCell, boolCell},
+	}
+	var b strings.Builder
+	if err := (jsonWriter{}).Write(&b, table); err != nil {
+		t.Fatal(err)
+	}
+	if !strings.HasPrefix(b.String(), "[\n  {\"prNumber\": 1, \"title\"") {
+		t.Errorf("keys are not in column order:\n%s", b.String())
+	}
+
+	var rows []map[string]any
+	if err := json.Unmarshal([]byte(b.String()), &rows); err != nil {
+		t.Fatalf("output is not valid JSON: %v\n%s", err, b.String())
+	}
+	if len(rows) != 2 || rows[0]["timeToMergeHours"] != 2.5 || rows[0]["draft"] != true || rows[1]["title"] != "Line\nbreak" || rows[1]["timeToMergeHours"] != nil {
+		t.Errorf("decoded %v", rows)
+	}
+
+	table.Rows[1][0] = "two"
+	if err := (jsonWriter{}).Write(&b, table); err == nil || !strings.Contains(err.Error(), `"PR Number"`) {
+		t.Errorf("err = %v, want the column that isn't a number", err)
+	}
+}
+
+func TestJSONKey(t *testing.T) {
+	for header, want := range map[string]string{"PR Number": "prNumber", "URL": "url", "Time To Merge p50 (days)": "timeToMergeP50Days", "Jira URL": "jiraUrl"} {
+		if got := jsonKey(header); got != want {
+			t.Errorf("jsonKey(%q) = %q, want %q", header, got, want)
+		}
+	}
+}
+
+func TestLookupWriterDefaultsToCSV(t *testing.T) {
+	writer, err := lookupWriter("")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if writer.Extension() != ".csv" {
+		t.Errorf("default writer extension = %q, want .csv", writer.Extension())
+	}
+	if _, err := lookupWriter("xml"); err == nil {
+		t.Error("lookupWriter(\"xml\") succeeded, want an error")
+	}
+}
+
+func TestTemplateWriter(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "report.xml.tmpl")
+	tmpl := `{{range .PRs}}<pr n="{{.Number}}" changes="{{.Changes}}">{{xml .Title}}</pr>{{end}}`
+	if err := os.WriteFile(path, []byte(tmpl), 0644); err != nil {
+		t.Fatal(err)
+	}
+	writer, err := newTemplateWriter(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if writer.Extension() != ".xml" {
+		t.Errorf("extension = %q, want .xml from the template name", writer.Extension())
+	}
+
+	var b strings.Builder
+	table := Table{PRs: []PR{{Number: "3", Title: "Use <b> & friends", Additions: 2, Deletions: 1}}}
+	if err := writer.Write(&b, table); err != nil {
+		t.Fatal(err)
+	}
+	if want := `<pr n="3" changes="3">Use &lt;b&gt; &amp; friends</pr>`; b.String() != want {
+		t.Errorf("rendered %q, want %q", b.String(), want)
+	}
+}
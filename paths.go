@@ -0,0 +1,110 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// defaultOutputDir is where list mode saves its results, relative to the working directory
+var defaultOutputDir = filepath.Join("generated", "csv")
+
+// expandHome replaces a leading ~ in path with the user's home directory
+func expandHome(path string) (string, error) {
+	if path != "~" && !strings.HasPrefix(path, "~/") && !strings.HasPrefix(path, `~\`) {
+		return path, nil
+	}
+	home, err := os.UserHomeDir()
+	if err != nil {
+		return "", fmt.Errorf("error expanding ~: %v", err)
+	}
+	return filepath.Join(home, path[1:]), nil
+}
+
+// prepareOutputDir creates dir if needed and checks that files can be written
+// to it, so a bad output directory is reported before anything is fetched
+func prepareOutputDir(dir string) error {
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return fmt.Errorf("error creating output directory: %v", err)
+	}
+	return checkWritable(dir)
+}
+
+// checkWritable checks that files can be created in the existing directory dir
+func checkWritable(dir string) error {
+	file, err := os.CreateTemp(dir, ".write-check-*")
+	if err != nil {
+		return fmt.Errorf("output directory %s is not writable: %v", dir, err)
+	}
+	file.Close()
+	return os.Remove(file.Name())
+}
+
+// nearestExistingDir returns dir if it exists, or else its closest parent
+// that does, which is where creating dir would start
+func nearestExistingDir(dir string) (string, error) {
+	for {
+		info, err := os.Stat(dir)
+		if err == nil {
+			if !info.IsDir() {
+				return "", fmt.Errorf("%s is not a directory", dir)
+			}
+			return dir, nil
+		}
+		if !os.IsNotExist(err) {
+			return "", err
+		}
+		parent := filepath.Dir(dir)
+		if parent == dir {
+			return "", err
+		}
+		dir = parent
+	}
+}
+
+// unusedPath returns path if nothing exists there yet, or else the first of
+// name-1.ext, name-2.ext, and so on that is free
+func unusedPath(path string) string {
+	ext := filepath.Ext(path)
+	stem := strings.TrimSuffix(path, ext)
+	for i := 1; ; i++ {
+		if _, err := os.Lstat(path); os.IsNotExist(err) {
+			return path
+		}
+		path = fmt.Sprintf("%s-%d%s", stem, i, ext)
+	}
+}
+
+// windowsReservedNames are device names Windows won't allow as a file name,
+// with or without an extension
+var windowsReservedNames = map[string]bool{
+	"CON": true, "PRN": true, "AUX": true, "NUL": true,
+	"COM1": true, "COM2": true, "COM3": true, "COM4": true, "COM5": true, "COM6": true, "COM7": true, "COM8": true, "COM9": true,
+	"LPT1": true, "LPT2": true, "LPT3": true, "LPT4": true, "LPT5": true, "LPT6": true, "LPT7": true, "LPT8": true, "LPT9": true,
+}
+
+// sanitizeFilename makes name safe to use as a file name on every platform, so
+// outputs can be copied between machines. Path separators, characters Windows
+// forbids (such as the colons in search qualifiers like label:bug), and
+// control characters become underscores, spaces become underscores to keep
+// names shell-friendly, trailing dots are dropped, and reserved device names
+// like CON get an underscore appended.
+func sanitizeFilename(name string) string {
+	name = strings.Map(func(r rune) rune {
+		if r < 32 || strings.ContainsRune(`<>:"/\|?* `, r) {
+			return '_'
+		}
+		return r
+	}, name)
+	name = strings.TrimRight(name, ".")
+
+	stem, _, _ := strings.Cut(name, ".")
+	if windowsReservedNames[strings.ToUpper(stem)] {
+		name = stem + "_" + strings.TrimPrefix(name, stem)
+	}
+	if name == "" {
+		return "_"
+	}
+	return name
+}
@@ -0,0 +1,46 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestSanitizeFilename(t *testing.T) {
+	tests := []struct {
+		name string
+		want string
+	}{
+		{"merged_prs_acme/widgets_20240501", "merged_prs_acme_widgets_20240501"},
+		{`label:bug "needs review"`, "label_bug__needs_review_"},
+		{"a<b>c|d?e*f\\g", "a_b_c_d_e_f_g"},
+		{"tab\there", "tab_here"},
+		{"trailing...", "trailing"},
+		{"CON", "CON_"},
+		{"nul.csv", "nul_.csv"},
+		{"COM10", "COM10"},
+		{"...", "_"},
+	}
+	for _, tt := range tests {
+		if got := sanitizeFilename(tt.name); got != tt.want {
+			t.Errorf("sanitizeFilename(%q) = %q, want %q", tt.name, got, tt.want)
+		}
+	}
+}
+
+func TestUnusedPath(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "merged_prs.csv")
+	if got := unusedPath(path); got != path {
+		t.Errorf("unusedPath with no existing file = %q, want %q", got, path)
+	}
+
+	for _, name := range []string{"merged_prs.csv", "merged_prs-1.csv"} {
+		if err := os.WriteFile(filepath.Join(dir, name), nil, 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if got, want := unusedPath(path), filepath.Join(dir, "merged_prs-2.csv"); got != want {
+		t.Errorf("unusedPath = %q, want %q", got, want)
+	}
+}
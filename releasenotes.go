@@ -0,0 +1,210 @@
+package main
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"slices"
+	"strings"
+	"time"
+)
+
+// ReleaseNotesOptions holds the parameters for release-notes mode
+type ReleaseNotesOptions struct {
+	// List holds the search settings. Its Since and Until are replaced by the
+	// times From and To resolve to, when set.
+	List ListOptions
+	// From is the tag, or other git ref or YYYY-MM-DD date, the notes start
+	// after; empty means List.Since
+	From string
+	// To is where the notes end, like From; empty means List.Until
+	To string
+	// Changelog writes a keep-a-changelog section from the PR titles'
+	// conventional-commit prefixes instead of grouping the PRs by label
+	Changelog bool
+	// Output receives the notes; nil means stdout. Progress goes to stderr
+	// unless List.Progress is set, so the notes can be redirected to a file.
+	Output io.Writer
+}
+
+// releaseNotesSection is a heading in the release notes and the labels that
+// put a PR under it, compared ignoring case
+type releaseNotesSection struct {
+	Heading string
+	Labels  []string
+}
+
+// releaseNotesSections are the release notes headings in order. A PR goes
+// under the first heading one of its labels matches, or under otherChanges.
+var releaseNotesSections = []releaseNotesSection{
+	{Heading: "Breaking Changes", Labels: []string{"breaking", "breaking-change", "breaking change"}},
+	{Heading: "Features", Labels: []string{"feature", "enhancement", "feat"}},
+	{Heading: "Fixes", Labels: []string{"bug", "fix", "bugfix", "regression"}},
+	{Heading: "Documentation", Labels: []string{"documentation", "docs"}},
+	{Heading: "Chores", Labels: []string{"chore", "dependencies", "ci", "refactor", "maintenance"}},
+}
+
+// otherChanges is the heading for PRs whose labels match no section
+const otherChanges = "Other Changes"
+
+// releaseNotesSkipLabels leave a PR out of the release notes
+var releaseNotesSkipLabels = []string{"skip-changelog", "ignore-for-release"}
+
+// releaseNotesHeading returns the heading the PR belongs under, or "" if it's skipped
+func releaseNotesHeading(pr PR) string {
+	labels := make([]string, len(pr.Labels))
+	for i, label := range pr.Labels {
+		labels[i] = strings.ToLower(label)
+		if slices.Contains(releaseNotesSkipLabels, labels[i]) {
+			return ""
+		}
+	}
+	for _, section := range releaseNotesSections {
+		for _, label := range labels {
+			if slices.Contains(section.Labels, label) {
+				return section.Heading
+			}
+		}
+	}
+	return otherChanges
+}
+
+// parseRefDate parses ref if it's a YYYY-MM-DD date rather than a git ref
+func parseRefDate(ref string) (time.Time, bool) {
+	date, err := time.ParseInLocation("2006-01-02", ref, time.Local)
+	return date, err == nil
+}
+
+// resolveRef returns the time ref stands for: a YYYY-MM-DD date as is, or the
+// commit date of a tag or other git ref in the repo
+func resolveRef(opts ListOptions, ref string) (time.Time, error) {
+	if date, ok := parseRefDate(ref); ok {
+		return date, nil
+	}
+	output, err := opts.runGH("api", fmt.Sprintf("repos/%s/commits/%s", opts.Repo, ref), "--jq", ".commit.committer.date")
+	if err != nil {
+		return time.Time{}, fmt.Errorf("error resolving %s: %v", ref, err)
+	}
+	date, err := time.Parse(time.RFC3339, strings.TrimSpace(output))
+	if err != nil {
+		return time.Time{}, fmt.Errorf("error resolving %s: unexpected commit date %q", ref, output)
+	}
+	return date, nil
+}
+
+// writeReleaseNotes writes the PRs as Markdown for a GitHub release body,
+// grouped under releaseNotesSections
+func writeReleaseNotes(w io.Writer, prs []PR, opts ReleaseNotesOptions) {
+	byHeading := make(map[string][]PR)
+	for _, pr := range prs {
+		if heading := releaseNotesHeading(pr); heading != "" {
+			byHeading[heading] = append(byHeading[heading], pr)
+		}
+	}
+
+	title := "## What's Changed"
+	if opts.To != "" {
+		title = "## " + opts.To
+	}
+	fmt.Fprintln(w, title)
+	empty := true
+	for _, section := range append(releaseNotesSections, releaseNotesSection{Heading: otherChanges}) {
+		if len(byHeading[section.Heading]) == 0 {
+			continue
+		}
+		empty = false
+		fmt.Fprintf(w, "\n### %s\n", section.Heading)
+		for _, pr := range byHeading[section.Heading] {
+			line := "- " + pr.Title
+			if pr.Author != "" {
+				line += " by @" + pr.Author
+			}
+			fmt.Fprintf(w, "%s in %s\n", line, pr.URL)
+		}
+	}
+	if empty {
+		fmt.Fprintln(w, "\nNo changes.")
+	}
+	_, fromDate := parseRefDate(opts.From)
+	_, toDate := parseRefDate(opts.To)
+	if opts.From != "" && opts.To != "" && !fromDate && !toDate {
+		fmt.Fprintf(w, "\n**Full Changelog**: https://github.com/%s/compare/%s...%s\n", opts.List.Repo, opts.From, opts.To)
+	}
+}
+
+// mergedBetween returns the PRs merged after since and no later than until,
+// oldest first. The search only narrows merges down to the day.
+func mergedBetween(prs []PR, since, until time.Time) []PR {
+	var between []PR
+	for _, pr := range prs {
+		merged, err := time.Parse(time.RFC3339, pr.MergedAt)
+		if err != nil || !merged.After(since) || merged.After(until) {
+			continue
+		}
+		between = append(between, pr)
+	}
+	slices.SortStableFunc(between, func(a, b PR) int { return strings.Compare(a.MergedAt, b.MergedAt) })
+	return between
+}
+
+// runReleaseNotesMode fetches the PRs merged between two releases and writes
+// them as release notes
+func runReleaseNotesMode(opts ReleaseNotesOptions) error {
+	w := opts.Output
+	if w == nil {
+		w = os.Stdout
+	}
+	list := opts.List
+	list.Fields = []string{"author", "labels"}
+	if list.Progress == nil {
+		list.Progress = os.Stderr
+	}
+
+	unresolved := false
+	for _, bound := range []struct {
+		ref  string
+		time *time.Time
+	}{
+		{opts.From, &list.Since},
+		{opts.To, &list.Until},
+	} {
+		if bound.ref == "" {
+			continue
+		}
+		if _, isDate := parseRefDate(bound.ref); list.DryRun && !isDate {
+			list.printf("Would resolve %s with %s\n", bound.ref, formatGHCommand("api", fmt.Sprintf("repos/%s/commits/%s", list.Repo, bound.ref), "--jq", ".commit.committer.date"))
+			unresolved = true
+			continue
+		}
+		resolved, err := resolveRef(list, bound.ref)
+		if err != nil {
+			return err
+		}
+		*bound.time = resolved
+	}
+	if list.DryRun {
+		// The chunks to fetch depend on the dates the refs resolve to
+		if unresolved {
+			list.printf("Would then fetch the PRs merged in between, in monthly chunks as in list mode\n")
+		} else {
+			planMergedPRs(list)
+		}
+		return nil
+	}
+	if !list.until().After(list.Since) {
+		return fmt.Errorf("%s is not after %s", list.until().Format(time.RFC3339), list.Since.Format(time.RFC3339))
+	}
+
+	prs, err := getMergedPRs(list)
+	if err != nil {
+		return err
+	}
+	prs = mergedBetween(prs, list.Since, list.until())
+	if opts.Changelog {
+		writeChangelog(w, prs, opts.To, list.until())
+	} else {
+		writeReleaseNotes(w, prs, opts)
+	}
+	events.Info("release_notes_written", "repo", list.Repo, "count", len(prs), "changelog", opts.Changelog)
+	return nil
+}
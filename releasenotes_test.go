@@ -0,0 +1,100 @@
+package main
+
+import (
+	"bytes"
+	"io"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestWriteReleaseNotes(t *testing.T) {
+	prs := []PR{
+		{Title: "Fix crash on empty repo", URL: "https://github.com/acme/widgets/pull/1", Author: "alice", Labels: []string{"Bug"}},
+		{Title: "Add retries", URL: "https://github.com/acme/widgets/pull/2", Author: "bob", Labels: []string{"enhancement", "bug"}},
+		{Title: "Bump deps", URL: "https://github.com/acme/widgets/pull/3", Labels: []string{"dependencies", "skip-changelog"}},
+		{Title: "Tidy up", URL: "https://github.com/acme/widgets/pull/4", Author: "carol"},
+	}
+	var b bytes.Buffer
+	writeReleaseNotes(&b, prs, ReleaseNotesOptions{List: ListOptions{Repo: "acme/widgets"}, From: "v1.2.0", To: "v1.3.0"})
+	want := `## v1.3.0
+
+### Features
+- Add retries by @bob in https://github.com/acme/widgets/pull/2
+
+### Fixes
+- Fix crash on empty repo by @alice in https://github.com/acme/widgets/pull/1
+
+### Other Changes
+- Tidy up by @carol in https://github.com/acme/widgets/pull/4
+
+**Full Changelog**: https://github.com/acme/widgets/compare/v1.2.0...v1.3.0
+`
+	if b.String() != want {
+		t.Errorf("release notes:\n%s\nwant:\n%s", b.String(), want)
+	}
+
+	b.Reset()
+	writeReleaseNotes(&b, nil, ReleaseNotesOptions{From: "2024-03-01"})
+	if b.String() != "## What's Changed\n\nNo changes.\n" {
+		t.Errorf("empty release notes:\n%s", b.String())
+	}
+}
+
+func TestRunReleaseNotesMode(t *testing.T) {
+	from, to := daysAgo(10).Add(12*time.Hour), daysAgo(3).Add(12*time.Hour)
+	prs := []PR{
+		{Number: "1", Title: "Before the release", MergedAt: daysAgo(10).Add(6 * time.Hour).Format(time.RFC3339), CreatedAt: daysAgo(11).Format(time.RFC3339), URL: "https://github.com/acme/widgets/pull/1"},
+		{Number: "2", Title: "In the release", MergedAt: daysAgo(5).Format(time.RFC3339), CreatedAt: daysAgo(6).Format(time.RFC3339), URL: "https://github.com/acme/widgets/pull/2", Labels: []string{"feature"}},
+		{Number: "3", Title: "After the release", MergedAt: daysAgo(3).Add(18 * time.Hour).Format(time.RFC3339), CreatedAt: daysAgo(4).Format(time.RFC3339), URL: "https://github.com/acme/widgets/pull/3"},
+	}
+	runner := &fakeRunner{prs: prs, commitDates: map[string]string{"v1.2.0": from.Format(time.RFC3339), "v1.3.0": to.Format(time.RFC3339)}}
+	var out bytes.Buffer
+	opts := ReleaseNotesOptions{List: testOptions(time.Time{}, runner), From: "v1.2.0", To: "v1.3.0", Output: &out}
+
+	if err := runReleaseNotesMode(opts); err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(out.String(), "### Features\n- In the release") || strings.Contains(out.String(), "Before") || strings.Contains(out.String(), "After") {
+		t.Errorf("release notes:\n%s", out.String())
+	}
+
+	opts.To = "v9.9.9"
+	if err := runReleaseNotesMode(opts); err == nil || !strings.Contains(err.Error(), "v9.9.9") {
+		t.Errorf("unknown tag: err = %v", err)
+	}
+}
+
+func TestRunReleaseNotesModeDryRun(t *testing.T) {
+	runner := &fakeRunner{}
+	var progress bytes.Buffer
+	opts := ReleaseNotesOptions{List: testOptions(time.Time{}, runner), From: "v1.2.0", To: "2024-03-10", Output: io.Discard}
+	opts.List.DryRun = true
+	opts.List.Progress = &progress
+
+	// A tag can't be resolved without running gh, so the chunks aren't planned
+	if err := runReleaseNotesMode(opts); err != nil {
+		t.Fatal(err)
+	}
+	for _, want := range []string{
+		"Would resolve v1.2.0 with gh api repos/acme/widgets/commits/v1.2.0 --jq .commit.committer.date\n",
+		"Would then fetch the PRs merged in between, in monthly chunks as in list mode\n",
+	} {
+		if !strings.Contains(progress.String(), want) {
+			t.Errorf("plan is missing %q:\n%s", want, progress.String())
+		}
+	}
+
+	// With dates on both ends the chunks are known
+	progress.Reset()
+	opts.From = "2024-03-01"
+	if err := runReleaseNotesMode(opts); err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(progress.String(), "Would fetch 1 monthly chunks:") || !strings.Contains(progress.String(), "--search merged:2024-03-01..2024-03-10") {
+		t.Errorf("plan:\n%s", progress.String())
+	}
+	if runner.calls != 0 {
+		t.Errorf("dry run ran %d commands", runner.calls)
+	}
+}
@@ -0,0 +1,247 @@
+package main
+
+import (
+	"fmt"
+	"html/template"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+)
+
+// ReportOptions holds the parameters for report mode
+type ReportOptions struct {
+	// Stats holds the search and grouping settings; its output files are ignored,
+	// and the report is saved in Stats.List's output directory
+	Stats StatsOptions
+	// Force overwrites an existing report instead of saving alongside it
+	Force bool
+}
+
+// reportOutputFile returns the file report mode writes to
+func reportOutputFile(opts ReportOptions) string {
+	list := opts.Stats.List
+	name := fmt.Sprintf("report_%s_%s", strings.Join(opts.Stats.Repos, "_"), list.Since.Format("20060102"))
+	outputFile := filepath.Join(list.outputDir(), sanitizeFilename(name)+".html")
+	if !opts.Force {
+		outputFile = unusedPath(outputFile)
+	}
+	return outputFile
+}
+
+// reportTile is one headline number at the top of the report
+type reportTile struct {
+	Label string
+	Value string
+}
+
+// reportData is what the report template is executed with
+type reportData struct {
+	Title     string
+	Generated string
+	Tiles     []reportTile
+	Charts    []template.HTML
+	Authors   []authorStats
+	Total     int
+}
+
+// reportCharts returns the report's charts: merges over time, authors, labels, and sizes
+func reportCharts(report statsReport) []barChart {
+	overTime := barChart{Name: "merges_per_" + report.Period.Name, Title: fmt.Sprintf("Merged PRs per %s", report.Period.Name)}
+	for _, p := range report.Periods {
+		overTime.Labels = append(overTime.Labels, p.Period)
+		overTime.Values = append(overTime.Values, p.PRs)
+	}
+
+	authors := barChart{Name: "authors", Title: "Top authors", Horizontal: true}
+	for _, a := range report.Authors[:min(15, len(report.Authors))] {
+		authors.Labels = append(authors.Labels, a.Author)
+		authors.Values = append(authors.Values, a.PRs)
+	}
+
+	labels := barChart{Name: "labels", Title: "Labels", Horizontal: true}
+	for _, l := range topLabels(report.MergeTimesByLabel, 15) {
+		labels.Labels = append(labels.Labels, l.Group)
+		labels.Values = append(labels.Values, l.PRs)
+	}
+
+	sizes := barChart{Name: "sizes", Title: "PR size (lines changed)", Labels: sizeBucketHeaders(), Values: make([]int, len(sizeBuckets))}
+	for _, h := range report.Sizes {
+		for i, count := range h.Counts {
+			sizes.Values[i] += count
+		}
+	}
+	return []barChart{overTime, authors, labels, sizes}
+}
+
+// topLabels returns up to n labels with the most PRs, most first, leaving out PRs without labels
+func topLabels(groups []mergeTimes, n int) []mergeTimes {
+	var labels []mergeTimes
+	for _, g := range groups {
+		if g.Group != noLabel {
+			labels = append(labels, g)
+		}
+	}
+	sort.Slice(labels, func(i, j int) bool {
+		if labels[i].PRs != labels[j].PRs {
+			return labels[i].PRs > labels[j].PRs
+		}
+		return labels[i].Group < labels[j].Group
+	})
+	return labels[:min(n, len(labels))]
+}
+
+// reportTiles returns the headline numbers for the report
+func reportTiles(prs []PR, report statsReport, unit string) []reportTile {
+	var durations []time.Duration
+	var changes []int
+	for _, pr := range prs {
+		if d, err := pr.TimeToMerge(); err == nil {
+			durations = append(durations, d)
+		}
+		changes = append(changes, pr.Changes())
+	}
+	ttm := newMergeTimes("all", durations)
+	return []reportTile{
+		{Label: "Merged PRs", Value: fmt.Sprint(len(prs))},
+		{Label: "Authors", Value: fmt.Sprint(len(report.Authors))},
+		{Label: fmt.Sprintf("Median time to merge (%s)", unit), Value: formatDuration(ttm.P50, unit)},
+		{Label: "Median lines changed", Value: fmt.Sprint(medianInt(changes))},
+	}
+}
+
+// writeReport renders the HTML report for the PRs
+func writeReport(w io.Writer, prs []PR, opts StatsOptions) error {
+	period, err := lookupStatsPeriod(opts.Period)
+	if err != nil {
+		return err
+	}
+	unit := opts.List.TimeToMergeUnit
+	if unit == "" {
+		unit = "hours"
+	}
+	report := computeStats(prs, opts.List.Since, opts.List.until(), period)
+
+	data := reportData{
+		Title:     fmt.Sprintf("Merged PRs in %s since %s", strings.Join(opts.Repos, ", "), opts.List.Since.Format("2006-01-02")),
+		Generated: opts.List.until().UTC().Format("2006-01-02 15:04 MST"),
+		Tiles:     reportTiles(prs, report, unit),
+		Authors:   report.Authors,
+		Total:     len(prs),
+	}
+	for _, chart := range reportCharts(report) {
+		if len(chart.Labels) == 0 {
+			continue
+		}
+		// The SVG is built with every label escaped
+		data.Charts = append(data.Charts, template.HTML(chart.svg()))
+	}
+	return reportTemplate.Execute(w, data)
+}
+
+// runReportMode fetches merged PRs and saves them as a single-file HTML dashboard
+func runReportMode(opts ReportOptions) error {
+	period, err := lookupStatsPeriod(opts.Stats.Period)
+	if err != nil {
+		return err
+	}
+	chartFormat, err := lookupChartFormat(opts.Stats.ChartFormat)
+	if err != nil {
+		return err
+	}
+	outputFile := reportOutputFile(opts)
+	if opts.Stats.List.DryRun {
+		for _, repo := range opts.Stats.Repos {
+			list := opts.Stats.List
+			list.Repo = repo
+			list.Fields = []string{"author", "labels"}
+			planMergedPRs(list)
+		}
+		fmt.Printf("Would save the report to %s\n", outputFile)
+		if opts.Stats.ChartsDir != "" {
+			fmt.Printf("Would save %s charts to %s\n", chartFormat, opts.Stats.ChartsDir)
+		}
+		return nil
+	}
+	if err := prepareOutputDir(filepath.Dir(outputFile)); err != nil {
+		return err
+	}
+
+	prs, err := fetchStatsPRs(opts.Stats)
+	if err != nil {
+		return err
+	}
+	if len(prs) == 0 {
+		fmt.Println("No PRs found.")
+		return nil
+	}
+
+	file, err := os.Create(outputFile)
+	if err != nil {
+		return fmt.Errorf("error saving report: %v", err)
+	}
+	if err := writeReport(file, prs, opts.Stats); err != nil {
+		file.Close()
+		return fmt.Errorf("error saving report: %v", err)
+	}
+	if err := file.Close(); err != nil {
+		return fmt.Errorf("error saving report: %v", err)
+	}
+	fmt.Printf("Report saved to %s\n", outputFile)
+	events.Info("report_saved", "file", outputFile, "count", len(prs))
+	addOutput(outputFile)
+
+	report := computeStats(prs, opts.Stats.List.Since, opts.Stats.List.until(), period)
+	return saveStatsCharts(os.Stdout, report, opts.Stats.ChartsDir, chartFormat)
+}
+
+// reportTemplate is the HTML report, with its styles inline so the file can be shared on its own
+var reportTemplate = template.Must(template.New("report").Funcs(template.FuncMap{
+	"percent": func(n, total int) string { return fmt.Sprintf("%.0f%%", 100*float64(n)/float64(total)) },
+}).Parse(`<!DOCTYPE html>
+<html lang="en">
+<head>
+<meta charset="utf-8">
+<meta name="viewport" content="width=device-width, initial-scale=1">
+<title>{{.Title}}</title>
+<style>
+  body { font-family: -apple-system, "Segoe UI", Helvetica, Arial, sans-serif; margin: 2rem auto; max-width: 1400px; padding: 0 1rem; color: #1f2328; }
+  h1 { font-size: 1.6rem; margin-bottom: 0.2rem; }
+  .generated { color: #656d76; margin-top: 0; }
+  .tiles { display: flex; flex-wrap: wrap; gap: 1rem; margin: 1.5rem 0; }
+  .tile { border: 1px solid #d0d7de; border-radius: 6px; padding: 1rem 1.5rem; min-width: 10rem; }
+  .tile .value { font-size: 2rem; font-weight: 600; }
+  .tile .label { color: #656d76; }
+  .charts { display: grid; grid-template-columns: repeat(auto-fit, minmax(640px, 1fr)); gap: 1.5rem; }
+  .chart { border: 1px solid #d0d7de; border-radius: 6px; padding: 0.5rem; overflow-x: auto; }
+  .chart svg { max-width: 100%; height: auto; }
+  table { border-collapse: collapse; margin-top: 1rem; }
+  th, td { border-bottom: 1px solid #d0d7de; padding: 0.4rem 0.8rem; text-align: right; }
+  th:first-child, td:first-child { text-align: left; }
+</style>
+</head>
+<body>
+<h1>{{.Title}}</h1>
+<p class="generated">Generated {{.Generated}} by github-pr-grabber</p>
+<div class="tiles">
+{{- range .Tiles}}
+  <div class="tile"><div class="value">{{.Value}}</div><div class="label">{{.Label}}</div></div>
+{{- end}}
+</div>
+<div class="charts">
+{{- range .Charts}}
+  <div class="chart">{{.}}</div>
+{{- end}}
+</div>
+<h2>Authors</h2>
+<table>
+  <tr><th>Author</th><th>PRs</th><th>Share</th><th>Additions</th><th>Deletions</th></tr>
+{{- range .Authors}}
+  <tr><td>{{.Author}}</td><td>{{.PRs}}</td><td>{{percent .PRs $.Total}}</td><td>+{{.Additions}}</td><td>-{{.Deletions}}</td></tr>
+{{- end}}
+</table>
+</body>
+</html>
+`))
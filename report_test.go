@@ -0,0 +1,152 @@
+package main
+
+import (
+	"bytes"
+	"image/png"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestBarChartSVG(t *testing.T) {
+	for _, horizontal := range []bool{false, true} {
+		chart := barChart{Title: "Authors & <friends>", Labels: []string{"alice", "bob"}, Values: []int{4, 2}, Horizontal: horizontal}
+		svg := chart.svg()
+		if !strings.HasPrefix(svg, "<svg") || !strings.HasSuffix(svg, "</svg>\n") {
+			t.Errorf("horizontal=%v: not an SVG document:\n%s", horizontal, svg)
+		}
+		if !strings.Contains(svg, "Authors &amp; &lt;friends&gt;") || strings.Contains(svg, "<friends>") {
+			t.Errorf("horizontal=%v: title not escaped:\n%s", horizontal, svg)
+		}
+		if strings.Count(svg, "<rect") != 3 { // the background and one bar per label
+			t.Errorf("horizontal=%v: want 2 bars:\n%s", horizontal, svg)
+		}
+	}
+}
+
+func TestWriteReport(t *testing.T) {
+	prs := makePRs(time.Date(2024, 3, 4, 12, 0, 0, 0, time.UTC), 24*time.Hour, 6)
+	for i := range prs {
+		prs[i].Author = []string{"alice", "<script>bob</script>"}[i%2]
+	}
+	prs[0].Labels = []string{"bug"}
+	opts := StatsOptions{
+		List:  ListOptions{Since: time.Date(2024, 3, 1, 0, 0, 0, 0, time.UTC), Until: time.Date(2024, 3, 18, 0, 0, 0, 0, time.UTC)},
+		Repos: []string{"acme/widgets"},
+	}
+
+	var b bytes.Buffer
+	if err := writeReport(&b, prs, opts); err != nil {
+		t.Fatal(err)
+	}
+	html := b.String()
+	for _, want := range []string{
+		"<title>Merged PRs in acme/widgets since 2024-03-01</title>",
+		"Merged PRs per week",
+		"Top authors",
+		"PR size (lines changed)",
+		"&lt;script&gt;bob&lt;/script&gt;",
+	} {
+		if !strings.Contains(html, want) {
+			t.Errorf("report missing %q", want)
+		}
+	}
+	if strings.Contains(html, "<script>") {
+		t.Error("author names are not escaped")
+	}
+}
+
+func TestRunReportMode(t *testing.T) {
+	dir := t.TempDir()
+	prs := makePRs(daysAgo(5), time.Hour, 3)
+	opts := ReportOptions{Stats: StatsOptions{List: testOptions(daysAgo(7), &fakeRunner{prs: prs}), Repos: []string{"acme/widgets"}}}
+	opts.Stats.List.OutDir = dir
+
+	if err := runReportMode(opts); err != nil {
+		t.Fatal(err)
+	}
+	// A second run keeps the first report
+	if err := runReportMode(opts); err != nil {
+		t.Fatal(err)
+	}
+	files, _ := filepath.Glob(filepath.Join(dir, "report_acme_widgets_*.html"))
+	if len(files) != 2 {
+		t.Fatalf("saved %v, want two reports", files)
+	}
+	data, _ := os.ReadFile(files[0])
+	if !strings.Contains(string(data), "<svg") {
+		t.Errorf("report has no charts")
+	}
+}
+
+func TestSaveCharts(t *testing.T) {
+	dir := filepath.Join(t.TempDir(), "charts")
+	charts := []barChart{
+		{Name: "merges_per_week", Title: "Merged PRs per week", Labels: []string{"2024-03-04", "2024-03-11"}, Values: []int{3, 5}},
+		{Name: "authors", Title: "Top authors", Labels: []string{"alice"}, Values: []int{8}, Horizontal: true},
+	}
+	for _, format := range chartFormats {
+		files, err := saveCharts(charts, dir, format)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(files) != 2 || files[0] != filepath.Join(dir, "merges_per_week."+format) {
+			t.Fatalf("%s: saved %v", format, files)
+		}
+	}
+
+	file, err := os.Open(filepath.Join(dir, "authors.png"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer file.Close()
+	img, err := png.Decode(file)
+	if err != nil {
+		t.Fatalf("authors.png is not a PNG: %v", err)
+	}
+	if size := img.Bounds().Size(); size.X != chartWidth || size.Y != chartMargin*2+chartBarHeight {
+		t.Errorf("authors.png is %v", size)
+	}
+	// The middle of the bar is drawn in the bar color
+	if r, g, b, _ := img.At(chartLabelSize+10, chartMargin+chartBarHeight/2).RGBA(); r>>8 != uint32(chartBarColor.R) || g>>8 != uint32(chartBarColor.G) || b>>8 != uint32(chartBarColor.B) {
+		t.Errorf("no bar in authors.png")
+	}
+
+	data, _ := os.ReadFile(filepath.Join(dir, "merges_per_week.svg"))
+	if !strings.HasPrefix(string(data), "<svg") {
+		t.Errorf("merges_per_week.svg is not an SVG document")
+	}
+
+	if _, err := lookupChartFormat("gif"); err == nil {
+		t.Error("gif accepted as a chart format")
+	}
+}
+
+func TestRunReportModeDryRun(t *testing.T) {
+	runner := &fakeRunner{prs: makePRs(daysAgo(5), time.Hour, 3)}
+	var progress bytes.Buffer
+	opts := ReportOptions{Stats: StatsOptions{List: testOptions(daysAgo(7), runner), Repos: []string{"acme/widgets"}}}
+	opts.Stats.List.OutDir = filepath.Join(t.TempDir(), "reports")
+	opts.Stats.List.DryRun = true
+	opts.Stats.List.Progress = &progress
+
+	out := captureStdout(t, func() {
+		if err := runReportMode(opts); err != nil {
+			t.Error(err)
+		}
+	})
+	if runner.calls != 0 {
+		t.Errorf("dry run ran %d commands", runner.calls)
+	}
+	if !strings.Contains(progress.String(), "gh pr list --repo acme/widgets --search merged:") || !strings.Contains(progress.String(), "author,labels") {
+		t.Errorf("plan:\n%s", progress.String())
+	}
+	if !strings.Contains(out, "Would save the report to "+reportOutputFile(opts)) {
+		t.Errorf("printed:\n%s", out)
+	}
+	if _, err := os.Stat(opts.Stats.List.OutDir); !os.IsNotExist(err) {
+		t.Errorf("dry run created the output directory: %v", err)
+	}
+}
@@ -0,0 +1,248 @@
+package main
+
+import (
+	"fmt"
+	"io"
+	"sort"
+	"strconv"
+	"strings"
+	"text/tabwriter"
+	"time"
+)
+
+// Review is one submitted review of a PR
+type Review struct {
+	Reviewer    string
+	State       string // APPROVED, CHANGES_REQUESTED, COMMENTED, or DISMISSED
+	SubmittedAt string
+}
+
+// reviewsJQ turns the reviews API response into one tab-separated line per review
+const reviewsJQ = `.[] | [.user.login, .state, .submitted_at] | @tsv`
+
+// fetchReviews fills in Reviews for each PR, taking one API call per PR.
+// Failures are reported and leave the PR without reviews.
+func fetchReviews(opts ListOptions, prs []PR) {
+	opts.printf("Fetching reviews for %d PRs...\n", len(prs))
+	for i := range prs {
+		output, err := opts.runGH("api", fmt.Sprintf("repos/%s/pulls/%s/reviews", opts.Repo, prs[i].Number), "--paginate", "--jq", reviewsJQ)
+		if err != nil {
+			opts.printf("  Warning: Error fetching reviews for PR #%s: %v\n", prs[i].Number, err)
+			events.Warn("reviews_failed", "number", prs[i].Number, "error", err.Error())
+			continue
+		}
+		prs[i].Reviews = parseReviews(output)
+	}
+}
+
+// parseReviews parses the output of the reviews query, skipping reviews that
+// are still pending and so have no submission time
+func parseReviews(output string) []Review {
+	var reviews []Review
+	for _, line := range strings.Split(output, "\n") {
+		fields := strings.Split(line, "\t")
+		if len(fields) != 3 || fields[1] == "PENDING" || fields[2] == "" {
+			continue
+		}
+		reviews = append(reviews, Review{Reviewer: fields[0], State: fields[1], SubmittedAt: fields[2]})
+	}
+	return reviews
+}
+
+// reviewLatency summarizes how quickly a repo's or reviewer's PRs were reviewed
+type reviewLatency struct {
+	Group string
+	// Reviewed is the number of PRs reviewed, and Approved the number approved
+	Reviewed int
+	Approved int
+	// FirstReview is the time from a PR's creation to its first review. For a
+	// reviewer, it's the time to their own first review of the PR.
+	FirstReview mergeTimes
+	// ApprovalToMerge is the time from a PR's last approval before merging to
+	// the merge. For a reviewer, it's from their own last approval.
+	ApprovalToMerge mergeTimes
+}
+
+// reviewReport aggregates review latency by repo and by reviewer
+type reviewReport struct {
+	ByRepo     []reviewLatency // alphabetical
+	ByReviewer []reviewLatency // most PRs reviewed first
+	// Unreviewed is the number of PRs merged without a review from anyone but their author
+	Unreviewed int
+}
+
+// reviewTimes collects the durations for one group before they're summarized
+type reviewTimes struct {
+	reviewed, approved           int
+	firstReview, approvalToMerge []time.Duration
+}
+
+func (t *reviewTimes) add(firstReview time.Time, lastApproval time.Time, created, merged time.Time) {
+	t.reviewed++
+	t.firstReview = append(t.firstReview, firstReview.Sub(created))
+	if !lastApproval.IsZero() {
+		t.approved++
+		t.approvalToMerge = append(t.approvalToMerge, merged.Sub(lastApproval))
+	}
+}
+
+func (t *reviewTimes) latency(group string) reviewLatency {
+	return reviewLatency{
+		Group:           group,
+		Reviewed:        t.reviewed,
+		Approved:        t.approved,
+		FirstReview:     newMergeTimes(group, t.firstReview),
+		ApprovalToMerge: newMergeTimes(group, t.approvalToMerge),
+	}
+}
+
+// reviewerStages is when one reviewer first reviewed a PR and last approved
+// it; Approved is zero if they didn't
+type reviewerStages struct {
+	FirstReview time.Time
+	Approved    time.Time
+}
+
+// reviewStages returns when the PR was first reviewed and last approved before
+// merged, across all reviewers and for each one. Reviews by the PR's own
+// author and reviews submitted after the merge don't count. Both times are
+// zero if no review counts.
+func (pr PR) reviewStages(merged time.Time) (firstReview, lastApproval time.Time, byReviewer map[string]reviewerStages) {
+	byReviewer = make(map[string]reviewerStages)
+	for _, review := range pr.Reviews {
+		submitted, err := time.Parse(time.RFC3339, review.SubmittedAt)
+		if err != nil || submitted.After(merged) || (review.Reviewer == pr.Author && pr.Author != "") {
+			continue
+		}
+		stages, seen := byReviewer[review.Reviewer]
+		if !seen || submitted.Before(stages.FirstReview) {
+			stages.FirstReview = submitted
+		}
+		if firstReview.IsZero() || submitted.Before(firstReview) {
+			firstReview = submitted
+		}
+		if review.State == "APPROVED" {
+			if submitted.After(stages.Approved) {
+				stages.Approved = submitted
+			}
+			if submitted.After(lastApproval) {
+				lastApproval = submitted
+			}
+		}
+		byReviewer[review.Reviewer] = stages
+	}
+	return firstReview, lastApproval, byReviewer
+}
+
+// computeReviewReport measures review latency from the PRs' reviews, counted
+// as in reviewStages.
+func computeReviewReport(prs []PR) reviewReport {
+	byRepo := make(map[string]*reviewTimes)
+	byReviewer := make(map[string]*reviewTimes)
+	group := func(groups map[string]*reviewTimes, name string) *reviewTimes {
+		if groups[name] == nil {
+			groups[name] = &reviewTimes{}
+		}
+		return groups[name]
+	}
+
+	var report reviewReport
+	for _, pr := range prs {
+		created, err := time.Parse(time.RFC3339, pr.CreatedAt)
+		if err != nil {
+			continue
+		}
+		merged, err := time.Parse(time.RFC3339, pr.MergedAt)
+		if err != nil {
+			continue
+		}
+
+		firstReview, lastApproval, byPRReviewer := pr.reviewStages(merged)
+		if firstReview.IsZero() {
+			report.Unreviewed++
+			continue
+		}
+
+		group(byRepo, repoFromURL(pr.URL)).add(firstReview, lastApproval, created, merged)
+		for reviewer, stages := range byPRReviewer {
+			name := reviewer
+			if name == "" {
+				name = unknownAuthor
+			}
+			group(byReviewer, name).add(stages.FirstReview, stages.Approved, created, merged)
+		}
+	}
+
+	for repo, times := range byRepo {
+		report.ByRepo = append(report.ByRepo, times.latency(repo))
+	}
+	sort.Slice(report.ByRepo, func(i, j int) bool { return report.ByRepo[i].Group < report.ByRepo[j].Group })
+	for reviewer, times := range byReviewer {
+		report.ByReviewer = append(report.ByReviewer, times.latency(reviewer))
+	}
+	sort.Slice(report.ByReviewer, func(i, j int) bool {
+		a, b := report.ByReviewer[i], report.ByReviewer[j]
+		if a.Reviewed != b.Reviewed {
+			return a.Reviewed > b.Reviewed
+		}
+		return a.Group < b.Group
+	})
+	return report
+}
+
+// table returns the review latency by repo and then by reviewer, for saving,
+// with times in unit
+func (r reviewReport) table(unit string) Table {
+	table := Table{Header: []string{"Scope", "Name", "PRs Reviewed", "PRs Approved"}}
+	for _, metric := range []string{"First Review", "Approval To Merge"} {
+		for _, p := range []string{"p50", "p75", "p90"} {
+			table.Header = append(table.Header, fmt.Sprintf("%s %s (%s)", metric, p, unit))
+		}
+	}
+	for _, section := range []struct {
+		scope  string
+		groups []reviewLatency
+	}{
+		{"repo", r.ByRepo},
+		{"reviewer", r.ByReviewer},
+	} {
+		for _, g := range section.groups {
+			row := []string{section.scope, g.Group, strconv.Itoa(g.Reviewed), strconv.Itoa(g.Approved)}
+			for _, times := range []mergeTimes{g.FirstReview, g.ApprovalToMerge} {
+				if times.PRs == 0 {
+					row = append(row, "", "", "")
+					continue
+				}
+				row = append(row, formatDuration(times.P50, unit), formatDuration(times.P75, unit), formatDuration(times.P90, unit))
+			}
+			table.Rows = append(table.Rows, row)
+		}
+	}
+	table.Kinds = textThenNumbers(2, len(table.Header))
+	return table
+}
+
+// print writes the review latency tables with times in unit
+func (r reviewReport) print(w io.Writer, unit string) {
+	for _, section := range []struct {
+		name   string
+		groups []reviewLatency
+	}{
+		{"Repo", r.ByRepo},
+		{"Reviewer", r.ByReviewer},
+	} {
+		fmt.Fprintf(w, "\nReview latency by %s (%s)\n", strings.ToLower(section.name), unit)
+		tw := tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
+		fmt.Fprintf(tw, "%s\tReviewed\tApproved\tFirst review p50\tp90\tApproval to merge p50\tp90\n", section.name)
+		for _, g := range section.groups {
+			approval := "-\t-"
+			if g.ApprovalToMerge.PRs > 0 {
+				approval = formatDuration(g.ApprovalToMerge.P50, unit) + "\t" + formatDuration(g.ApprovalToMerge.P90, unit)
+			}
+			fmt.Fprintf(tw, "%s\t%d\t%d\t%s\t%s\t%s\n", g.Group, g.Reviewed, g.Approved,
+				formatDuration(g.FirstReview.P50, unit), formatDuration(g.FirstReview.P90, unit), approval)
+		}
+		tw.Flush()
+	}
+	fmt.Fprintf(w, "%d PRs merged without review\n", r.Unreviewed)
+}
@@ -0,0 +1,118 @@
+package main
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestParseReviews(t *testing.T) {
+	reviews := parseReviews("octocat\tAPPROVED\t2024-03-05T10:00:00Z\nhubot\tPENDING\t\nmalformed\n")
+	if len(reviews) != 1 || reviews[0] != (Review{Reviewer: "octocat", State: "APPROVED", SubmittedAt: "2024-03-05T10:00:00Z"}) {
+		t.Errorf("parseReviews = %+v, want only octocat's approval", reviews)
+	}
+}
+
+func TestComputeReviewReport(t *testing.T) {
+	at := func(hours int) string {
+		return time.Date(2024, 3, 4, hours, 0, 0, 0, time.UTC).Format(time.RFC3339)
+	}
+	prs := []PR{
+		{
+			Number: "1", Author: "alice", CreatedAt: at(0), MergedAt: at(10), URL: "https://github.com/acme/widgets/pull/1",
+			Reviews: []Review{
+				{Reviewer: "alice", State: "COMMENTED", SubmittedAt: at(1)}, // the author's own, ignored
+				{Reviewer: "bob", State: "CHANGES_REQUESTED", SubmittedAt: at(2)},
+				{Reviewer: "carol", State: "APPROVED", SubmittedAt: at(4)},
+				{Reviewer: "bob", State: "APPROVED", SubmittedAt: at(6)},
+				{Reviewer: "dave", State: "APPROVED", SubmittedAt: at(12)}, // after the merge, ignored
+			},
+		},
+		{
+			Number: "2", Author: "bob", CreatedAt: at(0), MergedAt: at(8), URL: "https://github.com/acme/widgets/pull/2",
+			Reviews: []Review{{Reviewer: "carol", State: "COMMENTED", SubmittedAt: at(3)}},
+		},
+		{Number: "3", Author: "carol", CreatedAt: at(0), MergedAt: at(1), URL: "https://github.com/acme/widgets/pull/3"},
+	}
+
+	report := computeReviewReport(prs)
+	if len(report.ByRepo) != 1 {
+		t.Fatalf("by repo = %+v, want acme/widgets only", report.ByRepo)
+	}
+	if report.Unreviewed != 1 {
+		t.Errorf("unreviewed = %d, want PR 3 only", report.Unreviewed)
+	}
+	repo := report.ByRepo[0]
+	// PR 1 was first reviewed after 2h and last approved 4h before merging; PR 2 after 3h with no approval
+	if repo.Reviewed != 2 || repo.Approved != 1 || repo.FirstReview.P50 != 2*time.Hour || repo.FirstReview.P90 != 3*time.Hour || repo.ApprovalToMerge.P50 != 4*time.Hour {
+		t.Errorf("acme/widgets = %+v", repo)
+	}
+
+	var reviewers []string
+	for _, g := range report.ByReviewer {
+		reviewers = append(reviewers, g.Group)
+	}
+	if strings.Join(reviewers, ",") != "carol,bob" {
+		t.Fatalf("reviewers = %v, want carol then bob", reviewers)
+	}
+	carol, bob := report.ByReviewer[0], report.ByReviewer[1]
+	if carol.Reviewed != 2 || carol.Approved != 1 || carol.ApprovalToMerge.P50 != 6*time.Hour {
+		t.Errorf("carol = %+v", carol)
+	}
+	if bob.FirstReview.P50 != 2*time.Hour || bob.ApprovalToMerge.P50 != 4*time.Hour {
+		t.Errorf("bob = %+v", bob)
+	}
+
+	table := report.table("hours")
+	if len(table.Rows) != 3 || table.Rows[0][0] != "repo" || table.Rows[1][1] != "carol" {
+		t.Errorf("table rows = %v", table.Rows)
+	}
+}
+
+func TestRunStatsModeWithReviews(t *testing.T) {
+	prs := makePRs(daysAgo(5), time.Hour, 2)
+	created, _ := time.Parse(time.RFC3339, prs[0].CreatedAt)
+	runner := &fakeRunner{prs: prs, reviews: map[string][]string{
+		"1": {"octocat\tAPPROVED\t" + created.Add(30*time.Minute).Format(time.RFC3339)},
+		// PR 2's lookup fails and it's left out of the review latency
+	}}
+	var out bytes.Buffer
+	listOpts := testOptions(daysAgo(7), runner)
+	listOpts.Progress = &out
+
+	if err := runStatsMode(StatsOptions{List: listOpts, Repos: []string{"acme/widgets"}, Reviews: true, Output: &out}); err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(out.String(), "Error fetching reviews for PR #2") {
+		t.Errorf("missing warning for PR 2:\n%s", out.String())
+	}
+	if !strings.Contains(out.String(), "octocat   1         1         0.50") {
+		t.Errorf("missing octocat's review latency:\n%s", out.String())
+	}
+}
+
+func TestReviewStages(t *testing.T) {
+	pr := PR{Author: "alice", Reviews: []Review{
+		{Reviewer: "alice", State: "COMMENTED", SubmittedAt: "2024-03-04T09:00:00Z"}, // the author's own
+		{Reviewer: "bob", State: "CHANGES_REQUESTED", SubmittedAt: "2024-03-04T10:00:00Z"},
+		{Reviewer: "carol", State: "APPROVED", SubmittedAt: "2024-03-04T11:00:00Z"},
+		{Reviewer: "bob", State: "APPROVED", SubmittedAt: "2024-03-04T12:00:00Z"},
+		{Reviewer: "dave", State: "APPROVED", SubmittedAt: "2024-03-05T12:00:00Z"}, // after the merge
+	}}
+	merged := time.Date(2024, 3, 5, 0, 0, 0, 0, time.UTC)
+
+	first, approved, byReviewer := pr.reviewStages(merged)
+	if first.Hour() != 10 || approved.Hour() != 12 {
+		t.Errorf("first review %s, last approval %s, want 10:00 and 12:00", first, approved)
+	}
+	if len(byReviewer) != 2 || byReviewer["bob"].FirstReview.Hour() != 10 || byReviewer["bob"].Approved.Hour() != 12 || byReviewer["carol"].Approved.Hour() != 11 {
+		t.Errorf("by reviewer = %+v, want bob and carol only", byReviewer)
+	}
+
+	pr.MergedAt, pr.CreatedAt = merged.Format(time.RFC3339), "2024-03-04T08:00:00Z"
+	c, err := pr.CycleTime()
+	if err != nil || !c.FirstReview.Equal(first) || !c.Approved.Equal(approved) {
+		t.Errorf("CycleTime = %+v, %v, want the same stages", c, err)
+	}
+}
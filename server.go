@@ -0,0 +1,238 @@
+package main
+
+import (
+	"context"
+	"crypto/subtle"
+	"errors"
+	"fmt"
+	"io"
+	"mime"
+	"net"
+	"net/http"
+	"net/url"
+	"os"
+	"os/signal"
+	"strconv"
+	"strings"
+	"syscall"
+	"time"
+)
+
+// ServeOptions holds the parameters for serve mode
+type ServeOptions struct {
+	// Addr is the address to listen on, such as :8080
+	Addr string
+	// Runner and Until are passed on to every fetch; see ListOptions
+	Runner CommandRunner
+	Until  time.Time
+	// Token, if set, has to be sent as a bearer token with every /prs request
+	Token string
+	// MaxDays and MaxLimit cap each /prs request's date range in days and
+	// number of PRs, so one request can't run unbounded searches; 0 means
+	// no cap. Requests without a limit get MaxLimit.
+	MaxDays  int
+	MaxLimit int
+	// JiraURL and JiraProjects are used for the jira field; see ListOptions
+	JiraURL      string
+	JiraProjects []string
+	// DryRun prints the address, authentication, and caps instead of serving
+	DryRun bool
+}
+
+// newServeMux returns the handler for serve mode's endpoints
+func newServeMux(opts ServeOptions) *http.ServeMux {
+	mux := http.NewServeMux()
+	mux.HandleFunc("GET /prs", func(w http.ResponseWriter, r *http.Request) {
+		handlePRs(w, r, opts)
+	})
+	mux.HandleFunc("GET /metrics", metricsHandler(opts.Runner))
+	mux.HandleFunc("GET /healthz", func(w http.ResponseWriter, r *http.Request) {
+		io.WriteString(w, "ok\n")
+	})
+	return mux
+}
+
+// runServeMode serves the API until interrupted or sent SIGTERM
+func runServeMode(opts ServeOptions) error {
+	if opts.DryRun {
+		fmt.Printf("Dry run: would serve on %s\n", opts.Addr)
+		if opts.Token == "" {
+			fmt.Println("Would accept /prs requests without a token")
+		} else {
+			fmt.Println("Would require the -serve-token bearer token on /prs requests")
+		}
+		fmt.Printf("Would cap each request at %s and %s\n", describeCap(opts.MaxDays, "days"), describeCap(opts.MaxLimit, "PRs"))
+		return nil
+	}
+	fmt.Printf("Serving on %s (GET /prs?repo=owner/repo&since=YYYY-MM-DD&format=json)\n", opts.Addr)
+	if opts.Token == "" && !loopbackAddr(opts.Addr) {
+		fmt.Fprintf(os.Stderr, "Warning: anyone who can reach %s can fetch PRs with your gh credentials; set -serve-token to require a token\n", opts.Addr)
+	}
+	return listenUntilStopped(&http.Server{
+		Addr:    opts.Addr,
+		Handler: newServeMux(opts),
+		// Large fetches take a while, so only reading the request is bounded
+		ReadHeaderTimeout: 10 * time.Second,
+	})
+}
+
+// describeCap formats a -serve-max-* value for display
+func describeCap(value int, unit string) string {
+	if value == 0 {
+		return "unlimited " + unit
+	}
+	return fmt.Sprintf("%d %s", value, unit)
+}
+
+// loopbackAddr reports whether addr only listens on the local machine
+func loopbackAddr(addr string) bool {
+	host, _, err := net.SplitHostPort(addr)
+	if err != nil {
+		return false
+	}
+	if host == "localhost" {
+		return true
+	}
+	ip := net.ParseIP(host)
+	return ip != nil && ip.IsLoopback()
+}
+
+// listenUntilStopped runs server until interrupted or sent SIGTERM, then lets
+// in-flight requests finish for a few seconds before returning
+func listenUntilStopped(server *http.Server) error {
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+	go func() {
+		<-ctx.Done()
+		shutdownCtx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+		defer cancel()
+		server.Shutdown(shutdownCtx)
+	}()
+
+	events.Info("server_started", "addr", server.Addr)
+	if err := server.ListenAndServe(); !errors.Is(err, http.ErrServerClosed) {
+		return err
+	}
+	return nil
+}
+
+// listOptionsFromQuery builds list options from the query parameters of a
+// /prs request, which are named after the list mode flags
+func listOptionsFromQuery(query url.Values) (ListOptions, error) {
+	var opts ListOptions
+	opts.Repo = query.Get("repo")
+	if opts.Repo == "" || query.Get("since") == "" {
+		return opts, fmt.Errorf("repo and since are required")
+	}
+
+	var err error
+	if opts.Since, err = parseSinceDate(query.Get("since")); err != nil {
+		return opts, fmt.Errorf("invalid since: %v", err)
+	}
+	if opts.Since.After(time.Now()) {
+		return opts, fmt.Errorf("since %s is in the future", opts.Since.Format("2006-01-02"))
+	}
+	opts.SearchTerm = query.Get("search")
+
+	for name, value := range map[string]*int{"limit": &opts.Limit, "min-changes": &opts.MinChanges, "max-changes": &opts.MaxChanges} {
+		if query.Get(name) == "" {
+			continue
+		}
+		if *value, err = strconv.Atoi(query.Get(name)); err != nil {
+			return opts, fmt.Errorf("invalid %s %q: must be a number", name, query.Get(name))
+		}
+	}
+	if opts.Limit < 0 {
+		return opts, fmt.Errorf("invalid limit %d: must be 0 or greater", opts.Limit)
+	}
+
+	if opts.Fields, err = parseFields(query.Get("fields")); err != nil {
+		return opts, fmt.Errorf("invalid fields: %v", err)
+	}
+	opts.TimeToMergeUnit = query.Get("ttm-unit")
+	if opts.TimeToMergeUnit == "" {
+		opts.TimeToMergeUnit = "hours"
+	}
+	// The same checks as the flags, which the parameters are named after
+	if err := validateListFlags(opts.MinChanges, opts.MaxChanges, opts.TimeToMergeUnit); err != nil {
+		return opts, err
+	}
+
+	opts.Format = query.Get("format")
+	if opts.Format == "" {
+		opts.Format = "json"
+	}
+	return opts, nil
+}
+
+// authorized reports whether r carries the bearer token, if one is required
+func (o ServeOptions) authorized(r *http.Request) bool {
+	if o.Token == "" {
+		return true
+	}
+	token, ok := strings.CutPrefix(r.Header.Get("Authorization"), "Bearer ")
+	return ok && subtle.ConstantTimeCompare([]byte(token), []byte(o.Token)) == 1
+}
+
+// capRequest applies MaxDays and MaxLimit to a /prs request, defaulting its
+// limit to MaxLimit
+func (o ServeOptions) capRequest(opts *ListOptions) error {
+	if o.MaxLimit > 0 {
+		if opts.Limit == 0 {
+			opts.Limit = o.MaxLimit
+		} else if opts.Limit > o.MaxLimit {
+			return fmt.Errorf("limit %d is more than the server allows, %d", opts.Limit, o.MaxLimit)
+		}
+	}
+	until := o.Until
+	if until.IsZero() {
+		until = time.Now()
+	}
+	if o.MaxDays > 0 && until.Sub(opts.Since) > time.Duration(o.MaxDays)*24*time.Hour {
+		return fmt.Errorf("since %s covers more than the %d days the server allows", opts.Since.Format("2006-01-02"), o.MaxDays)
+	}
+	return nil
+}
+
+// handlePRs fetches merged PRs for the query parameters and responds in the requested format
+func handlePRs(w http.ResponseWriter, r *http.Request, serveOpts ServeOptions) {
+	if !serveOpts.authorized(r) {
+		w.Header().Set("WWW-Authenticate", "Bearer")
+		http.Error(w, "missing or invalid bearer token", http.StatusUnauthorized)
+		return
+	}
+	opts, err := listOptionsFromQuery(r.URL.Query())
+	if err == nil {
+		err = serveOpts.capRequest(&opts)
+	}
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	writer, err := lookupWriter(opts.Format)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	opts.Runner = serveOpts.Runner
+	opts.Until = serveOpts.Until
+	opts.JiraURL = serveOpts.JiraURL
+	opts.JiraProjects = serveOpts.JiraProjects
+	opts.Progress = io.Discard
+
+	events.Info("request_received", "repo", opts.Repo, "since", opts.Since.Format("2006-01-02"), "format", opts.Format)
+	prs, err := getMergedPRs(opts)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadGateway)
+		return
+	}
+
+	contentType := mime.TypeByExtension(writer.Extension())
+	if contentType == "" {
+		contentType = "text/plain; charset=utf-8"
+	}
+	w.Header().Set("Content-Type", contentType)
+	if err := writer.Write(w, buildTable(prs, opts)); err != nil {
+		events.Error("response_failed", "repo", opts.Repo, "error", err.Error())
+	}
+}
@@ -0,0 +1,121 @@
+package main
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestServePRs(t *testing.T) {
+	since := daysAgo(10)
+	runner := &fakeRunner{prs: makePRs(since.Add(time.Hour), time.Hour, 5)}
+	server := httptest.NewServer(newServeMux(ServeOptions{Runner: runner}))
+	defer server.Close()
+
+	resp, err := http.Get(server.URL + "/prs?repo=acme/widgets&since=" + since.Format("2006-01-02") + "&limit=3")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		t.Fatalf("status %d, want 200", resp.StatusCode)
+	}
+	if ct := resp.Header.Get("Content-Type"); !strings.HasPrefix(ct, "application/json") {
+		t.Errorf("Content-Type %q, want JSON by default", ct)
+	}
+	var rows []map[string]any
+	if err := json.NewDecoder(resp.Body).Decode(&rows); err != nil {
+		t.Fatal(err)
+	}
+	if len(rows) != 3 || rows[0]["url"] != "https://github.com/acme/widgets/pull/1" {
+		t.Errorf("got %v, want the first 3 PRs", rows)
+	}
+	if !strings.HasPrefix(runner.queries[0], "merged:"+since.Format("2006-01-02")) {
+		t.Errorf("searched %q, want merged PRs since %s", runner.queries[0], since.Format("2006-01-02"))
+	}
+}
+
+func TestServePRsBadRequest(t *testing.T) {
+	server := httptest.NewServer(newServeMux(ServeOptions{Runner: &fakeRunner{}}))
+	defer server.Close()
+
+	for _, query := range []string{
+		"since=2024-01-01",
+		"repo=acme/widgets&since=yesterday",
+		"repo=acme/widgets&since=2024-01-01&limit=-5",
+		"repo=acme/widgets&since=2024-01-01&format=xml",
+		"repo=acme/widgets&since=2024-01-01&min-changes=10&max-changes=5",
+		"repo=acme/widgets&since=2024-01-01&min-changes=-1",
+		"repo=acme/widgets&since=2024-01-01&ttm-unit=weeks",
+	} {
+		resp, err := http.Get(server.URL + "/prs?" + query)
+		if err != nil {
+			t.Fatal(err)
+		}
+		resp.Body.Close()
+		if resp.StatusCode != http.StatusBadRequest {
+			t.Errorf("%s: status %d, want 400", query, resp.StatusCode)
+		}
+	}
+}
+
+func TestServePRsToken(t *testing.T) {
+	since := daysAgo(10)
+	server := httptest.NewServer(newServeMux(ServeOptions{Runner: &fakeRunner{prs: makePRs(since.Add(time.Hour), time.Hour, 2)}, Token: "s3cret"}))
+	defer server.Close()
+
+	for header, want := range map[string]int{"": http.StatusUnauthorized, "Bearer wrong": http.StatusUnauthorized, "s3cret": http.StatusUnauthorized, "Bearer s3cret": http.StatusOK} {
+		req, _ := http.NewRequest("GET", server.URL+"/prs?repo=acme/widgets&since="+since.Format("2006-01-02"), nil)
+		if header != "" {
+			req.Header.Set("Authorization", header)
+		}
+		resp, err := http.DefaultClient.Do(req)
+		if err != nil {
+			t.Fatal(err)
+		}
+		resp.Body.Close()
+		if resp.StatusCode != want {
+			t.Errorf("Authorization %q: status %d, want %d", header, resp.StatusCode, want)
+		}
+	}
+}
+
+func TestServePRsCaps(t *testing.T) {
+	since := daysAgo(10)
+	runner := &fakeRunner{prs: makePRs(since.Add(time.Hour), time.Hour, 5)}
+	server := httptest.NewServer(newServeMux(ServeOptions{Runner: runner, MaxDays: 30, MaxLimit: 4}))
+	defer server.Close()
+
+	get := func(query string) int {
+		t.Helper()
+		resp, err := http.Get(server.URL + "/prs?repo=acme/widgets&" + query)
+		if err != nil {
+			t.Fatal(err)
+		}
+		resp.Body.Close()
+		return resp.StatusCode
+	}
+	if status := get("since=" + daysAgo(60).Format("2006-01-02")); status != http.StatusBadRequest {
+		t.Errorf("60 days back: status %d, want 400", status)
+	}
+	if status := get("since=" + since.Format("2006-01-02") + "&limit=10"); status != http.StatusBadRequest {
+		t.Errorf("limit 10: status %d, want 400", status)
+	}
+	if status := get("since=" + since.Format("2006-01-02")); status != http.StatusOK {
+		t.Fatalf("no limit: status %d, want 200", status)
+	}
+	if limit := runner.limits[len(runner.limits)-1]; limit != "4" {
+		t.Errorf("queried with --limit %s, want the server's cap of 4", limit)
+	}
+}
+
+func TestLoopbackAddr(t *testing.T) {
+	for addr, want := range map[string]bool{"127.0.0.1:8080": true, "localhost:8080": true, "[::1]:8080": true, ":8080": false, "0.0.0.0:8080": false, "10.0.0.5:8080": false} {
+		if got := loopbackAddr(addr); got != want {
+			t.Errorf("loopbackAddr(%q) = %v, want %v", addr, got, want)
+		}
+	}
+}
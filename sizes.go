@@ -0,0 +1,144 @@
+package main
+
+import (
+	"fmt"
+	"io"
+	"sort"
+	"strconv"
+	"strings"
+	"text/tabwriter"
+	"time"
+)
+
+// sizeBucket is a range of lines changed; a PR is in the first bucket whose Max it doesn't exceed
+type sizeBucket struct {
+	Name string
+	Max  int // 0 means no maximum
+}
+
+// sizeBuckets are the PR sizes reported by stats mode, smallest first
+var sizeBuckets = []sizeBucket{
+	{Name: "XS", Max: 9},
+	{Name: "S", Max: 29},
+	{Name: "M", Max: 99},
+	{Name: "L", Max: 499},
+	{Name: "XL"},
+}
+
+// sizeBucketIndex returns the index in sizeBuckets of a PR with this many lines changed
+func sizeBucketIndex(changes int) int {
+	for i, bucket := range sizeBuckets {
+		if bucket.Max == 0 || changes <= bucket.Max {
+			return i
+		}
+	}
+	return len(sizeBuckets) - 1
+}
+
+// medianInt returns the lower median of values, 0 if there are none
+func medianInt(values []int) int {
+	if len(values) == 0 {
+		return 0
+	}
+	sorted := append([]int(nil), values...)
+	sort.Ints(sorted)
+	return sorted[(len(sorted)-1)/2]
+}
+
+// sizeHistogram counts one repo's PRs in each size bucket over one period
+type sizeHistogram struct {
+	Repo   string
+	Period string // the period's start date, YYYY-MM-DD
+	Counts []int  // by index in sizeBuckets
+	// MedianChanges is the median lines changed, 0 if nothing was merged
+	MedianChanges int
+}
+
+// computeSizeHistograms buckets the PRs by size for each repo and period from
+// since until until, with repos in alphabetical order and periods oldest first
+func computeSizeHistograms(prs []PR, since, until time.Time, period statsPeriod) []sizeHistogram {
+	changes := make(map[string]map[string][]int) // by repo, then period
+	for _, pr := range prs {
+		merged, err := time.Parse(time.RFC3339, pr.MergedAt)
+		if err != nil {
+			continue
+		}
+		repo := repoFromURL(pr.URL)
+		if changes[repo] == nil {
+			changes[repo] = make(map[string][]int)
+		}
+		key := period.key(merged)
+		changes[repo][key] = append(changes[repo][key], pr.Changes())
+	}
+
+	repos := make([]string, 0, len(changes))
+	for repo := range changes {
+		repos = append(repos, repo)
+	}
+	sort.Strings(repos)
+
+	var histograms []sizeHistogram
+	for _, repo := range repos {
+		for _, key := range period.keys(since, until) {
+			h := sizeHistogram{Repo: repo, Period: key, Counts: make([]int, len(sizeBuckets))}
+			for _, size := range changes[repo][key] {
+				h.Counts[sizeBucketIndex(size)]++
+			}
+			h.MedianChanges = medianInt(changes[repo][key])
+			histograms = append(histograms, h)
+		}
+	}
+	return histograms
+}
+
+// sizeBucketHeaders returns the column headers for the size buckets, such as "XS (0-9)"
+func sizeBucketHeaders() []string {
+	headers := make([]string, len(sizeBuckets))
+	low := 0
+	for i, bucket := range sizeBuckets {
+		if bucket.Max == 0 {
+			headers[i] = fmt.Sprintf("%s (%d+)", bucket.Name, low)
+		} else {
+			headers[i] = fmt.Sprintf("%s (%d-%d)", bucket.Name, low, bucket.Max)
+			low = bucket.Max + 1
+		}
+	}
+	return headers
+}
+
+// sizeTable returns the histograms as a table, for saving
+func sizeTable(histograms []sizeHistogram, period statsPeriod) Table {
+	table := Table{Header: append(append([]string{"Repo", period.heading()}, sizeBucketHeaders()...), "Median Lines Changed")}
+	for _, h := range histograms {
+		row := []string{h.Repo, h.Period}
+		for _, count := range h.Counts {
+			row = append(row, strconv.Itoa(count))
+		}
+		table.Rows = append(table.Rows, append(row, strconv.Itoa(h.MedianChanges)))
+	}
+	table.Kinds = textThenNumbers(2, len(table.Header))
+	return table
+}
+
+// printSizeHistograms writes one table of PR sizes per period for each repo
+func printSizeHistograms(w io.Writer, histograms []sizeHistogram, period statsPeriod) {
+	var tw *tabwriter.Writer
+	for i, h := range histograms {
+		if i == 0 || h.Repo != histograms[i-1].Repo {
+			if tw != nil {
+				tw.Flush()
+			}
+			fmt.Fprintf(w, "\nPR size by %s in %s (lines changed)\n", period.Name, h.Repo)
+			tw = tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
+			fmt.Fprintf(tw, "%s\t%s\tMedian\n", period.heading(), strings.Join(sizeBucketHeaders(), "\t"))
+		}
+		counts := make([]string, len(h.Counts))
+		for j, count := range h.Counts {
+			counts[j] = strconv.Itoa(count)
+		}
+		fmt.Fprintf(tw, "%s\t%s\t%d\n", h.Period, strings.Join(counts, "\t"), h.MedianChanges)
+	}
+	if tw != nil {
+		tw.Flush()
+	}
+}
@@ -0,0 +1,224 @@
+package main
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"sort"
+	"strconv"
+	"strings"
+	"text/tabwriter"
+	"time"
+)
+
+// StaleOptions holds the parameters for stale mode
+type StaleOptions struct {
+	// List holds the search settings; Since is ignored for open PRs
+	List ListOptions
+	// Open reports PRs that are still open instead of merged ones
+	Open bool
+	// OlderThan is the age a PR has to exceed to be reported: time to merge
+	// for merged PRs, or time since it was opened for open ones
+	OlderThan time.Duration
+	// OutFile saves the report if set, in the format matching its extension
+	OutFile string
+	// Output receives the printed report; nil means stdout
+	Output io.Writer
+}
+
+// stalePR is a PR that was, or has been, open longer than the threshold
+type stalePR struct {
+	PR
+	Age   time.Duration
+	Draft bool
+}
+
+// parseAge parses a threshold as a number of days or weeks such as 7d or 2w,
+// or a Go duration such as 36h
+func parseAge(value string) (time.Duration, error) {
+	if match := relativeDatePattern.FindStringSubmatch(value); match != nil {
+		n, err := strconv.Atoi(match[1])
+		if err != nil {
+			return 0, err
+		}
+		if match[2] == "w" {
+			n *= 7
+		}
+		return time.Duration(n) * 24 * time.Hour, nil
+	}
+	d, err := time.ParseDuration(value)
+	if err != nil {
+		return 0, fmt.Errorf("invalid age %q: use a number of days or weeks like 7d or 2w, or a duration like 36h", value)
+	}
+	return d, nil
+}
+
+// openPRsArgs builds the gh arguments that list PRs opened before created and
+// still open, and returns the --limit they ask for
+func openPRsArgs(opts ListOptions, created time.Time) ([]string, int) {
+	searchQuery := "created:<" + created.Format("2006-01-02")
+	if opts.SearchTerm != "" {
+		searchQuery += " " + opts.SearchTerm
+	}
+	limit := 1000
+	if opts.Limit > 0 && opts.Limit < limit {
+		limit = opts.Limit
+	}
+	return []string{
+		"pr", "list",
+		"--repo", opts.Repo,
+		"--state", "open",
+		"--search", searchQuery,
+		"--json", "number,title,createdAt,url,additions,deletions,author,isDraft",
+		"--jq", ".[] | [.number, .title, .createdAt, .url, .additions, .deletions, .author.login, .isDraft] | @tsv",
+		"--limit", strconv.Itoa(limit),
+	}, limit
+}
+
+// fetchStaleOpenPRs returns the open PRs older than olderThan at now. The
+// search only narrows creation down to the day, so the age is checked here.
+func fetchStaleOpenPRs(opts ListOptions, olderThan time.Duration, now time.Time) ([]stalePR, error) {
+	opts.printf("Fetching open PRs in %s...\n", opts.Repo)
+	args, limit := openPRsArgs(opts, now.Add(-olderThan).AddDate(0, 0, 1))
+	output, err := opts.runGH(args...)
+	if err != nil {
+		return nil, fmt.Errorf("error fetching open PRs: %v", err)
+	}
+	var stale []stalePR
+	fetched := 0
+	for _, line := range strings.Split(output, "\n") {
+		fields := strings.Split(line, "\t")
+		if len(fields) != 8 {
+			continue
+		}
+		fetched++
+		created, err := time.Parse(time.RFC3339, fields[2])
+		if err != nil || now.Sub(created) <= olderThan {
+			continue
+		}
+		additions, _ := strconv.Atoi(fields[4])
+		deletions, _ := strconv.Atoi(fields[5])
+		pr := PR{Number: fields[0], Title: fields[1], CreatedAt: fields[2], URL: fields[3], Additions: additions, Deletions: deletions, Author: fields[6]}
+		stale = append(stale, stalePR{PR: pr, Age: now.Sub(created), Draft: fields[7] == "true"})
+	}
+	// Count what gh returned, not what passed the age check, since the search
+	// includes PRs from the last day that are too young
+	if fetched == limit {
+		opts.printf("  Warning: Hit the %d PR limit, so older open PRs may be missing\n", limit)
+		events.Warn("chunk_limit_hit", "repo", opts.Repo, "state", "open", "limit", limit)
+	}
+	return stale, nil
+}
+
+// staleMergedPRs returns the PRs that took longer than olderThan to merge
+func staleMergedPRs(prs []PR, olderThan time.Duration) []stalePR {
+	var stale []stalePR
+	for _, pr := range prs {
+		if d, err := pr.TimeToMerge(); err == nil && d > olderThan {
+			stale = append(stale, stalePR{PR: pr, Age: d})
+		}
+	}
+	return stale
+}
+
+// sortStale orders the PRs oldest first
+func sortStale(stale []stalePR) {
+	sort.SliceStable(stale, func(i, j int) bool { return stale[i].Age > stale[j].Age })
+}
+
+// staleTable returns the report for saving, with ages in unit
+func staleTable(stale []stalePR, open bool, unit string) Table {
+	table := Table{Header: []string{"PR Number", "Title", "Author", "Created At"}, Kinds: []cellKind{numberCell, textCell, textCell, textCell}}
+	if open {
+		table.Header = append(table.Header, "Draft", fmt.Sprintf("Open For (%s)", unit))
+		table.Kinds = append(table.Kinds, boolCell, numberCell)
+	} else {
+		table.Header = append(table.Header, "Merged At", fmt.Sprintf("Time To Merge (%s)", unit))
+		table.Kinds = append(table.Kinds, textCell, numberCell)
+	}
+	table.Header = append(table.Header, "URL")
+	for _, s := range stale {
+		row := []string{s.Number, s.Title, s.Author, s.CreatedAt}
+		if open {
+			row = append(row, strconv.FormatBool(s.Draft))
+		} else {
+			row = append(row, s.MergedAt)
+		}
+		table.Rows = append(table.Rows, append(row, formatDuration(s.Age, unit), s.URL))
+	}
+	return table
+}
+
+// printStale writes the report with ages in unit, oldest first
+func printStale(w io.Writer, stale []stalePR, opts StaleOptions, unit string) {
+	state := "took longer than " + formatDuration(opts.OlderThan, unit) + " " + unit + " to merge"
+	if opts.Open {
+		state = "have been open longer than " + formatDuration(opts.OlderThan, unit) + " " + unit
+	}
+	fmt.Fprintf(w, "\n%d PRs in %s %s\n", len(stale), opts.List.Repo, state)
+	if len(stale) == 0 {
+		return
+	}
+	tw := tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
+	fmt.Fprintf(tw, "Age (%s)\tPR\tAuthor\tTitle\tURL\n", unit)
+	for _, s := range stale {
+		title := truncateLabel(s.Title, 60)
+		if s.Draft {
+			title = "[draft] " + title
+		}
+		fmt.Fprintf(tw, "%s\t#%s\t%s\t%s\t%s\n", formatDuration(s.Age, unit), s.Number, s.Author, title, s.URL)
+	}
+	tw.Flush()
+}
+
+// runStaleMode reports merged PRs that were open longer than the threshold,
+// or open PRs past it, oldest first
+func runStaleMode(opts StaleOptions) error {
+	w := opts.Output
+	if w == nil {
+		w = os.Stdout
+	}
+	list := opts.List
+	list.Fields = []string{"author"}
+	unit := list.TimeToMergeUnit
+	if unit == "" {
+		unit = "hours"
+	}
+	if opts.OutFile != "" {
+		if _, err := writerForFile(opts.OutFile); err != nil {
+			return err
+		}
+	}
+
+	if list.DryRun {
+		if opts.Open {
+			args, _ := openPRsArgs(list, list.until().Add(-opts.OlderThan).AddDate(0, 0, 1))
+			list.printf("%s\n", formatGHCommand(args...))
+		} else {
+			planMergedPRs(list)
+		}
+		if opts.OutFile != "" {
+			fmt.Fprintf(w, "Would save the report to %s\n", opts.OutFile)
+		}
+		return nil
+	}
+
+	var stale []stalePR
+	if opts.Open {
+		var err error
+		if stale, err = fetchStaleOpenPRs(list, opts.OlderThan, list.until()); err != nil {
+			return err
+		}
+	} else {
+		prs, err := getMergedPRs(list)
+		if err != nil {
+			return err
+		}
+		stale = staleMergedPRs(prs, opts.OlderThan)
+	}
+	sortStale(stale)
+
+	printStale(w, stale, opts, unit)
+	events.Info("stale_reported", "repo", list.Repo, "open", opts.Open, "count", len(stale))
+	return saveStatsTable(w, opts.OutFile, staleTable(stale, opts.Open, unit))
+}
@@ -0,0 +1,89 @@
+package main
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestParseAge(t *testing.T) {
+	for value, want := range map[string]time.Duration{"7d": 7 * 24 * time.Hour, "2w": 14 * 24 * time.Hour, "36h": 36 * time.Hour} {
+		if got, err := parseAge(value); err != nil || got != want {
+			t.Errorf("parseAge(%q) = %v, %v, want %v", value, got, err, want)
+		}
+	}
+	if _, err := parseAge("a week"); err == nil {
+		t.Error("parseAge accepted \"a week\"")
+	}
+}
+
+func TestStaleMergedPRs(t *testing.T) {
+	prs := makePRs(daysAgo(5), time.Hour, 3)
+	prs[0].CreatedAt = daysAgo(9).Format(time.RFC3339)
+	prs[2].CreatedAt = daysAgo(20).Format(time.RFC3339)
+
+	stale := staleMergedPRs(prs, 3*24*time.Hour)
+	sortStale(stale)
+	if len(stale) != 2 || stale[0].Number != prs[2].Number || stale[1].Number != prs[0].Number {
+		t.Fatalf("stale = %+v, want PRs %s then %s", stale, prs[2].Number, prs[0].Number)
+	}
+	table := staleTable(stale, false, "days")
+	if table.Header[5] != "Time To Merge (days)" || table.Rows[0][5] != "15.08" {
+		t.Errorf("table = %+v", table)
+	}
+}
+
+func TestRunStaleModeOpen(t *testing.T) {
+	now := time.Now()
+	prs := []PR{
+		{Number: "1", Title: "Ancient", CreatedAt: now.Add(-30 * 24 * time.Hour).Format(time.RFC3339), URL: "https://github.com/acme/widgets/pull/1", Author: "alice"},
+		{Number: "2", Title: "Recent", CreatedAt: now.Add(-2 * 24 * time.Hour).Format(time.RFC3339), URL: "https://github.com/acme/widgets/pull/2", Author: "bob"},
+		{Number: "3", Title: "Old", CreatedAt: now.Add(-10 * 24 * time.Hour).Format(time.RFC3339), URL: "https://github.com/acme/widgets/pull/3", Author: "carol"},
+		{Number: "4", Title: "Merged", CreatedAt: now.Add(-40 * 24 * time.Hour).Format(time.RFC3339), MergedAt: now.Format(time.RFC3339), URL: "https://github.com/acme/widgets/pull/4"},
+	}
+	outFile := filepath.Join(t.TempDir(), "stale.csv")
+	var out bytes.Buffer
+
+	opts := StaleOptions{List: testOptions(time.Time{}, &fakeRunner{prs: prs}), Open: true, OlderThan: 7 * 24 * time.Hour, OutFile: outFile, Output: &out}
+	opts.List.TimeToMergeUnit = "days"
+	if err := runStaleMode(opts); err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(out.String(), "2 PRs in acme/widgets have been open longer than 7.00 days") {
+		t.Errorf("printed report:\n%s", out.String())
+	}
+	if ancient, old := strings.Index(out.String(), "Ancient"), strings.Index(out.String(), "Old"); ancient < 0 || old < ancient {
+		t.Errorf("want the oldest PR first:\n%s", out.String())
+	}
+	data, err := os.ReadFile(outFile)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if lines := strings.Split(strings.TrimSpace(string(data)), "\n"); len(lines) != 3 || !strings.HasPrefix(lines[0], "PR Number,Title,Author,Created At,Draft,Open For (days),URL") {
+		t.Errorf("saved report:\n%s", data)
+	}
+}
+
+func TestFetchStaleOpenPRsWarnsAtLimit(t *testing.T) {
+	now := time.Date(2024, 3, 10, 12, 0, 0, 0, time.UTC)
+	prs := []PR{
+		{Number: "1", CreatedAt: "2024-02-10T12:00:00Z", URL: "https://github.com/acme/widgets/pull/1"},
+		// Found by the day-granular search, but too young to be stale
+		{Number: "2", CreatedAt: "2024-03-03T18:00:00Z", URL: "https://github.com/acme/widgets/pull/2"},
+	}
+	var progress bytes.Buffer
+	opts := testOptions(time.Time{}, &fakeRunner{prs: prs})
+	opts.Progress = &progress
+	opts.Limit = 2
+
+	stale, err := fetchStaleOpenPRs(opts, 7*24*time.Hour, now)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(stale) != 1 || !strings.Contains(progress.String(), "Hit the 2 PR limit") {
+		t.Errorf("got %d stale PRs, progress:\n%s", len(stale), progress.String())
+	}
+}
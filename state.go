@@ -0,0 +1,52 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+)
+
+// openState is the progress of an open mode session, saved next to the CSV file
+type openState struct {
+	LastRow int  `json:"lastRow"`
+	Reverse bool `json:"reverse"`
+}
+
+// stateFilePath returns the sidecar state file used for a CSV file
+func stateFilePath(csvFile string) string {
+	return csvFile + ".open-state.json"
+}
+
+// loadOpenState reads the saved state for a CSV file, returning nil if there is none
+func loadOpenState(csvFile string) (*openState, error) {
+	data, err := os.ReadFile(stateFilePath(csvFile))
+	if os.IsNotExist(err) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("error reading state file: %v", err)
+	}
+
+	var state openState
+	if err := json.Unmarshal(data, &state); err != nil {
+		return nil, fmt.Errorf("error parsing state file %s: %v", stateFilePath(csvFile), err)
+	}
+	return &state, nil
+}
+
+// saveOpenState records the progress of an open mode session
+func saveOpenState(csvFile string, state openState) error {
+	data, err := json.Marshal(state)
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(stateFilePath(csvFile), data, 0644)
+}
+
+// clearOpenState removes the saved state once a session has opened everything
+func clearOpenState(csvFile string) error {
+	if err := os.Remove(stateFilePath(csvFile)); err != nil && !os.IsNotExist(err) {
+		return err
+	}
+	return nil
+}
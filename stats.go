@@ -0,0 +1,640 @@
+package main
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"text/tabwriter"
+	"time"
+)
+
+// StatsOptions holds the parameters for stats mode
+type StatsOptions struct {
+	// List holds the search settings shared by every repo. Its Repo is ignored,
+	// and the author and labels fields are always fetched.
+	List  ListOptions
+	Repos []string
+	// OutFile saves the per-author table if set, in the format matching its extension
+	OutFile string
+	// JSONFile saves every aggregate as one JSON document with a stable schema
+	// if set; - writes it to Output instead of the tables
+	JSONFile string
+	// Reviews fetches each PR's reviews and reports review latency
+	Reviews bool
+	// ReviewsOutFile saves the review latency table if set, like OutFile
+	ReviewsOutFile string
+	// LeadTime fetches each PR's first commit and reports lead time for changes
+	LeadTime bool
+	// LeadTimeReleases also measures lead time to the first release after each
+	// merge; it implies LeadTime
+	LeadTimeReleases bool
+	// LeadTimeOutFile saves the lead time table if set, like OutFile
+	LeadTimeOutFile string
+	// SizesOutFile saves the size histograms if set, like OutFile
+	SizesOutFile string
+	// TrendOutFile saves the totals for each period if set, like OutFile
+	TrendOutFile string
+	// Leaderboard ranks the contributors and checks which are first-timers,
+	// taking one search per contributor and repo
+	Leaderboard bool
+	// LeaderboardOutFile saves the leaderboard if set, like OutFile; it
+	// implies Leaderboard
+	LeaderboardOutFile string
+	// CompareTo fetches a second date range and reports the change from it:
+	// previous for the range of the same length just before List.Since, or a
+	// start date as accepted by -since; empty means no comparison
+	CompareTo string
+	// ChartsDir saves the report's charts there as image files if set
+	ChartsDir string
+	// ChartFormat is the charts' file format, svg or png; empty means svg
+	ChartFormat string
+	// Period is the key in statsPeriods to group merges by; empty means week
+	Period string
+	// Output receives the printed tables; nil means stdout
+	Output io.Writer
+}
+
+// unknownAuthor stands in for PRs whose author couldn't be determined, such as deleted accounts
+const unknownAuthor = "(unknown)"
+
+// noLabel groups PRs without labels in the time to merge by label
+const noLabel = "(no label)"
+
+// authorStats is what one author merged over the whole period
+type authorStats struct {
+	Author    string
+	PRs       int
+	Additions int
+	Deletions int
+	// Periods counts the author's merged PRs by the start date of each period, YYYY-MM-DD
+	Periods map[string]int
+	// TimeToMerge is the percentiles of how long the author's PRs were open
+	TimeToMerge mergeTimes
+}
+
+// mergeTimes summarizes how long a group of PRs took from creation to merge
+type mergeTimes struct {
+	Group string
+	PRs   int
+	P50   time.Duration
+	P75   time.Duration
+	P90   time.Duration
+}
+
+// percentile returns the nearest-rank pth percentile of sorted durations
+func percentile(sorted []time.Duration, p int) time.Duration {
+	if len(sorted) == 0 {
+		return 0
+	}
+	rank := (p*len(sorted) + 99) / 100 // ceil(p/100 * n)
+	return sorted[max(rank, 1)-1]
+}
+
+// newMergeTimes computes the percentiles of durations for a group
+func newMergeTimes(group string, durations []time.Duration) mergeTimes {
+	sorted := append([]time.Duration(nil), durations...)
+	sort.Slice(sorted, func(i, j int) bool { return sorted[i] < sorted[j] })
+	return mergeTimes{
+		Group: group,
+		PRs:   len(sorted),
+		P50:   percentile(sorted, 50),
+		P75:   percentile(sorted, 75),
+		P90:   percentile(sorted, 90),
+	}
+}
+
+// groupMergeTimes computes the time to merge percentiles for each group in
+// durations, slowest median first
+func groupMergeTimes(durations map[string][]time.Duration) []mergeTimes {
+	var groups []mergeTimes
+	for group, ds := range durations {
+		groups = append(groups, newMergeTimes(group, ds))
+	}
+	sort.Slice(groups, func(i, j int) bool {
+		if groups[i].P50 != groups[j].P50 {
+			return groups[i].P50 > groups[j].P50
+		}
+		return groups[i].Group < groups[j].Group
+	})
+	return groups
+}
+
+// periodStats is what was merged in one week or month
+type periodStats struct {
+	Period    string // the start date, YYYY-MM-DD
+	PRs       int
+	Authors   int
+	Additions int
+	Deletions int
+}
+
+// statsReport aggregates merged PRs by author and by period
+type statsReport struct {
+	// Period is how merges are grouped over time
+	Period  statsPeriod
+	Authors []authorStats // most PRs first
+	Periods []periodStats // oldest first, including periods with nothing merged
+
+	// Time to merge percentiles, slowest median first. A PR with several
+	// labels counts towards each of them.
+	MergeTimesByRepo  []mergeTimes
+	MergeTimesByLabel []mergeTimes
+
+	// Sizes holds each repo's PR size histograms for each period
+	Sizes []sizeHistogram
+}
+
+// weekStart returns midnight UTC on the Monday of t's week
+func weekStart(t time.Time) time.Time {
+	t = t.UTC()
+	daysSinceMonday := (int(t.Weekday()) + 6) % 7
+	return time.Date(t.Year(), t.Month(), t.Day()-daysSinceMonday, 0, 0, 0, 0, time.UTC)
+}
+
+// statsPeriod is a length of time stats mode groups merges by
+type statsPeriod struct {
+	Name string // week or month, as given to -period
+	// Start returns the start of the period containing t
+	Start func(t time.Time) time.Time
+	// Next returns the start of the period after the one starting at start
+	Next func(start time.Time) time.Time
+}
+
+// statsPeriods maps the -period values stats mode accepts to their periods
+var statsPeriods = map[string]statsPeriod{
+	"week": {
+		Name:  "week",
+		Start: weekStart,
+		Next:  func(start time.Time) time.Time { return start.AddDate(0, 0, 7) },
+	},
+	"month": {
+		Name: "month",
+		Start: func(t time.Time) time.Time {
+			t = t.UTC()
+			return time.Date(t.Year(), t.Month(), 1, 0, 0, 0, 0, time.UTC)
+		},
+		Next: func(start time.Time) time.Time { return start.AddDate(0, 1, 0) },
+	},
+}
+
+// key returns the start date of the period containing t, YYYY-MM-DD
+func (p statsPeriod) key(t time.Time) string {
+	return p.Start(t).Format("2006-01-02")
+}
+
+// keys returns the start dates of every period from since until until, oldest first
+func (p statsPeriod) keys(since, until time.Time) []string {
+	var keys []string
+	for start := p.Start(since); start.Before(until); start = p.Next(start) {
+		keys = append(keys, start.Format("2006-01-02"))
+	}
+	return keys
+}
+
+// heading returns the column heading for period start dates, such as "Week of"
+func (p statsPeriod) heading() string {
+	return strings.ToUpper(p.Name[:1]) + p.Name[1:] + " of"
+}
+
+// computeStats aggregates the PRs merged in each period from since until until
+func computeStats(prs []PR, since, until time.Time, period statsPeriod) statsReport {
+	byAuthor := make(map[string]*authorStats)
+	periodAuthors := make(map[string]map[string]bool)
+	byPeriod := make(map[string]*periodStats)
+	authorTimes := make(map[string][]time.Duration)
+	repoTimes := make(map[string][]time.Duration)
+	labelTimes := make(map[string][]time.Duration)
+
+	for _, pr := range prs {
+		merged, err := time.Parse(time.RFC3339, pr.MergedAt)
+		if err != nil {
+			continue
+		}
+		author := pr.Author
+		if author == "" {
+			author = unknownAuthor
+		}
+		key := period.key(merged)
+
+		stats, ok := byAuthor[author]
+		if !ok {
+			stats = &authorStats{Author: author, Periods: make(map[string]int)}
+			byAuthor[author] = stats
+		}
+		stats.PRs++
+		stats.Additions += pr.Additions
+		stats.Deletions += pr.Deletions
+		stats.Periods[key]++
+
+		if byPeriod[key] == nil {
+			byPeriod[key] = &periodStats{Period: key}
+			periodAuthors[key] = make(map[string]bool)
+		}
+		periodAuthors[key][author] = true
+		byPeriod[key].PRs++
+		byPeriod[key].Additions += pr.Additions
+		byPeriod[key].Deletions += pr.Deletions
+
+		if ttm, err := pr.TimeToMerge(); err == nil {
+			authorTimes[author] = append(authorTimes[author], ttm)
+			repoTimes[repoFromURL(pr.URL)] = append(repoTimes[repoFromURL(pr.URL)], ttm)
+			labels := pr.Labels
+			if len(labels) == 0 {
+				labels = []string{noLabel}
+			}
+			for _, label := range labels {
+				labelTimes[label] = append(labelTimes[label], ttm)
+			}
+		}
+	}
+
+	report := statsReport{Period: period}
+	for _, stats := range byAuthor {
+		stats.TimeToMerge = newMergeTimes(stats.Author, authorTimes[stats.Author])
+		report.Authors = append(report.Authors, *stats)
+	}
+	report.MergeTimesByRepo = groupMergeTimes(repoTimes)
+	report.MergeTimesByLabel = groupMergeTimes(labelTimes)
+	report.Sizes = computeSizeHistograms(prs, since, until, period)
+	sort.Slice(report.Authors, func(i, j int) bool {
+		a, b := report.Authors[i], report.Authors[j]
+		if a.PRs != b.PRs {
+			return a.PRs > b.PRs
+		}
+		return a.Author < b.Author
+	})
+
+	for _, key := range period.keys(since, until) {
+		stats := periodStats{Period: key}
+		if byPeriod[key] != nil {
+			stats = *byPeriod[key]
+			stats.Authors = len(periodAuthors[key])
+		}
+		report.Periods = append(report.Periods, stats)
+	}
+	return report
+}
+
+// formatDuration formats d as a number of the named -ttm-unit units
+func formatDuration(d time.Duration, unit string) string {
+	return strconv.FormatFloat(float64(d)/float64(timeToMergeUnits[unit]), 'f', 2, 64)
+}
+
+// table returns the per-author stats with one count column per period, for
+// saving, with times to merge in unit
+func (r statsReport) table(unit string) Table {
+	table := Table{Header: []string{"Author", "PRs", "Additions", "Deletions"}}
+	for _, p := range []string{"p50", "p75", "p90"} {
+		table.Header = append(table.Header, fmt.Sprintf("Time To Merge %s (%s)", p, unit))
+	}
+	for _, period := range r.Periods {
+		table.Header = append(table.Header, r.Period.heading()+" "+period.Period)
+	}
+	for _, stats := range r.Authors {
+		row := []string{stats.Author, strconv.Itoa(stats.PRs), strconv.Itoa(stats.Additions), strconv.Itoa(stats.Deletions)}
+		ttm := stats.TimeToMerge
+		row = append(row, formatDuration(ttm.P50, unit), formatDuration(ttm.P75, unit), formatDuration(ttm.P90, unit))
+		for _, period := range r.Periods {
+			row = append(row, strconv.Itoa(stats.Periods[period.Period]))
+		}
+		table.Rows = append(table.Rows, row)
+	}
+	table.Kinds = textThenNumbers(1, len(table.Header))
+	return table
+}
+
+// trendTable returns the totals for each period, for saving
+func (r statsReport) trendTable() Table {
+	table := Table{Header: []string{r.Period.heading(), "PRs", "Authors", "Additions", "Deletions"}, Kinds: textThenNumbers(1, 5)}
+	for _, p := range r.Periods {
+		table.Rows = append(table.Rows, []string{p.Period, strconv.Itoa(p.PRs), strconv.Itoa(p.Authors), strconv.Itoa(p.Additions), strconv.Itoa(p.Deletions)})
+	}
+	return table
+}
+
+// print writes the per-author and per-period tables, the time to merge
+// percentiles in unit, and the size histograms
+func (r statsReport) print(w io.Writer, unit string) {
+	total := 0
+	for _, stats := range r.Authors {
+		total += stats.PRs
+	}
+
+	tw := tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(tw, "Author\tPRs\tShare\tAdditions\tDeletions")
+	for _, stats := range r.Authors {
+		fmt.Fprintf(tw, "%s\t%d\t%.0f%%\t+%d\t-%d\n", stats.Author, stats.PRs, 100*float64(stats.PRs)/float64(total), stats.Additions, stats.Deletions)
+	}
+	tw.Flush()
+
+	fmt.Fprintln(w)
+	tw = tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
+	fmt.Fprintf(tw, "%s\tPRs\tAuthors\tAdditions\tDeletions\n", r.Period.heading())
+	for _, period := range r.Periods {
+		fmt.Fprintf(tw, "%s\t%d\t%d\t+%d\t-%d\n", period.Period, period.PRs, period.Authors, period.Additions, period.Deletions)
+	}
+	tw.Flush()
+
+	authorTimes := make([]mergeTimes, len(r.Authors))
+	for i, stats := range r.Authors {
+		authorTimes[i] = stats.TimeToMerge
+	}
+	for _, section := range []struct {
+		name   string
+		groups []mergeTimes
+	}{
+		{"Repo", r.MergeTimesByRepo},
+		{"Author", authorTimes},
+		{"Label", r.MergeTimesByLabel},
+	} {
+		fmt.Fprintf(w, "\nTime to merge by %s (%s)\n", strings.ToLower(section.name), unit)
+		tw = tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
+		fmt.Fprintf(tw, "%s\tPRs\tp50\tp75\tp90\n", section.name)
+		for _, g := range section.groups {
+			if g.PRs == 0 {
+				continue
+			}
+			fmt.Fprintf(tw, "%s\t%d\t%s\t%s\t%s\n", g.Group, g.PRs, formatDuration(g.P50, unit), formatDuration(g.P75, unit), formatDuration(g.P90, unit))
+		}
+		tw.Flush()
+	}
+
+	printSizeHistograms(w, r.Sizes, r.Period)
+}
+
+// writerForFile returns the registered Writer whose extension matches path
+func writerForFile(path string) (Writer, error) {
+	ext := filepath.Ext(path)
+	for _, name := range writerNames() {
+		if writers[name].Extension() == ext {
+			return writers[name], nil
+		}
+	}
+	return nil, fmt.Errorf("can't tell the format of %q from its extension, use one of .csv, .json, or .md", path)
+}
+
+// lookupStatsPeriod returns the period for a -period value; empty means week
+func lookupStatsPeriod(name string) (statsPeriod, error) {
+	if name == "" {
+		name = "week"
+	}
+	period, ok := statsPeriods[name]
+	if !ok {
+		return statsPeriod{}, fmt.Errorf("invalid period %q: must be week or month", name)
+	}
+	return period, nil
+}
+
+// fetchStatsPRs fetches the merged PRs of every repo with the fields stats
+// need, and their reviews if opts.Reviews is set
+func fetchStatsPRs(opts StatsOptions) ([]PR, error) {
+	listOpts := opts.List
+	listOpts.Fields = opts.fields()
+	var prs []PR
+	for _, repo := range opts.Repos {
+		// The limit covers every repo, so each one only fetches what's left of it
+		if opts.List.Limit > 0 {
+			if len(prs) >= opts.List.Limit {
+				break
+			}
+			listOpts.Limit = opts.List.Limit - len(prs)
+		}
+		listOpts.Repo = repo
+		repoPRs, err := getMergedPRs(listOpts)
+		if err != nil {
+			return nil, fmt.Errorf("error fetching PRs for %s: %v", repo, err)
+		}
+		if opts.Reviews {
+			fetchReviews(listOpts, repoPRs)
+		}
+		if opts.LeadTimeReleases {
+			if err := fetchReleases(listOpts, repoPRs); err != nil {
+				listOpts.printf("  Warning: %v\n", err)
+				events.Warn("releases_failed", "repo", repo, "error", err.Error())
+			}
+		}
+		prs = append(prs, repoPRs...)
+	}
+	return prs, nil
+}
+
+// fields returns the optional fields stats mode fetches with each PR
+func (o StatsOptions) fields() []string {
+	fields := []string{"author", "labels"}
+	if o.LeadTime || o.LeadTimeReleases {
+		fields = append(fields, "firstCommit")
+	}
+	return fields
+}
+
+// runStatsMode fetches merged PRs and prints who merged them and when
+func runStatsMode(opts StatsOptions) error {
+	w := opts.Output
+	if w == nil {
+		w = os.Stdout
+	}
+	// With the JSON on stdout, the tables are dropped and progress goes to stderr
+	jsonOut := w
+	if opts.JSONFile == "-" {
+		w = io.Discard
+		if opts.List.Progress == nil {
+			opts.List.Progress = os.Stderr
+		}
+	}
+	listOpts := opts.List
+	listOpts.Fields = opts.fields()
+	unit := listOpts.TimeToMergeUnit
+	if unit == "" {
+		unit = "hours"
+	}
+
+	period, err := lookupStatsPeriod(opts.Period)
+	if err != nil {
+		return err
+	}
+
+	// Check the output formats first so a bad name doesn't waste a fetch
+	for _, file := range []string{opts.OutFile, opts.ReviewsOutFile, opts.LeadTimeOutFile, opts.LeaderboardOutFile, opts.SizesOutFile, opts.TrendOutFile} {
+		if file == "" {
+			continue
+		}
+		if _, err := writerForFile(file); err != nil {
+			return err
+		}
+	}
+	chartFormat, err := lookupChartFormat(opts.ChartFormat)
+	if err != nil {
+		return err
+	}
+	// The comparison fetches only what the summary needs
+	compareOpts := opts
+	if opts.CompareTo != "" {
+		since, until, err := comparisonRange(opts.CompareTo, listOpts.Since, listOpts.until())
+		if err != nil {
+			return err
+		}
+		compareOpts.List.Since, compareOpts.List.Until = since, until
+		compareOpts.Reviews, compareOpts.LeadTime, compareOpts.LeadTimeReleases = false, false, false
+	}
+
+	if listOpts.DryRun {
+		for _, repo := range opts.Repos {
+			listOpts.Repo = repo
+			planMergedPRs(listOpts)
+			if opts.Reviews {
+				fmt.Fprintf(w, "Would then run %s for each PR\n", formatGHCommand("api", fmt.Sprintf("repos/%s/pulls/<number>/reviews", repo), "--paginate", "--jq", reviewsJQ))
+			}
+			if opts.LeadTimeReleases {
+				fmt.Fprintf(w, "Would then run %s\n", formatGHCommand("api", fmt.Sprintf("repos/%s/releases", repo), "--paginate", "--jq", releasesJQ))
+			}
+			if opts.Leaderboard || opts.LeaderboardOutFile != "" {
+				fmt.Fprintf(w, "Would then run %s for each contributor\n", formatGHCommand(priorPRsArgs(repo, "<login>", listOpts.Since)...))
+			}
+		}
+		if listOpts.Limit > 0 && len(opts.Repos) > 1 {
+			fmt.Fprintf(w, "Would skip the remaining repos once %d PRs have been collected across them\n", listOpts.Limit)
+		}
+		for _, file := range []string{opts.OutFile, opts.ReviewsOutFile, opts.LeadTimeOutFile, opts.LeaderboardOutFile, opts.SizesOutFile, opts.TrendOutFile} {
+			if file != "" {
+				fmt.Fprintf(w, "Would save stats to %s\n", file)
+			}
+		}
+		if opts.ChartsDir != "" {
+			fmt.Fprintf(w, "Would save %s charts to %s\n", chartFormat, opts.ChartsDir)
+		}
+		if opts.JSONFile != "" && opts.JSONFile != "-" {
+			fmt.Fprintf(w, "Would save stats to %s\n", opts.JSONFile)
+		}
+		if opts.CompareTo != "" {
+			fmt.Fprintf(w, "Would compare with %s to %s:\n", compareOpts.List.Since.Format("2006-01-02"), compareOpts.List.Until.Format("2006-01-02"))
+			for _, repo := range opts.Repos {
+				compareList := compareOpts.List
+				compareList.Repo = repo
+				compareList.Fields = compareOpts.fields()
+				planMergedPRs(compareList)
+			}
+		}
+		return nil
+	}
+
+	prs, err := fetchStatsPRs(opts)
+	if err != nil {
+		return err
+	}
+	if len(prs) == 0 {
+		fmt.Fprintln(w, "No PRs found.")
+		// CI jobs reading the JSON still get a document, with everything 0
+		if opts.JSONFile != "" {
+			results := statsResults{Report: computeStats(nil, listOpts.Since, listOpts.until(), period)}
+			return saveStatsJSON(jsonOut, opts.JSONFile, newStatsDocument(opts, results))
+		}
+		return nil
+	}
+
+	report := computeStats(prs, listOpts.Since, listOpts.until(), period)
+	results := statsResults{PRs: prs, Report: report, LeadTimeReleases: opts.LeadTimeReleases}
+	fmt.Fprintln(w)
+	report.print(w, unit)
+	if err := saveStatsTable(w, opts.OutFile, report.table(unit)); err != nil {
+		return err
+	}
+	if err := saveStatsTable(w, opts.TrendOutFile, report.trendTable()); err != nil {
+		return err
+	}
+	if err := saveStatsTable(w, opts.SizesOutFile, sizeTable(report.Sizes, report.Period)); err != nil {
+		return err
+	}
+	if err := saveStatsCharts(w, report, opts.ChartsDir, chartFormat); err != nil {
+		return err
+	}
+
+	if opts.Reviews {
+		reviews := computeReviewReport(prs)
+		results.Reviews = &reviews
+		reviews.print(w, unit)
+		if err := saveStatsTable(w, opts.ReviewsOutFile, reviews.table(unit)); err != nil {
+			return err
+		}
+	}
+	if opts.Leaderboard || opts.LeaderboardOutFile != "" {
+		leaderboard := computeLeaderboard(prs, fetchFirstTimers(listOpts, prs))
+		results.Leaderboard = leaderboard
+		printLeaderboard(w, leaderboard)
+		if err := saveStatsTable(w, opts.LeaderboardOutFile, leaderboardTable(leaderboard)); err != nil {
+			return err
+		}
+	}
+	if opts.CompareTo != "" {
+		previous, err := fetchStatsPRs(compareOpts)
+		if err != nil {
+			return err
+		}
+		previous = mergedIn(previous, compareOpts.List.Since, compareOpts.List.Until)
+		summary := summarizePeriod(previous, compareOpts.List.Since, compareOpts.List.Until)
+		results.Previous = &summary
+		printComparison(w, summarizePeriod(prs, listOpts.Since, listOpts.until()), summary, unit)
+	}
+	if opts.LeadTime || opts.LeadTimeReleases {
+		leadTimes := computeLeadTimes(prs)
+		results.LeadTimes = leadTimes
+		printLeadTimes(w, leadTimes, unit, opts.LeadTimeReleases)
+		if err := saveStatsTable(w, opts.LeadTimeOutFile, leadTimeTable(leadTimes, unit, opts.LeadTimeReleases)); err != nil {
+			return err
+		}
+	}
+	if opts.JSONFile != "" {
+		return saveStatsJSON(jsonOut, opts.JSONFile, newStatsDocument(opts, results))
+	}
+	return nil
+}
+
+// saveStatsCharts saves the report's charts to dir, doing nothing if dir is empty
+func saveStatsCharts(w io.Writer, report statsReport, dir, format string) error {
+	if dir == "" {
+		return nil
+	}
+	var charts []barChart
+	for _, chart := range reportCharts(report) {
+		if len(chart.Labels) > 0 {
+			charts = append(charts, chart)
+		}
+	}
+	files, err := saveCharts(charts, dir, format)
+	if err != nil {
+		return err
+	}
+	fmt.Fprintf(w, "\nSaved %d charts to %s\n", len(files), dir)
+	events.Info("charts_saved", "dir", dir, "format", format, "count", len(files))
+	addOutput(dir)
+	return nil
+}
+
+// saveStatsTable saves table to path in the format matching its extension,
+// doing nothing if path is empty
+func saveStatsTable(w io.Writer, path string, table Table) error {
+	if path == "" {
+		return nil
+	}
+	writer, err := writerForFile(path)
+	if err != nil {
+		return err
+	}
+	file, err := os.Create(path)
+	if err != nil {
+		return fmt.Errorf("error saving stats: %v", err)
+	}
+	if err := writer.Write(file, table); err != nil {
+		file.Close()
+		return fmt.Errorf("error saving stats: %v", err)
+	}
+	if err := file.Close(); err != nil {
+		return fmt.Errorf("error saving stats: %v", err)
+	}
+	fmt.Fprintf(w, "\nSaved stats to %s\n", path)
+	addOutput(path)
+	return nil
+}
@@ -0,0 +1,234 @@
+package main
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"reflect"
+	"slices"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestWeekStart(t *testing.T) {
+	for _, tc := range []struct{ in, want string }{
+		{"2024-03-04T10:00:00Z", "2024-03-04"}, // Monday
+		{"2024-03-10T23:59:00Z", "2024-03-04"}, // Sunday
+		{"2024-03-01T00:00:00Z", "2024-02-26"}, // Friday, previous month
+	} {
+		in, _ := time.Parse(time.RFC3339, tc.in)
+		if got := weekStart(in).Format("2006-01-02"); got != tc.want {
+			t.Errorf("weekStart(%s) = %s, want %s", tc.in, got, tc.want)
+		}
+	}
+}
+
+func TestComputeStats(t *testing.T) {
+	prs := makePRs(time.Date(2024, 3, 4, 12, 0, 0, 0, time.UTC), 24*time.Hour, 10)
+	for i := range prs {
+		prs[i].Author = []string{"alice", "bob", "alice", ""}[i%4]
+	}
+	report := computeStats(prs, time.Date(2024, 3, 1, 0, 0, 0, 0, time.UTC), time.Date(2024, 3, 25, 0, 0, 0, 0, time.UTC), statsPeriods["week"])
+
+	var authors []string
+	for _, stats := range report.Authors {
+		authors = append(authors, stats.Author)
+	}
+	if strings.Join(authors, ",") != "alice,bob,"+unknownAuthor {
+		t.Errorf("authors = %v, want alice, bob, then unknown", authors)
+	}
+	alice := report.Authors[0]
+	if alice.PRs != 5 || alice.Periods["2024-03-04"] != 4 || alice.Periods["2024-03-11"] != 1 {
+		t.Errorf("alice = %+v", alice)
+	}
+
+	// Weeks with nothing merged are still listed, so trends have no gaps
+	var weeks []string
+	for _, week := range report.Periods {
+		weeks = append(weeks, week.Period)
+	}
+	if strings.Join(weeks, ",") != "2024-02-26,2024-03-04,2024-03-11,2024-03-18" {
+		t.Errorf("weeks = %v", weeks)
+	}
+	if report.Periods[0].PRs != 0 || report.Periods[1].PRs != 7 || report.Periods[1].Authors != 3 {
+		t.Errorf("week counts = %+v", report.Periods)
+	}
+
+	table := report.table("hours")
+	if len(table.Header) != 7+len(report.Periods) || table.Rows[0][0] != "alice" || table.Rows[0][8] != "4" {
+		t.Errorf("table = %v %v", table.Header, table.Rows)
+	}
+}
+
+func TestRunStatsMode(t *testing.T) {
+	prs := makePRs(daysAgo(5), time.Hour, 3)
+	for i := range prs {
+		prs[i].Author = "octocat"
+	}
+	outFile := filepath.Join(t.TempDir(), "stats.json")
+	var out bytes.Buffer
+
+	err := runStatsMode(StatsOptions{List: testOptions(daysAgo(7), &fakeRunner{prs: prs}), Repos: []string{"acme/widgets"}, OutFile: outFile, Output: &out})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(out.String(), "octocat  3") {
+		t.Errorf("printed stats missing octocat's 3 PRs:\n%s", out.String())
+	}
+	data, err := os.ReadFile(outFile)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(string(data), `"author": "octocat", "prs": 3,`) {
+		t.Errorf("saved stats:\n%s", data)
+	}
+}
+
+func TestFetchStatsPRsLimitAcrossRepos(t *testing.T) {
+	runner := &fakeRunner{prs: makePRs(daysAgo(5), time.Hour, 4)}
+	list := testOptions(daysAgo(7), runner)
+	list.Limit = 6
+
+	prs, err := fetchStatsPRs(StatsOptions{List: list, Repos: []string{"acme/widgets", "acme/gadgets", "acme/gizmos"}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(prs) != 6 {
+		t.Errorf("fetched %d PRs, want the limit of 6 across all repos", len(prs))
+	}
+	if !slices.Equal(runner.limits, []string{"6", "2"}) {
+		t.Errorf("queried with limits %v, want 6 then what's left, and no query for the third repo", runner.limits)
+	}
+}
+
+func TestPercentile(t *testing.T) {
+	var durations []time.Duration
+	for i := 10; i >= 1; i-- {
+		durations = append(durations, time.Duration(i)*time.Hour)
+	}
+	times := newMergeTimes("all", durations)
+	if times.PRs != 10 || times.P50 != 5*time.Hour || times.P75 != 8*time.Hour || times.P90 != 9*time.Hour {
+		t.Errorf("newMergeTimes = %+v, want p50 5h, p75 8h, p90 9h", times)
+	}
+	if one := newMergeTimes("one", []time.Duration{time.Minute}); one.P50 != time.Minute || one.P90 != time.Minute {
+		t.Errorf("single PR = %+v", one)
+	}
+	if empty := newMergeTimes("none", nil); empty.P90 != 0 {
+		t.Errorf("no PRs = %+v", empty)
+	}
+}
+
+func TestMergeTimesByGroup(t *testing.T) {
+	prs := makePRs(time.Date(2024, 3, 4, 12, 0, 0, 0, time.UTC), time.Hour, 3)
+	prs[0].Labels = []string{"bug", "p1"} // open for an hour, like the others
+	prs[1].Labels = []string{"bug"}
+	prs[2].CreatedAt = time.Date(2024, 3, 1, 14, 0, 0, 0, time.UTC).Format(time.RFC3339)
+	prs[2].URL = "https://github.com/acme/gadgets/pull/3"
+
+	report := computeStats(prs, time.Date(2024, 3, 4, 0, 0, 0, 0, time.UTC), time.Date(2024, 3, 5, 0, 0, 0, 0, time.UTC), statsPeriods["week"])
+	// Slowest median first: the unlabeled PR in acme/gadgets took three days
+	if g := report.MergeTimesByRepo; len(g) != 2 || g[0].Group != "acme/gadgets" || g[0].P50 != 72*time.Hour || g[1].PRs != 2 {
+		t.Errorf("by repo = %+v", g)
+	}
+	if g := report.MergeTimesByLabel; len(g) != 3 || g[0].Group != noLabel || g[1].Group != "bug" || g[1].PRs != 2 || g[2].Group != "p1" {
+		t.Errorf("by label = %+v", g)
+	}
+}
+
+func TestWriterForFile(t *testing.T) {
+	if w, err := writerForFile("out/stats.md"); err != nil || w.Extension() != ".md" {
+		t.Errorf("writerForFile(.md) = %v, %v", w, err)
+	}
+	if _, err := writerForFile("stats.xlsx"); err == nil {
+		t.Error("expected an error for an unknown extension")
+	}
+}
+
+func TestSizeBucketIndex(t *testing.T) {
+	for changes, want := range map[int]string{0: "XS", 9: "XS", 10: "S", 99: "M", 100: "L", 499: "L", 500: "XL", 100000: "XL"} {
+		if got := sizeBuckets[sizeBucketIndex(changes)].Name; got != want {
+			t.Errorf("%d lines changed is %s, want %s", changes, got, want)
+		}
+	}
+	if got := strings.Join(sizeBucketHeaders(), ","); got != "XS (0-9),S (10-29),M (30-99),L (100-499),XL (500+)" {
+		t.Errorf("headers = %s", got)
+	}
+}
+
+func TestComputeSizeHistograms(t *testing.T) {
+	prs := makePRs(time.Date(2024, 3, 4, 12, 0, 0, 0, time.UTC), 24*time.Hour, 3)
+	prs[0].Additions, prs[1].Additions, prs[2].Additions = 4, 40, 800
+	prs[2].URL = "https://github.com/acme/gadgets/pull/3"
+
+	histograms := computeSizeHistograms(prs, time.Date(2024, 3, 4, 0, 0, 0, 0, time.UTC), time.Date(2024, 3, 12, 0, 0, 0, 0, time.UTC), statsPeriods["week"])
+	// Two repos with two weeks each, including the empty second week
+	if len(histograms) != 4 {
+		t.Fatalf("got %d histograms, want 4: %+v", len(histograms), histograms)
+	}
+	gadgets, widgets := histograms[0], histograms[2]
+	if gadgets.Repo != "acme/gadgets" || gadgets.Counts[4] != 1 || gadgets.MedianChanges != 801 {
+		t.Errorf("acme/gadgets = %+v", gadgets)
+	}
+	if widgets.Period != "2024-03-04" || widgets.Counts[0] != 1 || widgets.Counts[2] != 1 || widgets.MedianChanges != 5 {
+		t.Errorf("acme/widgets = %+v", widgets)
+	}
+	if empty := histograms[3]; empty.Period != "2024-03-11" || empty.MedianChanges != 0 {
+		t.Errorf("empty week = %+v", empty)
+	}
+}
+
+func TestMonthlyStats(t *testing.T) {
+	prs := makePRs(time.Date(2024, 1, 30, 12, 0, 0, 0, time.UTC), 24*time.Hour, 4)
+	report := computeStats(prs, time.Date(2024, 1, 15, 0, 0, 0, 0, time.UTC), time.Date(2024, 3, 10, 0, 0, 0, 0, time.UTC), statsPeriods["month"])
+
+	table := report.trendTable()
+	want := [][]string{
+		{"2024-01-01", "2", "1", "1", "2"},
+		{"2024-02-01", "2", "1", "5", "2"},
+		{"2024-03-01", "0", "0", "0", "0"},
+	}
+	if table.Header[0] != "Month of" || !reflect.DeepEqual(table.Rows, want) {
+		t.Errorf("trend table = %v %v, want %v", table.Header, table.Rows, want)
+	}
+	if len(report.Sizes) != 3 || report.Sizes[1].Period != "2024-02-01" {
+		t.Errorf("sizes = %+v, want one histogram per month", report.Sizes)
+	}
+}
+
+func TestRunStatsModeRejectsUnknownPeriod(t *testing.T) {
+	err := runStatsMode(StatsOptions{List: testOptions(daysAgo(7), &fakeRunner{}), Repos: []string{"acme/widgets"}, Period: "day"})
+	if err == nil || !strings.Contains(err.Error(), "week or month") {
+		t.Errorf("runStatsMode with -period day = %v", err)
+	}
+}
+
+func TestRunStatsModeDryRun(t *testing.T) {
+	runner := &fakeRunner{prs: makePRs(daysAgo(5), time.Hour, 3)}
+	var out bytes.Buffer
+	list := testOptions(daysAgo(7), runner)
+	list.DryRun = true
+	list.Progress = &out
+	outFile := filepath.Join(t.TempDir(), "stats.csv")
+
+	err := runStatsMode(StatsOptions{List: list, Repos: []string{"acme/widgets", "acme/gadgets"}, Reviews: true, OutFile: outFile, Output: &out})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if runner.calls != 0 {
+		t.Errorf("dry run ran %d commands", runner.calls)
+	}
+	for _, want := range []string{
+		"gh pr list --repo acme/widgets --search merged:",
+		"gh pr list --repo acme/gadgets --search merged:",
+		"Would then run gh api 'repos/acme/gadgets/pulls/<number>/reviews' --paginate",
+		"Would save stats to " + outFile,
+	} {
+		if !strings.Contains(out.String(), want) {
+			t.Errorf("plan is missing %q:\n%s", want, out.String())
+		}
+	}
+	if _, err := os.Stat(outFile); !os.IsNotExist(err) {
+		t.Errorf("dry run saved %s: %v", outFile, err)
+	}
+}
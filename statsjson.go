@@ -0,0 +1,243 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"time"
+)
+
+// statsSchemaVersion is bumped whenever a field of statsDocument is renamed,
+// removed, or changes meaning; adding fields doesn't change it
+const statsSchemaVersion = 1
+
+// statsDocument is the stats mode aggregates as saved with -stats-json, for
+// CI jobs and scorecards to read. Durations are whole seconds, and sections
+// whose flag wasn't given are null, so every key is always present.
+type statsDocument struct {
+	SchemaVersion int      `json:"schemaVersion"`
+	Since         string   `json:"since"`
+	Until         string   `json:"until"`
+	Repos         []string `json:"repos"`
+	Period        string   `json:"period"`
+
+	Totals             statsTotalsJSON     `json:"totals"`
+	Authors            []authorStatsJSON   `json:"authors"`
+	Periods            []periodStatsJSON   `json:"periods"`
+	TimeToMergeByRepo  []mergeTimesJSON    `json:"timeToMergeByRepo"`
+	TimeToMergeByLabel []mergeTimesJSON    `json:"timeToMergeByLabel"`
+	Sizes              []sizeHistogramJSON `json:"sizes"`
+	Reviews            *reviewReportJSON   `json:"reviews"`
+	LeadTime           []leadTimesJSON     `json:"leadTime"`
+	Leaderboard        []leaderboardJSON   `json:"leaderboard"`
+	Comparison         *periodSummaryJSON  `json:"comparison"`
+}
+
+type statsTotalsJSON struct {
+	PRs           int            `json:"prs"`
+	Authors       int            `json:"authors"`
+	Additions     int            `json:"additions"`
+	Deletions     int            `json:"deletions"`
+	MedianChanges int            `json:"medianChanges"`
+	TimeToMerge   mergeTimesJSON `json:"timeToMerge"`
+}
+
+type mergeTimesJSON struct {
+	Group      string `json:"group,omitempty"`
+	PRs        int    `json:"prs"`
+	P50Seconds int64  `json:"p50Seconds"`
+	P75Seconds int64  `json:"p75Seconds"`
+	P90Seconds int64  `json:"p90Seconds"`
+}
+
+type authorStatsJSON struct {
+	Author      string         `json:"author"`
+	PRs         int            `json:"prs"`
+	Additions   int            `json:"additions"`
+	Deletions   int            `json:"deletions"`
+	TimeToMerge mergeTimesJSON `json:"timeToMerge"`
+}
+
+type periodStatsJSON struct {
+	Start     string `json:"start"`
+	PRs       int    `json:"prs"`
+	Authors   int    `json:"authors"`
+	Additions int    `json:"additions"`
+	Deletions int    `json:"deletions"`
+}
+
+type sizeHistogramJSON struct {
+	Repo          string         `json:"repo"`
+	Period        string         `json:"period"`
+	Counts        map[string]int `json:"counts"` // by bucket name, such as XS
+	MedianChanges int            `json:"medianChanges"`
+}
+
+type reviewReportJSON struct {
+	Unreviewed int                 `json:"unreviewed"`
+	ByRepo     []reviewLatencyJSON `json:"byRepo"`
+	ByReviewer []reviewLatencyJSON `json:"byReviewer"`
+}
+
+type reviewLatencyJSON struct {
+	Group           string         `json:"group"`
+	Reviewed        int            `json:"reviewed"`
+	Approved        int            `json:"approved"`
+	FirstReview     mergeTimesJSON `json:"firstReview"`
+	ApprovalToMerge mergeTimesJSON `json:"approvalToMerge"`
+}
+
+type leadTimesJSON struct {
+	Repo           string          `json:"repo"`
+	ToMerge        mergeTimesJSON  `json:"toMerge"`
+	Released       *int            `json:"released,omitempty"`
+	MergeToRelease *mergeTimesJSON `json:"mergeToRelease,omitempty"`
+	ToRelease      *mergeTimesJSON `json:"toRelease,omitempty"`
+}
+
+type leaderboardJSON struct {
+	Rank        int      `json:"rank"`
+	Author      string   `json:"author"`
+	PRs         int      `json:"prs"`
+	Changes     int      `json:"changes"`
+	Repos       []string `json:"repos"`
+	FirstTimeIn []string `json:"firstTimeIn"`
+}
+
+type periodSummaryJSON struct {
+	Since                    string `json:"since"`
+	Until                    string `json:"until"`
+	PRs                      int    `json:"prs"`
+	Authors                  int    `json:"authors"`
+	MedianTimeToMergeSeconds int64  `json:"medianTimeToMergeSeconds"`
+}
+
+// statsResults is everything runStatsMode computed, for saving as JSON; the
+// optional sections are nil when their flag wasn't given, and non-nil but
+// empty when it was and there was nothing to report
+type statsResults struct {
+	PRs              []PR
+	Report           statsReport
+	Reviews          *reviewReport
+	LeadTimes        []leadTimes
+	LeadTimeReleases bool
+	Leaderboard      []leaderboardEntry
+	Previous         *periodSummary
+}
+
+func newMergeTimesJSON(t mergeTimes) mergeTimesJSON {
+	return mergeTimesJSON{Group: t.Group, PRs: t.PRs, P50Seconds: seconds(t.P50), P75Seconds: seconds(t.P75), P90Seconds: seconds(t.P90)}
+}
+
+func mergeTimesListJSON(groups []mergeTimes) []mergeTimesJSON {
+	list := make([]mergeTimesJSON, len(groups))
+	for i, g := range groups {
+		list[i] = newMergeTimesJSON(g)
+	}
+	return list
+}
+
+func reviewLatencyListJSON(groups []reviewLatency) []reviewLatencyJSON {
+	list := make([]reviewLatencyJSON, len(groups))
+	for i, g := range groups {
+		list[i] = reviewLatencyJSON{Group: g.Group, Reviewed: g.Reviewed, Approved: g.Approved, FirstReview: newMergeTimesJSON(g.FirstReview), ApprovalToMerge: newMergeTimesJSON(g.ApprovalToMerge)}
+	}
+	return list
+}
+
+// seconds returns d in whole seconds
+func seconds(d time.Duration) int64 {
+	return int64(d / time.Second)
+}
+
+// newStatsDocument builds the JSON document for a stats mode run
+func newStatsDocument(opts StatsOptions, results statsResults) statsDocument {
+	report := results.Report
+	doc := statsDocument{
+		SchemaVersion: statsSchemaVersion,
+		Since:         opts.List.Since.Format(time.RFC3339),
+		Until:         opts.List.until().Format(time.RFC3339),
+		Repos:         opts.Repos,
+		Period:        report.Period.Name,
+
+		Authors:            []authorStatsJSON{},
+		Periods:            []periodStatsJSON{},
+		TimeToMergeByRepo:  mergeTimesListJSON(report.MergeTimesByRepo),
+		TimeToMergeByLabel: mergeTimesListJSON(report.MergeTimesByLabel),
+		Sizes:              []sizeHistogramJSON{},
+	}
+
+	var durations []time.Duration
+	var changes []int
+	for _, pr := range results.PRs {
+		if d, err := pr.TimeToMerge(); err == nil {
+			durations = append(durations, d)
+		}
+		changes = append(changes, pr.Changes())
+		doc.Totals.Additions += pr.Additions
+		doc.Totals.Deletions += pr.Deletions
+	}
+	doc.Totals.PRs = len(results.PRs)
+	doc.Totals.Authors = len(report.Authors)
+	doc.Totals.MedianChanges = medianInt(changes)
+	doc.Totals.TimeToMerge = newMergeTimesJSON(newMergeTimes("", durations))
+
+	for _, a := range report.Authors {
+		doc.Authors = append(doc.Authors, authorStatsJSON{Author: a.Author, PRs: a.PRs, Additions: a.Additions, Deletions: a.Deletions, TimeToMerge: newMergeTimesJSON(a.TimeToMerge)})
+	}
+	for _, p := range report.Periods {
+		doc.Periods = append(doc.Periods, periodStatsJSON{Start: p.Period, PRs: p.PRs, Authors: p.Authors, Additions: p.Additions, Deletions: p.Deletions})
+	}
+	for _, h := range report.Sizes {
+		counts := make(map[string]int, len(sizeBuckets))
+		for i, bucket := range sizeBuckets {
+			counts[bucket.Name] = h.Counts[i]
+		}
+		doc.Sizes = append(doc.Sizes, sizeHistogramJSON{Repo: h.Repo, Period: h.Period, Counts: counts, MedianChanges: h.MedianChanges})
+	}
+
+	if r := results.Reviews; r != nil {
+		doc.Reviews = &reviewReportJSON{Unreviewed: r.Unreviewed, ByRepo: reviewLatencyListJSON(r.ByRepo), ByReviewer: reviewLatencyListJSON(r.ByReviewer)}
+	}
+	if results.LeadTimes != nil {
+		doc.LeadTime = []leadTimesJSON{}
+	}
+	for _, l := range results.LeadTimes {
+		entry := leadTimesJSON{Repo: l.Group, ToMerge: newMergeTimesJSON(l.ToMerge)}
+		if results.LeadTimeReleases {
+			released, mergeToRelease, toRelease := l.Released, newMergeTimesJSON(l.MergeToRelease), newMergeTimesJSON(l.ToRelease)
+			entry.Released, entry.MergeToRelease, entry.ToRelease = &released, &mergeToRelease, &toRelease
+		}
+		doc.LeadTime = append(doc.LeadTime, entry)
+	}
+	if results.Leaderboard != nil {
+		doc.Leaderboard = []leaderboardJSON{}
+	}
+	for _, e := range results.Leaderboard {
+		doc.Leaderboard = append(doc.Leaderboard, leaderboardJSON{Rank: e.Rank, Author: e.Author, PRs: e.PRs, Changes: e.Changes, Repos: e.Repos, FirstTimeIn: append([]string{}, e.FirstTimeIn...)})
+	}
+	if p := results.Previous; p != nil {
+		doc.Comparison = &periodSummaryJSON{Since: p.Since.Format(time.RFC3339), Until: p.Until.Format(time.RFC3339), PRs: p.PRs, Authors: p.Authors, MedianTimeToMergeSeconds: seconds(p.MedianTimeToMerge)}
+	}
+	return doc
+}
+
+// saveStatsJSON writes the document to path, or to w if path is -
+func saveStatsJSON(w io.Writer, path string, doc statsDocument) error {
+	data, err := json.MarshalIndent(doc, "", "  ")
+	if err != nil {
+		return fmt.Errorf("error encoding stats: %v", err)
+	}
+	data = append(data, '\n')
+	if path == "-" {
+		_, err := w.Write(data)
+		return err
+	}
+	if err := os.WriteFile(path, data, 0644); err != nil {
+		return fmt.Errorf("error saving stats: %v", err)
+	}
+	fmt.Fprintf(w, "\nSaved stats to %s\n", path)
+	addOutput(path)
+	return nil
+}
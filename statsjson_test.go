@@ -0,0 +1,79 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestRunStatsModeJSON(t *testing.T) {
+	prs := makePRs(daysAgo(5), time.Hour, 3)
+	prs[0].Author, prs[1].Author, prs[2].Author = "alice", "bob", "alice"
+	created, _ := time.Parse(time.RFC3339, prs[0].CreatedAt)
+	runner := &fakeRunner{prs: prs, reviews: map[string][]string{
+		"1": {"bob\tAPPROVED\t" + created.Add(30*time.Minute).Format(time.RFC3339)},
+		"2": {},
+		"3": {"alice\tCOMMENTED\t" + created.Add(30*time.Minute).Format(time.RFC3339)}, // the author's own
+	}}
+	var out bytes.Buffer
+
+	opts := StatsOptions{List: testOptions(daysAgo(7), runner), Repos: []string{"acme/widgets"}, Reviews: true, JSONFile: "-", Output: &out}
+	if err := runStatsMode(opts); err != nil {
+		t.Fatal(err)
+	}
+	var doc struct {
+		SchemaVersion int    `json:"schemaVersion"`
+		Period        string `json:"period"`
+		Totals        struct {
+			PRs         int `json:"prs"`
+			Authors     int `json:"authors"`
+			TimeToMerge struct {
+				P50Seconds int64 `json:"p50Seconds"`
+			} `json:"timeToMerge"`
+		} `json:"totals"`
+		Reviews *struct {
+			Unreviewed int `json:"unreviewed"`
+		} `json:"reviews"`
+		LeadTime    []any `json:"leadTime"`
+		Leaderboard []any `json:"leaderboard"`
+	}
+	if err := json.Unmarshal(out.Bytes(), &doc); err != nil {
+		t.Fatalf("output is not only the JSON document: %v\n%s", err, out.String())
+	}
+	if doc.SchemaVersion != 1 || doc.Period != "week" || doc.Totals.PRs != 3 || doc.Totals.Authors != 2 || doc.Totals.TimeToMerge.P50Seconds != 3600 {
+		t.Errorf("document = %+v", doc)
+	}
+	if doc.Reviews == nil || doc.Reviews.Unreviewed != 2 {
+		t.Errorf("reviews = %+v, want 2 unreviewed", doc.Reviews)
+	}
+	if doc.LeadTime != nil || !strings.Contains(out.String(), `"leadTime": null`) {
+		t.Errorf("lead time wasn't requested but is %v", doc.LeadTime)
+	}
+}
+
+func TestRunStatsModeJSONWithoutPRs(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "stats.json")
+	var out bytes.Buffer
+	opts := StatsOptions{List: testOptions(daysAgo(7), &fakeRunner{}), Repos: []string{"acme/widgets"}, JSONFile: path, Output: &out}
+	if err := runStatsMode(opts); err != nil {
+		t.Fatal(err)
+	}
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var doc map[string]any
+	if err := json.Unmarshal(data, &doc); err != nil {
+		t.Fatal(err)
+	}
+	if totals, _ := doc["totals"].(map[string]any); totals["prs"] != 0.0 {
+		t.Errorf("totals = %v", doc["totals"])
+	}
+	if authors, ok := doc["authors"].([]any); !ok || len(authors) != 0 {
+		t.Errorf("authors = %v, want an empty list", doc["authors"])
+	}
+}
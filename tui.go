@@ -0,0 +1,676 @@
+package main
+
+import (
+	"fmt"
+	"io/fs"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/charmbracelet/bubbles/key"
+	"github.com/charmbracelet/bubbles/list"
+	"github.com/charmbracelet/bubbles/spinner"
+	"github.com/charmbracelet/bubbles/table"
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
+)
+
+// screen is a view of the interactive TUI
+type screen int
+
+const (
+	screenMenu screen = iota
+	screenListForm
+	screenCSVPicker
+	screenCSVForm
+	screenLoading
+	screenResults
+)
+
+// Fields of the list mode form
+const (
+	fieldSince = iota
+	fieldRepo
+	fieldSearch
+	fieldLimit
+)
+
+// listHistoryKeys are the History keys for each list form field
+var listHistoryKeys = []string{
+	fieldSince:  "since",
+	fieldRepo:   "repo",
+	fieldSearch: "search",
+	fieldLimit:  "limit",
+}
+
+var (
+	titleStyle  = lipgloss.NewStyle().Bold(true).Foreground(lipgloss.Color("63"))
+	errorStyle  = lipgloss.NewStyle().Foreground(lipgloss.Color("196"))
+	statusStyle = lipgloss.NewStyle().Foreground(lipgloss.Color("42"))
+	helpStyle   = lipgloss.NewStyle().Faint(true)
+	cursorStyle = lipgloss.NewStyle().Foreground(lipgloss.Color("212"))
+)
+
+var menuItems = []string{
+	"List PRs from GitHub",
+	"Open PRs from a CSV file",
+	"Quit",
+}
+
+// csvFileItem is a previous export shown in the CSV file picker
+type csvFileItem struct {
+	path    string
+	modTime time.Time
+}
+
+func (i csvFileItem) Title() string { return filepath.Base(i.path) }
+func (i csvFileItem) Description() string {
+	return i.modTime.Format("2006-01-02 15:04") + "  " + i.path
+}
+func (i csvFileItem) FilterValue() string { return filepath.Base(i.path) }
+
+// findCSVFiles lists the CSV files under dir, most recently modified first
+func findCSVFiles(dir string) []list.Item {
+	var files []csvFileItem
+	filepath.WalkDir(dir, func(path string, d fs.DirEntry, err error) error {
+		if err != nil || d.IsDir() || !strings.EqualFold(filepath.Ext(path), ".csv") {
+			return nil
+		}
+		if info, err := d.Info(); err == nil {
+			files = append(files, csvFileItem{path: path, modTime: info.ModTime()})
+		}
+		return nil
+	})
+	sort.Slice(files, func(i, j int) bool {
+		return files[i].modTime.After(files[j].modTime)
+	})
+
+	items := make([]list.Item, len(files))
+	for i, f := range files {
+		items[i] = f
+	}
+	return items
+}
+
+// progressMsg is a line of progress output from a running fetch
+type progressMsg string
+
+// fetchDoneMsg is sent when a fetch started from the list form finishes
+type fetchDoneMsg struct {
+	prs []PR
+	err error
+}
+
+// statusMsg reports the outcome of an action on the results table
+type statusMsg struct {
+	text string
+	err  error
+}
+
+// progressWriter forwards progress output to the TUI line by line
+type progressWriter chan string
+
+func (w progressWriter) Write(p []byte) (int, error) {
+	for _, line := range strings.Split(string(p), "\n") {
+		if line = strings.TrimSpace(line); line != "" {
+			w <- line
+		}
+	}
+	return len(p), nil
+}
+
+// tuiModel is the bubbletea model for interactive mode
+type tuiModel struct {
+	screen     screen
+	menuCursor int
+	// outDir is where results are saved and previous exports are listed from; empty means the default
+	outDir string
+
+	listInputs []historyInput
+	listFocus  int
+	csvInput   historyInput
+	history    History
+	csvPicker  list.Model
+
+	spinner  spinner.Model
+	progress []string
+	progCh   progressWriter
+
+	table    table.Model
+	prs      []PR
+	selected map[int]bool
+	// listOpts is set when the results came from GitHub, so they can be saved
+	listOpts *ListOptions
+
+	status string
+	err    string
+	width  int
+	height int
+}
+
+func newTUIModel(outDir string) tuiModel {
+	prompts := []struct{ prompt, placeholder string }{
+		fieldSince:  {"Start date:  ", "YYYY-MM-DD, or relative like 7d or 4w"},
+		fieldRepo:   {"Repository:  ", "owner/repo"},
+		fieldSearch: {"Search term: ", "optional"},
+		fieldLimit:  {"Limit:       ", "optional, maximum number of PRs"},
+	}
+	history := loadHistory()
+	inputs := make([]historyInput, len(prompts))
+	for i, p := range prompts {
+		inputs[i] = newHistoryInput(p.prompt, p.placeholder, history[listHistoryKeys[i]])
+	}
+	csvInput := newHistoryInput("CSV file: ", "path to a CSV file with PR URLs", history["csv"])
+
+	picker := list.New(nil, list.NewDefaultDelegate(), 0, 0)
+	picker.Title = "Select a CSV file (/ to search, tab to type a path)"
+	picker.SetStatusBarItemName("file", "files")
+	picker.DisableQuitKeybindings()
+
+	keys := table.DefaultKeyMap()
+	// Space toggles selection instead of paging
+	keys.PageDown = key.NewBinding(key.WithKeys("f", "pgdown"), key.WithHelp("f/pgdn", "page down"))
+
+	return tuiModel{
+		outDir:     outDir,
+		listInputs: inputs,
+		csvInput:   csvInput,
+		history:    history,
+		csvPicker:  picker,
+		spinner:    spinner.New(spinner.WithSpinner(spinner.Dot)),
+		table:      table.New(table.WithFocused(true), table.WithKeyMap(keys)),
+		selected:   make(map[int]bool),
+		height:     24,
+		width:      100,
+	}
+}
+
+func (m tuiModel) Init() tea.Cmd {
+	return nil
+}
+
+func (m tuiModel) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
+	switch msg := msg.(type) {
+	case tea.WindowSizeMsg:
+		m.width, m.height = msg.Width, msg.Height
+		m.resizeTable()
+		m.csvPicker.SetSize(m.width, max(5, m.height-4))
+		return m, nil
+	case tea.KeyMsg:
+		if msg.String() == "ctrl+c" {
+			return m, tea.Quit
+		}
+	case progressMsg:
+		m.progress = append(m.progress, string(msg))
+		return m, waitForProgress(m.progCh)
+	case fetchDoneMsg:
+		if msg.err != nil {
+			m.err = fmt.Sprintf("Error getting PRs: %v", msg.err)
+			m.screen = screenListForm
+			return m, m.listInputs[m.listFocus].Focus()
+		}
+		m.showResults(msg.prs)
+		m.status = fmt.Sprintf("Fetched %d PRs", len(msg.prs))
+		return m, nil
+	case statusMsg:
+		if msg.err != nil {
+			m.err = msg.err.Error()
+		} else {
+			m.status = msg.text
+		}
+		return m, nil
+	case spinner.TickMsg:
+		if m.screen != screenLoading {
+			return m, nil
+		}
+		var cmd tea.Cmd
+		m.spinner, cmd = m.spinner.Update(msg)
+		return m, cmd
+	}
+
+	switch m.screen {
+	case screenMenu:
+		return m.updateMenu(msg)
+	case screenListForm:
+		return m.updateListForm(msg)
+	case screenCSVPicker:
+		return m.updateCSVPicker(msg)
+	case screenCSVForm:
+		return m.updateCSVForm(msg)
+	case screenResults:
+		return m.updateResults(msg)
+	}
+	return m, nil
+}
+
+func (m tuiModel) updateMenu(msg tea.Msg) (tea.Model, tea.Cmd) {
+	keyMsg, ok := msg.(tea.KeyMsg)
+	if !ok {
+		return m, nil
+	}
+	switch keyMsg.String() {
+	case "up", "k":
+		m.menuCursor = (m.menuCursor + len(menuItems) - 1) % len(menuItems)
+	case "down", "j":
+		m.menuCursor = (m.menuCursor + 1) % len(menuItems)
+	case "q", "esc":
+		return m, tea.Quit
+	case "enter":
+		m.err, m.status = "", ""
+		switch m.menuCursor {
+		case 0:
+			m.screen = screenListForm
+			m.listFocus = 0
+			return m, m.listInputs[0].Focus()
+		case 1:
+			// Offer previous exports to pick from, falling back to typing a path
+			if files := findCSVFiles(ListOptions{OutDir: m.outDir}.outputDir()); len(files) > 0 {
+				m.screen = screenCSVPicker
+				m.csvPicker.ResetFilter()
+				m.csvPicker.SetSize(m.width, max(5, m.height-4))
+				return m, m.csvPicker.SetItems(files)
+			}
+			m.screen = screenCSVForm
+			return m, m.csvInput.Focus()
+		default:
+			return m, tea.Quit
+		}
+	}
+	return m, nil
+}
+
+func (m tuiModel) updateListForm(msg tea.Msg) (tea.Model, tea.Cmd) {
+	if keyMsg, ok := msg.(tea.KeyMsg); ok {
+		switch keyMsg.String() {
+		case "esc":
+			m.listInputs[m.listFocus].Blur()
+			m.screen = screenMenu
+			return m, nil
+		case "tab":
+			return m, m.focusListInput(m.listFocus + 1)
+		case "shift+tab":
+			return m, m.focusListInput(m.listFocus - 1)
+		case "enter":
+			if m.listFocus < len(m.listInputs)-1 {
+				return m, m.focusListInput(m.listFocus + 1)
+			}
+			return m.startFetch()
+		}
+	}
+
+	var cmd tea.Cmd
+	m.listInputs[m.listFocus], cmd = m.listInputs[m.listFocus].Update(msg)
+	return m, cmd
+}
+
+// saveHistory writes the prompt history to disk, reporting failures in the status line
+func (m *tuiModel) saveHistory() {
+	if err := m.history.save(); err != nil {
+		m.err = fmt.Sprintf("Error saving input history: %v", err)
+	}
+}
+
+// focusListInput moves focus to the list form field i, wrapping around
+func (m *tuiModel) focusListInput(i int) tea.Cmd {
+	m.listInputs[m.listFocus].Blur()
+	m.listFocus = (i + len(m.listInputs)) % len(m.listInputs)
+	return m.listInputs[m.listFocus].Focus()
+}
+
+// startFetch validates the list form and starts fetching PRs in the background
+func (m tuiModel) startFetch() (tea.Model, tea.Cmd) {
+	sinceDate, err := parseSinceDate(strings.TrimSpace(m.listInputs[fieldSince].Value()))
+	if err != nil {
+		m.err = "Invalid date format. Please use YYYY-MM-DD, or relative like 7d"
+		return m, nil
+	}
+	if sinceDate.After(time.Now()) {
+		m.err = "The date cannot be in the future"
+		return m, nil
+	}
+	repo := strings.TrimSpace(m.listInputs[fieldRepo].Value())
+	if !strings.Contains(repo, "/") {
+		m.err = "Invalid repository format. Please use owner/repo"
+		return m, nil
+	}
+	limit := 0
+	if value := strings.TrimSpace(m.listInputs[fieldLimit].Value()); value != "" {
+		if limit, err = strconv.Atoi(value); err != nil || limit < 0 {
+			m.err = "Invalid limit. Please enter a number, or leave it empty"
+			return m, nil
+		}
+	}
+
+	if err := checkGHReady(); err != nil {
+		m.err = err.Error()
+		return m, nil
+	}
+
+	m.progCh = make(progressWriter, 64)
+	opts := ListOptions{
+		Since:      sinceDate,
+		Repo:       repo,
+		SearchTerm: strings.TrimSpace(m.listInputs[fieldSearch].Value()),
+		Limit:      limit,
+		OutDir:     m.outDir,
+		Progress:   m.progCh,
+	}
+	// Keep the options for saving later, without the progress channel that closes when the fetch ends
+	saved := opts
+	saved.Progress = nil
+	m.listOpts = &saved
+	m.err, m.status = "", ""
+
+	for i := range m.listInputs {
+		m.history.add(listHistoryKeys[i], strings.TrimSpace(m.listInputs[i].Value()))
+		m.listInputs[i].setEntries(m.history[listHistoryKeys[i]])
+	}
+	m.saveHistory()
+
+	m.progress = nil
+	m.screen = screenLoading
+	m.listInputs[m.listFocus].Blur()
+
+	ch := m.progCh
+	fetch := func() tea.Msg {
+		prs, err := getMergedPRs(opts)
+		close(ch)
+		return fetchDoneMsg{prs: prs, err: err}
+	}
+	return m, tea.Batch(m.spinner.Tick, fetch, waitForProgress(ch))
+}
+
+// waitForProgress waits for the next line of progress output from a fetch
+func waitForProgress(ch progressWriter) tea.Cmd {
+	return func() tea.Msg {
+		line, ok := <-ch
+		if !ok {
+			return nil
+		}
+		return progressMsg(line)
+	}
+}
+
+func (m tuiModel) updateCSVPicker(msg tea.Msg) (tea.Model, tea.Cmd) {
+	// While the search box is open, keys belong to it
+	if keyMsg, ok := msg.(tea.KeyMsg); ok && m.csvPicker.FilterState() != list.Filtering {
+		switch keyMsg.String() {
+		case "esc":
+			if m.csvPicker.IsFiltered() {
+				m.csvPicker.ResetFilter()
+				return m, nil
+			}
+			m.screen = screenMenu
+			return m, nil
+		case "q":
+			return m, tea.Quit
+		case "tab":
+			m.screen = screenCSVForm
+			return m, m.csvInput.Focus()
+		case "enter":
+			if item, ok := m.csvPicker.SelectedItem().(csvFileItem); ok {
+				return m.loadCSV(item.path)
+			}
+			return m, nil
+		}
+	}
+
+	var cmd tea.Cmd
+	m.csvPicker, cmd = m.csvPicker.Update(msg)
+	return m, cmd
+}
+
+func (m tuiModel) updateCSVForm(msg tea.Msg) (tea.Model, tea.Cmd) {
+	if keyMsg, ok := msg.(tea.KeyMsg); ok {
+		switch keyMsg.String() {
+		case "esc":
+			m.csvInput.Blur()
+			m.screen = screenMenu
+			return m, nil
+		case "enter":
+			csvFile := strings.TrimSpace(m.csvInput.Value())
+			model, cmd := m.loadCSV(csvFile)
+			if loaded := model.(tuiModel); loaded.screen == screenResults {
+				loaded.history.add("csv", csvFile)
+				loaded.csvInput.setEntries(loaded.history["csv"])
+				loaded.saveHistory()
+				return loaded, cmd
+			}
+			return model, cmd
+		}
+	}
+
+	var cmd tea.Cmd
+	m.csvInput, cmd = m.csvInput.Update(msg)
+	return m, cmd
+}
+
+// loadCSV loads the PR URLs from a CSV file into the results table
+func (m tuiModel) loadCSV(csvFile string) (tea.Model, tea.Cmd) {
+	prURLs, err := ParsePRURLsFromCSV(csvFile)
+	if err != nil {
+		m.err = err.Error()
+		return m, nil
+	}
+	prURLs, skipped := validatePRURLs(prURLs)
+
+	prs := make([]PR, len(prURLs))
+	for i, pr := range prURLs {
+		prs[i] = PR{Number: prNumberFromURL(pr.URL), Title: pr.Title, URL: pr.URL}
+	}
+	m.csvInput.Blur()
+	m.listOpts = nil
+	m.err = ""
+	m.showResults(prs)
+	m.status = fmt.Sprintf("Loaded %d PRs from %s", len(prs), csvFile)
+	if len(skipped) > 0 {
+		m.status += fmt.Sprintf(" (skipped %d duplicate or malformed rows)", len(skipped))
+	}
+	return m, nil
+}
+
+// prNumberFromURL returns the PR number from a validated PR URL
+func prNumberFromURL(prURL string) string {
+	_, rest, _ := strings.Cut(prURL, "/pull/")
+	number, _, _ := strings.Cut(rest, "/")
+	return number
+}
+
+// showResults switches to the results table for prs
+func (m *tuiModel) showResults(prs []PR) {
+	m.prs = prs
+	m.selected = make(map[int]bool)
+	m.screen = screenResults
+	m.table.SetCursor(0)
+	m.resizeTable()
+	m.refreshRows()
+}
+
+// resizeTable fits the results table to the terminal, giving spare width to the title
+func (m *tuiModel) resizeTable() {
+	const fixed = 3 + 8 + 22 + 45 + 10 // selection, number, merged at, URL, and cell padding
+	m.table.SetColumns([]table.Column{
+		{Title: " ", Width: 3},
+		{Title: "#", Width: 8},
+		{Title: "Title", Width: max(20, m.width-fixed)},
+		{Title: "Merged At", Width: 22},
+		{Title: "URL", Width: 45},
+	})
+	m.table.SetHeight(max(5, m.height-8))
+}
+
+// refreshRows rebuilds the table rows, e.g. after the selection changes
+func (m *tuiModel) refreshRows() {
+	rows := make([]table.Row, len(m.prs))
+	for i, pr := range m.prs {
+		mark := ""
+		if m.selected[i] {
+			mark = "[x]"
+		}
+		rows[i] = table.Row{mark, pr.Number, pr.Title, pr.MergedAt, pr.URL}
+	}
+	m.table.SetRows(rows)
+}
+
+// selectedPRs returns the selected PRs, or the one under the cursor if none are selected
+func (m tuiModel) selectedPRs() []PR {
+	var prs []PR
+	for i, pr := range m.prs {
+		if m.selected[i] {
+			prs = append(prs, pr)
+		}
+	}
+	if len(prs) == 0 && len(m.prs) > 0 {
+		prs = append(prs, m.prs[m.table.Cursor()])
+	}
+	return prs
+}
+
+func (m tuiModel) updateResults(msg tea.Msg) (tea.Model, tea.Cmd) {
+	if keyMsg, ok := msg.(tea.KeyMsg); ok {
+		switch keyMsg.String() {
+		case "q":
+			return m, tea.Quit
+		case "esc", "n":
+			m.screen = screenMenu
+			m.err, m.status = "", ""
+			return m, nil
+		case " ", "x":
+			if len(m.prs) > 0 {
+				if i := m.table.Cursor(); m.selected[i] {
+					delete(m.selected, i)
+				} else {
+					m.selected[i] = true
+				}
+				m.refreshRows()
+			}
+			return m, nil
+		case "a":
+			// Select everything, or clear the selection if everything is already selected
+			all := len(m.selected) == len(m.prs)
+			m.selected = make(map[int]bool)
+			if !all {
+				for i := range m.prs {
+					m.selected[i] = true
+				}
+			}
+			m.refreshRows()
+			return m, nil
+		case "o":
+			m.err, m.status = "", ""
+			return m, openPRsCmd(m.selectedPRs())
+		case "s":
+			m.err, m.status = "", ""
+			if m.listOpts == nil {
+				m.err = "These PRs were loaded from a CSV file, there is nothing new to save"
+				return m, nil
+			}
+			return m, saveResultsCmd(m.prs, *m.listOpts)
+		}
+	}
+
+	var cmd tea.Cmd
+	m.table, cmd = m.table.Update(msg)
+	return m, cmd
+}
+
+// openPRsCmd opens the PRs in the browser in the background, pausing between them like open mode
+func openPRsCmd(prs []PR) tea.Cmd {
+	return func() tea.Msg {
+		for i, pr := range prs {
+			cmd, err := openCommand(OpenOptions{}, pr.URL, i == 0)
+			if err != nil {
+				return statusMsg{err: err}
+			}
+			if err := cmd.Start(); err != nil {
+				return statusMsg{err: fmt.Errorf("error opening %s: %v", pr.URL, err)}
+			}
+			if i < len(prs)-1 {
+				time.Sleep(time.Second)
+			}
+		}
+		return statusMsg{text: fmt.Sprintf("Opened %d PRs", len(prs))}
+	}
+}
+
+// saveResultsCmd saves the fetched PRs to CSV in the background
+func saveResultsCmd(prs []PR, opts ListOptions) tea.Cmd {
+	return func() tea.Msg {
+		csvFile, err := writeListResults(prs, opts)
+		if err != nil {
+			return statusMsg{err: err}
+		}
+		return statusMsg{text: fmt.Sprintf("Results saved to %s", csvFile)}
+	}
+}
+
+func (m tuiModel) View() string {
+	var b strings.Builder
+	b.WriteString(titleStyle.Render("GitHub PR Grabber"))
+	b.WriteString("\n\n")
+
+	switch m.screen {
+	case screenMenu:
+		for i, item := range menuItems {
+			if i == m.menuCursor {
+				b.WriteString(cursorStyle.Render("> " + item))
+			} else {
+				b.WriteString("  " + item)
+			}
+			b.WriteString("\n")
+		}
+		b.WriteString("\n" + helpStyle.Render("↑/↓ move • enter select • q quit"))
+
+	case screenListForm:
+		b.WriteString("Fetch merged PRs and review them before saving to CSV.\n\n")
+		for _, input := range m.listInputs {
+			b.WriteString(input.View() + "\n")
+		}
+		b.WriteString("\n" + helpStyle.Render("tab/shift+tab move • ↑/↓ history • enter next/fetch • esc back"))
+
+	case screenCSVPicker:
+		// The picker draws its own title and help
+		return m.csvPicker.View() + m.messageView() + "\n"
+
+	case screenCSVForm:
+		b.WriteString("Load PR URLs from a CSV file to open them in your browser.\n\n")
+		b.WriteString(m.csvInput.View() + "\n")
+		b.WriteString("\n" + helpStyle.Render("↑/↓ history • enter load • esc back"))
+
+	case screenLoading:
+		b.WriteString(m.spinner.View() + " Fetching PRs...\n\n")
+		// Show the most recent progress lines that fit on screen
+		start := max(0, len(m.progress)-max(1, m.height-6))
+		for _, line := range m.progress[start:] {
+			b.WriteString(helpStyle.Render(line) + "\n")
+		}
+
+	case screenResults:
+		b.WriteString(m.table.View() + "\n")
+		b.WriteString(fmt.Sprintf("%d PRs, %d selected\n", len(m.prs), len(m.selected)))
+		b.WriteString(helpStyle.Render("space select • a select all • o open selected • s save CSV • n new • q quit"))
+	}
+
+	b.WriteString(m.messageView())
+	return b.String() + "\n"
+}
+
+// messageView renders the current error or status message, if any
+func (m tuiModel) messageView() string {
+	if m.err != "" {
+		return "\n\n" + errorStyle.Render(m.err)
+	}
+	if m.status != "" {
+		return "\n\n" + statusStyle.Render(m.status)
+	}
+	return ""
+}
+
+// runInteractiveMode runs the full-screen interactive TUI
+func runInteractiveMode(outDir string) {
+	if _, err := tea.NewProgram(newTUIModel(outDir), tea.WithAltScreen()).Run(); err != nil {
+		fmt.Printf("Error running interactive mode: %v\n", err)
+	}
+}
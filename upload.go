@@ -0,0 +1,123 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"os/exec"
+	"path"
+	"path/filepath"
+	"slices"
+	"strings"
+)
+
+// uploadTarget is the object storage location -upload pushes outputs to
+type uploadTarget struct {
+	Scheme string // s3 or gs
+	Bucket string
+	Prefix string // without leading or trailing slashes; empty for the bucket root
+}
+
+// uploadCLIs is the command line tool each scheme uploads with, and where to get it
+var uploadCLIs = map[string]struct{ Command, Install string }{
+	"s3": {"aws", "https://aws.amazon.com/cli/"},
+	"gs": {"gcloud", "https://cloud.google.com/sdk/docs/install"},
+}
+
+// parseUploadTarget parses an s3://bucket/prefix or gs://bucket/prefix URL
+func parseUploadTarget(value string) (uploadTarget, error) {
+	scheme, rest, ok := strings.Cut(value, "://")
+	if _, known := uploadCLIs[scheme]; !ok || !known {
+		return uploadTarget{}, fmt.Errorf("invalid upload location %q: must start with s3:// or gs://", value)
+	}
+	bucket, prefix, _ := strings.Cut(rest, "/")
+	if bucket == "" {
+		return uploadTarget{}, fmt.Errorf("invalid upload location %q: missing bucket name", value)
+	}
+	return uploadTarget{Scheme: scheme, Bucket: bucket, Prefix: strings.Trim(prefix, "/")}, nil
+}
+
+// String returns the target as a URL
+func (t uploadTarget) String() string {
+	return t.url("")
+}
+
+// url returns the URL of name under the target's prefix
+func (t uploadTarget) url(name string) string {
+	return t.Scheme + "://" + path.Join(t.Bucket, t.Prefix, name)
+}
+
+// checkUploadReady verifies that the target's CLI is installed, so a
+// scheduled run fails before fetching rather than after. Credentials are
+// left to the CLI, which reads them from its usual config and environment.
+func checkUploadReady(t uploadTarget) error {
+	cli := uploadCLIs[t.Scheme]
+	if _, err := exec.LookPath(cli.Command); err != nil {
+		return fmt.Errorf("uploading to %s:// needs the %s CLI, which was not found in PATH; install it from %s", t.Scheme, cli.Command, cli.Install)
+	}
+	return nil
+}
+
+// uploadCommand returns the command that uploads a local file or directory
+// to the target, keeping its base name. Directories are synced, so uploading
+// the same one again only sends what changed.
+func uploadCommand(t uploadTarget, local string, dir bool) *exec.Cmd {
+	dest := t.url(filepath.Base(local))
+	switch {
+	case t.Scheme == "s3" && dir:
+		return exec.Command("aws", "s3", "sync", local, dest)
+	case t.Scheme == "s3":
+		return exec.Command("aws", "s3", "cp", local, dest)
+	case dir:
+		return exec.Command("gcloud", "storage", "rsync", "--recursive", local, dest)
+	default:
+		return exec.Command("gcloud", "storage", "cp", local, dest)
+	}
+}
+
+// runUpload runs an upload command; tests replace it to capture commands
+var runUpload = func(cmd *exec.Cmd) ([]byte, error) {
+	return cmd.CombinedOutput()
+}
+
+// savedOutputs is the files and directories the run saved, in order, for -upload
+var savedOutputs []string
+
+// addOutput records a file or directory the run saved, for -upload. A path
+// saved more than once, like a CSV appended to in watch mode, is uploaded once.
+func addOutput(path string) {
+	if !slices.Contains(savedOutputs, path) {
+		savedOutputs = append(savedOutputs, path)
+	}
+}
+
+// uploadOutputs uploads each of paths, files or directories, to the target.
+// A failed upload doesn't stop the others; the error reports how many failed.
+func uploadOutputs(t uploadTarget, paths []string) error {
+	failed := 0
+	for _, local := range paths {
+		info, err := os.Stat(local)
+		if err != nil {
+			warnf("Warning: Error uploading %s: %v\n", local, err)
+			events.Error("upload_failed", "file", local, "target", t.String(), "error", err.Error())
+			failed++
+			continue
+		}
+		cmd := uploadCommand(t, local, info.IsDir())
+		if output, err := runUpload(cmd); err != nil {
+			msg := strings.TrimSpace(string(output))
+			if msg == "" {
+				msg = err.Error()
+			}
+			warnf("Warning: Error uploading %s: %s\n", local, msg)
+			events.Error("upload_failed", "file", local, "target", t.String(), "error", msg)
+			failed++
+			continue
+		}
+		fmt.Printf("Uploaded %s to %s\n", local, t.url(filepath.Base(local)))
+		events.Info("uploaded", "file", local, "target", t.url(filepath.Base(local)))
+	}
+	if failed > 0 {
+		return fmt.Errorf("%d of %d uploads to %s failed", failed, len(paths), t)
+	}
+	return nil
+}
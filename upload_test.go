@@ -0,0 +1,88 @@
+package main
+
+import (
+	"errors"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestParseUploadTarget(t *testing.T) {
+	tests := map[string]uploadTarget{
+		"s3://reports":                 {Scheme: "s3", Bucket: "reports"},
+		"s3://reports/prs/weekly/":     {Scheme: "s3", Bucket: "reports", Prefix: "prs/weekly"},
+		"gs://acme-metrics/github-prs": {Scheme: "gs", Bucket: "acme-metrics", Prefix: "github-prs"},
+	}
+	for value, want := range tests {
+		if got, err := parseUploadTarget(value); err != nil || got != want {
+			t.Errorf("parseUploadTarget(%q) = %+v, %v, want %+v", value, got, err, want)
+		}
+	}
+	for _, value := range []string{"reports", "azure://reports", "s3://", "s3:///prs"} {
+		if _, err := parseUploadTarget(value); err == nil {
+			t.Errorf("parseUploadTarget(%q) succeeded, want an error", value)
+		}
+	}
+}
+
+func TestUploadCommand(t *testing.T) {
+	s3 := uploadTarget{Scheme: "s3", Bucket: "reports", Prefix: "prs"}
+	gs := uploadTarget{Scheme: "gs", Bucket: "reports"}
+	tests := []struct {
+		target uploadTarget
+		local  string
+		dir    bool
+		want   string
+	}{
+		{s3, "generated/csv/stats.csv", false, "aws s3 cp generated/csv/stats.csv s3://reports/prs/stats.csv"},
+		{s3, "fixtures/", true, "aws s3 sync fixtures/ s3://reports/prs/fixtures"},
+		{gs, "report.html", false, "gcloud storage cp report.html gs://reports/report.html"},
+		{gs, "charts", true, "gcloud storage rsync --recursive charts gs://reports/charts"},
+	}
+	for _, tt := range tests {
+		if got := strings.Join(uploadCommand(tt.target, tt.local, tt.dir).Args, " "); got != tt.want {
+			t.Errorf("uploadCommand(%s, %s) = %s, want %s", tt.target, tt.local, got, tt.want)
+		}
+	}
+}
+
+func TestUploadOutputs(t *testing.T) {
+	dir := t.TempDir()
+	table := filepath.Join(dir, "stats.csv")
+	savedOutputs = nil
+	defer func() { savedOutputs = nil }()
+	if err := saveStatsTable(os.Stdout, table, Table{Header: []string{"Author"}}); err != nil {
+		t.Fatal(err)
+	}
+	charts := filepath.Join(dir, "charts")
+	if err := os.Mkdir(charts, 0755); err != nil {
+		t.Fatal(err)
+	}
+	addOutput(charts)
+	addOutput(filepath.Join(dir, "missing.csv"))
+	addOutput(table) // saved again, but uploaded once
+
+	var commands []string
+	defer func(original func(*exec.Cmd) ([]byte, error)) { runUpload = original }(runUpload)
+	runUpload = func(cmd *exec.Cmd) ([]byte, error) {
+		commands = append(commands, strings.Join(cmd.Args, " "))
+		if cmd.Args[2] == "sync" {
+			return []byte("upload failed: AccessDenied\n"), errors.New("exit status 1")
+		}
+		return nil, nil
+	}
+
+	err := uploadOutputs(uploadTarget{Scheme: "s3", Bucket: "reports"}, savedOutputs)
+	want := []string{
+		"aws s3 cp " + table + " s3://reports/stats.csv",
+		"aws s3 sync " + charts + " s3://reports/charts",
+	}
+	if strings.Join(commands, "\n") != strings.Join(want, "\n") {
+		t.Errorf("ran:\n%s\nwant:\n%s", strings.Join(commands, "\n"), strings.Join(want, "\n"))
+	}
+	if err == nil || !strings.Contains(err.Error(), "2 of 3 uploads") {
+		t.Errorf("err = %v, want the failed sync and missing file reported", err)
+	}
+}
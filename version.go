@@ -0,0 +1,73 @@
+package main
+
+import (
+	"fmt"
+	"runtime"
+	"runtime/debug"
+	"strings"
+)
+
+// Build information, set at build time with:
+//
+//	go build -ldflags "-X main.version=v1.2.3 -X main.commit=$(git rev-parse --short HEAD) -X main.buildDate=$(date -u +%Y-%m-%d)"
+var (
+	version   = "dev"
+	commit    = ""
+	buildDate = ""
+)
+
+// vcsSetting returns a VCS setting Go embedded in the binary, or "" if there isn't one
+func vcsSetting(key string) string {
+	if info, ok := debug.ReadBuildInfo(); ok {
+		for _, setting := range info.Settings {
+			if setting.Key == key {
+				return setting.Value
+			}
+		}
+	}
+	return ""
+}
+
+// buildCommit returns the commit the binary was built from, falling back to
+// the VCS information Go embeds when it wasn't set with -ldflags
+func buildCommit() string {
+	if commit != "" {
+		return commit
+	}
+	if revision := vcsSetting("vcs.revision"); len(revision) >= 7 {
+		return revision[:7]
+	}
+	return "unknown"
+}
+
+// buildTime returns when the binary was built, falling back to the commit time
+// Go embeds when it wasn't set with -ldflags
+func buildTime() string {
+	if buildDate != "" {
+		return buildDate
+	}
+	if commitTime := vcsSetting("vcs.time"); commitTime != "" {
+		return commitTime + " (commit time)"
+	}
+	return "unknown"
+}
+
+// ghVersion returns the installed GitHub CLI version, or a note if it can't be run
+func ghVersion() string {
+	output, err := runGHCommand("--version")
+	if err != nil {
+		return "not found"
+	}
+	// The first line looks like "gh version 2.40.1 (2023-12-13)"
+	firstLine, _, _ := strings.Cut(output, "\n")
+	return strings.TrimPrefix(firstLine, "gh version ")
+}
+
+// printVersion prints the build information and detected tool versions
+func printVersion() {
+	fmt.Printf("github-pr-grabber %s\n", version)
+	fmt.Printf("  commit:     %s\n", buildCommit())
+	fmt.Printf("  built:      %s\n", buildTime())
+	fmt.Printf("  go:         %s %s/%s\n", runtime.Version(), runtime.GOOS, runtime.GOARCH)
+	fmt.Printf("  gh:         %s\n", ghVersion())
+}
@@ -0,0 +1,324 @@
+package main
+
+import (
+	"context"
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"os/signal"
+	"path/filepath"
+	"slices"
+	"sort"
+	"strings"
+	"syscall"
+	"time"
+)
+
+// WatchOptions holds the parameters for watch mode
+type WatchOptions struct {
+	// List holds the search settings shared by every repo. Its Repo is ignored,
+	// and its Since is where the first poll starts; zero means only PRs merged
+	// after watching starts are reported.
+	List  ListOptions
+	Repos []string
+	// Interval is the time between polls
+	Interval time.Duration
+	// MetricsAddr serves /metrics on this address if set
+	MetricsAddr string
+	// OnNew is called with each repo's newly merged PRs, oldest first, after
+	// they have been saved
+	OnNew func(repo string, prs []PR)
+	// Output is where a dry run prints its plan, os.Stdout if nil
+	Output io.Writer
+}
+
+// watchState is what watch mode remembers about a repo between polls, saved
+// next to its output file so restarts pick up where they left off
+type watchState struct {
+	// Since is where the next poll's search starts
+	Since time.Time `json:"since"`
+	// Seen maps the URLs of PRs already reported that were merged on or after
+	// Since to when they were merged
+	Seen map[string]string `json:"seen"`
+}
+
+// watchOutputFile returns the CSV file new PRs for repo are appended to
+func watchOutputFile(opts ListOptions, repo string) string {
+	return filepath.Join(opts.outputDir(), sanitizeFilename("watch_"+repo)+".csv")
+}
+
+// watchStatePath returns the sidecar state file for a watch output file
+func watchStatePath(outputFile string) string {
+	return outputFile + ".watch-state.json"
+}
+
+// loadWatchState reads the saved state for a watch output file, returning nil if there is none
+func loadWatchState(outputFile string) (*watchState, error) {
+	data, err := os.ReadFile(watchStatePath(outputFile))
+	if os.IsNotExist(err) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("error reading watch state: %v", err)
+	}
+	var state watchState
+	if err := json.Unmarshal(data, &state); err != nil {
+		return nil, fmt.Errorf("error parsing watch state %s: %v", watchStatePath(outputFile), err)
+	}
+	return &state, nil
+}
+
+// saveWatchState records what has been reported for a watch output file
+func saveWatchState(outputFile string, state watchState) error {
+	data, err := json.Marshal(state)
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(watchStatePath(outputFile), data, 0644)
+}
+
+// appendToCSV appends PRs to a CSV file, writing the header first if the file
+// is new. A file with a different header, such as one written with other
+// -fields, is left alone, so rows don't end up under the wrong columns.
+func appendToCSV(prs []PR, opts ListOptions, outputFile string) error {
+	table := buildTable(prs, opts)
+	header, err := checkCSVHeader(opts, outputFile)
+	if err != nil {
+		return err
+	}
+
+	file, err := os.OpenFile(outputFile, os.O_WRONLY|os.O_APPEND|os.O_CREATE, 0644)
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+
+	writer := csv.NewWriter(file)
+	if header == nil {
+		if err := writer.Write(table.Header); err != nil {
+			return err
+		}
+	}
+	if err := writer.WriteAll(table.Rows); err != nil {
+		return err
+	}
+	return file.Sync()
+}
+
+// checkCSVHeader returns the header of an existing CSV file, or nil if there
+// isn't one yet, failing if it doesn't match the columns opts writes
+func checkCSVHeader(opts ListOptions, outputFile string) ([]string, error) {
+	header, err := readCSVHeader(outputFile)
+	if err != nil {
+		return nil, err
+	}
+	want := buildTable(nil, opts).Header
+	if header != nil && !slices.Equal(header, want) {
+		return nil, fmt.Errorf("%s has the columns %s, but this run writes %s; move the file aside or use the same -fields and -ttm-unit",
+			outputFile, strings.Join(header, ","), strings.Join(want, ","))
+	}
+	return header, nil
+}
+
+// readCSVHeader returns the first row of a CSV file, or nil if the file
+// doesn't exist or is empty
+func readCSVHeader(path string) ([]string, error) {
+	file, err := os.Open(path)
+	if os.IsNotExist(err) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+	header, err := csv.NewReader(file).Read()
+	if err == io.EOF {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("error reading the header of %s: %v", path, err)
+	}
+	return header, nil
+}
+
+// pollWindow returns where a poll's search starts. Searches are by merge date,
+// so each poll searches from midnight yesterday and relies on the saved state
+// for the PRs already reported. Going back a day covers PRs merged just before
+// midnight and ones the search index was slow to pick up.
+func pollWindow(opts ListOptions) time.Time {
+	now := opts.until().UTC()
+	return time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, time.UTC).AddDate(0, 0, -1)
+}
+
+// pollRepo fetches a repo's PRs merged since the last poll and appends the ones
+// not reported before, returning them oldest first
+func pollRepo(opts WatchOptions, repo string) ([]PR, error) {
+	listOpts := opts.List
+	listOpts.Repo = repo
+	outputFile := watchOutputFile(listOpts, repo)
+
+	state, err := loadWatchState(outputFile)
+	if err != nil {
+		return nil, err
+	}
+	// Without saved state or -since, the first poll only records what has
+	// already been merged, so it isn't reported as new
+	baseline := state == nil && listOpts.Since.IsZero()
+	if state == nil {
+		state = &watchState{Since: listOpts.Since}
+	}
+
+	window := pollWindow(listOpts)
+	if state.Since.IsZero() {
+		state.Since = window
+	}
+	listOpts.Since = state.Since
+
+	prs, err := getMergedPRs(listOpts)
+	if err != nil {
+		return nil, err
+	}
+
+	var newPRs []PR
+	for _, pr := range prs {
+		if _, ok := state.Seen[pr.URL]; !ok {
+			newPRs = append(newPRs, pr)
+		}
+	}
+	sort.SliceStable(newPRs, func(i, j int) bool { return newPRs[i].MergedAt < newPRs[j].MergedAt })
+
+	if baseline {
+		fmt.Printf("Watching %s: %d PRs merged since yesterday will not be reported\n", repo, len(newPRs))
+		newPRs = nil
+	} else if len(newPRs) > 0 {
+		if err := appendToCSV(newPRs, listOpts, outputFile); err != nil {
+			return nil, fmt.Errorf("error saving new PRs: %v", err)
+		}
+	}
+
+	// Only the PRs the next poll's search will return need remembering. Earlier
+	// entries are kept too, so a poll that failed to fetch some chunks doesn't
+	// cause their PRs to be reported again.
+	next := watchState{Since: window, Seen: make(map[string]string)}
+	for url, mergedAt := range state.Seen {
+		next.Seen[url] = mergedAt
+	}
+	for _, pr := range prs {
+		next.Seen[pr.URL] = pr.MergedAt
+	}
+	for url, mergedAt := range next.Seen {
+		if merged, err := time.Parse(time.RFC3339, mergedAt); err != nil || merged.Before(window) {
+			delete(next.Seen, url)
+		}
+	}
+	if err := saveWatchState(outputFile, next); err != nil {
+		return nil, fmt.Errorf("error saving watch state: %v", err)
+	}
+	return newPRs, nil
+}
+
+// planWatch prints what watch mode would poll and write, without running gh
+// or touching the output and state files
+func planWatch(opts WatchOptions) error {
+	w := opts.Output
+	if w == nil {
+		w = os.Stdout
+	}
+	fmt.Fprintf(w, "Dry run: would poll %s every %s\n", strings.Join(opts.Repos, ", "), opts.Interval)
+	for _, repo := range opts.Repos {
+		listOpts := opts.List
+		listOpts.Repo = repo
+		listOpts.Progress = w
+		outputFile := watchOutputFile(listOpts, repo)
+		if _, err := checkCSVHeader(listOpts, outputFile); err != nil {
+			return err
+		}
+		state, err := loadWatchState(outputFile)
+		if err != nil {
+			return err
+		}
+		if state != nil {
+			listOpts.Since = state.Since
+		} else if listOpts.Since.IsZero() {
+			fmt.Fprintf(w, "The first poll of %s would only record the PRs already merged\n", repo)
+		}
+		if listOpts.Since.IsZero() {
+			listOpts.Since = pollWindow(listOpts)
+		}
+		planMergedPRs(listOpts)
+		fmt.Fprintf(w, "Would append new PRs to %s and save state to %s\n", outputFile, watchStatePath(outputFile))
+	}
+	if opts.MetricsAddr != "" {
+		fmt.Fprintf(w, "Would serve metrics on %s/metrics\n", opts.MetricsAddr)
+	}
+	return nil
+}
+
+// runWatchMode polls the repos every opts.Interval until interrupted or sent
+// SIGTERM. A dry run only prints the plan.
+func runWatchMode(opts WatchOptions) error {
+	if opts.List.DryRun {
+		return planWatch(opts)
+	}
+	if err := prepareOutputDir(opts.List.outputDir()); err != nil {
+		return err
+	}
+
+	// Fail now rather than on every poll if a restart changed the columns
+	for _, repo := range opts.Repos {
+		listOpts := opts.List
+		listOpts.Repo = repo
+		if _, err := checkCSVHeader(listOpts, watchOutputFile(listOpts, repo)); err != nil {
+			return err
+		}
+	}
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+
+	if opts.MetricsAddr != "" {
+		mux := http.NewServeMux()
+		mux.HandleFunc("GET /metrics", metricsHandler(opts.List.Runner))
+		server := &http.Server{Addr: opts.MetricsAddr, Handler: mux, ReadHeaderTimeout: 10 * time.Second}
+		go func() {
+			if err := server.ListenAndServe(); !errors.Is(err, http.ErrServerClosed) {
+				warnf("Warning: Error serving metrics: %v\n", err)
+				events.Error("metrics_failed", "error", err.Error())
+			}
+		}()
+		defer server.Close()
+		fmt.Printf("Serving metrics on %s/metrics\n", opts.MetricsAddr)
+	}
+
+	for {
+		for _, repo := range opts.Repos {
+			newPRs, err := pollRepo(opts, repo)
+			if err != nil {
+				fmt.Printf("Warning: Error polling %s: %v\n", repo, err)
+				events.Error("poll_failed", "repo", repo, "error", err.Error())
+				continue
+			}
+			for _, pr := range newPRs {
+				fmt.Printf("New merged PR in %s: #%s %s %s\n", repo, pr.Number, pr.Title, pr.URL)
+				events.Info("pr_merged", "repo", repo, "number", pr.Number, "url", pr.URL)
+			}
+			if len(newPRs) > 0 {
+				fmt.Printf("Appended %d PRs to %s\n", len(newPRs), watchOutputFile(opts.List, repo))
+				if opts.OnNew != nil {
+					opts.OnNew(repo, newPRs)
+				}
+			}
+		}
+
+		select {
+		case <-ctx.Done():
+			fmt.Println("Stopped watching.")
+			return nil
+		case <-time.After(opts.Interval):
+		}
+	}
+}
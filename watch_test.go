@@ -0,0 +1,142 @@
+package main
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestPollRepoReportsOnlyNewPRs(t *testing.T) {
+	now := time.Now().UTC()
+	today := time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, time.UTC)
+	runner := &fakeRunner{prs: makePRs(today, time.Second, 2)}
+	opts := WatchOptions{List: testOptions(time.Time{}, runner)}
+	opts.List.OutDir = t.TempDir()
+
+	// The first poll only records what was already merged
+	newPRs, err := pollRepo(opts, "acme/widgets")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(newPRs) != 0 {
+		t.Fatalf("first poll reported %d PRs, want none", len(newPRs))
+	}
+
+	runner.prs = makePRs(today, time.Second, 4)
+	newPRs, err = pollRepo(opts, "acme/widgets")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(newPRs) != 2 || newPRs[0].Number != "3" || newPRs[1].Number != "4" {
+		t.Fatalf("second poll reported %+v, want PRs 3 and 4", newPRs)
+	}
+
+	newPRs, err = pollRepo(opts, "acme/widgets")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(newPRs) != 0 {
+		t.Errorf("third poll reported %d PRs, want none", len(newPRs))
+	}
+
+	data, err := os.ReadFile(watchOutputFile(opts.List, "acme/widgets"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	lines := strings.Split(strings.TrimSpace(string(data)), "\n")
+	if len(lines) != 3 || !strings.HasPrefix(lines[0], "PR Number,") || !strings.HasPrefix(lines[1], "3,") || !strings.HasPrefix(lines[2], "4,") {
+		t.Errorf("output file has lines %q, want the header and PRs 3 and 4", lines)
+	}
+}
+
+func TestPollRepoWithSinceReportsEverything(t *testing.T) {
+	since := daysAgo(3)
+	runner := &fakeRunner{prs: makePRs(since.Add(time.Hour), 24*time.Hour, 3)}
+	opts := WatchOptions{List: testOptions(since, runner)}
+	opts.List.OutDir = t.TempDir()
+
+	newPRs, err := pollRepo(opts, "acme/widgets")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(newPRs) != 3 {
+		t.Fatalf("first poll with since reported %d PRs, want all 3", len(newPRs))
+	}
+
+	// Later polls only search from yesterday
+	newPRs, err = pollRepo(opts, "acme/widgets")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(newPRs) != 0 {
+		t.Errorf("second poll reported %d PRs, want none", len(newPRs))
+	}
+	if last := runner.queries[len(runner.queries)-1]; !strings.HasPrefix(last, "merged:"+daysAgo(1).Format("2006-01-02")) {
+		t.Errorf("second poll searched %q, want to start from yesterday", last)
+	}
+}
+
+func TestAppendToCSVRefusesDifferentHeader(t *testing.T) {
+	opts := testOptions(time.Time{}, nil)
+	opts.OutDir = t.TempDir()
+	outputFile := watchOutputFile(opts, "acme/widgets")
+	prs := makePRs(daysAgo(1), time.Hour, 2)
+
+	if err := appendToCSV(prs[:1], opts, outputFile); err != nil {
+		t.Fatal(err)
+	}
+	if err := appendToCSV(prs[1:], opts, outputFile); err != nil {
+		t.Fatalf("appending with the same columns: %v", err)
+	}
+
+	// A restart with another -fields set would misalign the rows
+	opts.Fields = []string{"author"}
+	err := appendToCSV(prs, opts, outputFile)
+	if err == nil || !strings.Contains(err.Error(), "move the file aside") {
+		t.Errorf("err = %v, want the header mismatch reported", err)
+	}
+	if err := runWatchMode(WatchOptions{List: opts, Repos: []string{"acme/widgets"}}); err == nil {
+		t.Error("runWatchMode started with a mismatched output file")
+	}
+
+	data, err := os.ReadFile(outputFile)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if lines := strings.Split(strings.TrimSpace(string(data)), "\n"); len(lines) != 3 {
+		t.Errorf("output file has lines %q, want the header and 2 PRs", lines)
+	}
+}
+
+func TestRunWatchModeDryRun(t *testing.T) {
+	runner := &fakeRunner{prs: makePRs(daysAgo(1), time.Hour, 2)}
+	var out bytes.Buffer
+	opts := WatchOptions{List: testOptions(time.Time{}, runner), Repos: []string{"acme/widgets"}, Interval: 15 * time.Minute, Output: &out}
+	opts.List.OutDir = filepath.Join(t.TempDir(), "watch")
+	opts.List.DryRun = true
+
+	if err := runWatchMode(opts); err != nil {
+		t.Fatal(err)
+	}
+	if runner.calls != 0 {
+		t.Errorf("dry run ran %d commands", runner.calls)
+	}
+	outputFile := watchOutputFile(opts.List, "acme/widgets")
+	for _, want := range []string{
+		"would poll acme/widgets every 15m0s",
+		"would only record the PRs already merged",
+		"gh pr list --repo acme/widgets",
+		"merged:" + pollWindow(opts.List).Format("2006-01-02"),
+		"Would append new PRs to " + outputFile + " and save state to " + watchStatePath(outputFile),
+	} {
+		if !strings.Contains(out.String(), want) {
+			t.Errorf("plan is missing %q:\n%s", want, out.String())
+		}
+	}
+	if _, err := os.Stat(opts.List.OutDir); !os.IsNotExist(err) {
+		t.Errorf("dry run created the output directory: %v", err)
+	}
+}
@@ -0,0 +1,237 @@
+package main
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"path/filepath"
+	"slices"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// WebhookOptions holds the parameters for webhook mode
+type WebhookOptions struct {
+	// Addr is the address to listen on, such as :8080
+	Addr string
+	// Secret is the webhook secret configured on GitHub, used to verify deliveries
+	Secret string
+	// List holds the output settings; Repo comes from each delivery
+	List ListOptions
+	// OnNew is called with each merged PR after it has been saved
+	OnNew func(repo string, prs []PR)
+}
+
+// pullRequestEvent is the part of a GitHub pull_request webhook payload that's used
+type pullRequestEvent struct {
+	Action      string `json:"action"`
+	PullRequest struct {
+		Number         int    `json:"number"`
+		Title          string `json:"title"`
+		HTMLURL        string `json:"html_url"`
+		Merged         bool   `json:"merged"`
+		MergedAt       string `json:"merged_at"`
+		CreatedAt      string `json:"created_at"`
+		Additions      int    `json:"additions"`
+		Deletions      int    `json:"deletions"`
+		Comments       int    `json:"comments"`
+		ReviewComments int    `json:"review_comments"`
+		Body           string `json:"body"`
+		Head           struct {
+			Ref string `json:"ref"`
+		} `json:"head"`
+		User struct {
+			Login string `json:"login"`
+		} `json:"user"`
+		Labels []struct {
+			Name string `json:"name"`
+		} `json:"labels"`
+	} `json:"pull_request"`
+	Repository struct {
+		FullName string `json:"full_name"`
+	} `json:"repository"`
+}
+
+// validSignature reports whether signature, the X-Hub-Signature-256 header,
+// is the HMAC-SHA256 of body with secret
+func validSignature(secret string, body []byte, signature string) bool {
+	digest, ok := strings.CutPrefix(signature, "sha256=")
+	if !ok {
+		return false
+	}
+	got, err := hex.DecodeString(digest)
+	if err != nil {
+		return false
+	}
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write(body)
+	return hmac.Equal(got, mac.Sum(nil))
+}
+
+// webhookReceiver appends merged PRs from webhook deliveries to the same
+// per-repo files watch mode writes
+type webhookReceiver struct {
+	opts WebhookOptions
+
+	mu   sync.Mutex      // serializes writes to the output files
+	seen map[string]bool // PR URLs saved since startup, as GitHub may redeliver
+
+	notifying sync.WaitGroup // OnNew calls still running
+}
+
+// fieldsNeedingGH is the fields that need more than the webhook payload
+// has, which webhook mode would leave empty
+var fieldsNeedingGH = []string{"firstCommit", "cycleTime"}
+
+// checkWebhookFields rejects -fields that webhook mode can't fill in, since
+// it builds each PR from the delivery alone without calling gh
+func checkWebhookFields(fields []string) error {
+	for _, field := range fields {
+		if slices.Contains(fieldsNeedingGH, field) {
+			return fmt.Errorf("the %s field needs gh, which webhook mode doesn't call; use list or watch mode for it", field)
+		}
+	}
+	return nil
+}
+
+// newWebhookMux returns the handler for webhook mode's endpoints
+func newWebhookMux(opts WebhookOptions) *http.ServeMux {
+	return newWebhookReceiver(opts).mux()
+}
+
+// newWebhookReceiver returns a receiver that has saved nothing yet
+func newWebhookReceiver(opts WebhookOptions) *webhookReceiver {
+	return &webhookReceiver{opts: opts, seen: make(map[string]bool)}
+}
+
+// mux returns the handler for webhook mode's endpoints
+func (receiver *webhookReceiver) mux() *http.ServeMux {
+	mux := http.NewServeMux()
+	mux.HandleFunc("POST /webhook", receiver.handle)
+	mux.HandleFunc("GET /metrics", metricsHandler(receiver.opts.List.Runner))
+	mux.HandleFunc("GET /healthz", func(w http.ResponseWriter, r *http.Request) {
+		io.WriteString(w, "ok\n")
+	})
+	return mux
+}
+
+// handle verifies a delivery and saves the PR if it's a merge
+func (wr *webhookReceiver) handle(w http.ResponseWriter, r *http.Request) {
+	body, err := io.ReadAll(io.LimitReader(r.Body, 25<<20)) // GitHub caps payloads at 25 MB
+	if err != nil {
+		http.Error(w, "error reading body", http.StatusBadRequest)
+		return
+	}
+	if !validSignature(wr.opts.Secret, body, r.Header.Get("X-Hub-Signature-256")) {
+		events.Warn("webhook_rejected", "delivery", r.Header.Get("X-GitHub-Delivery"), "reason", "bad signature")
+		http.Error(w, "invalid signature", http.StatusUnauthorized)
+		return
+	}
+
+	// Other events, including the ping sent when the webhook is created, are acknowledged and ignored
+	if r.Header.Get("X-GitHub-Event") != "pull_request" {
+		io.WriteString(w, "ignored\n")
+		return
+	}
+	var event pullRequestEvent
+	if err := json.Unmarshal(body, &event); err != nil {
+		http.Error(w, "invalid payload", http.StatusBadRequest)
+		return
+	}
+	if event.Action != "closed" || !event.PullRequest.Merged {
+		io.WriteString(w, "ignored\n")
+		return
+	}
+
+	repo := event.Repository.FullName
+	pr := PR{
+		Number:         strconv.Itoa(event.PullRequest.Number),
+		Title:          event.PullRequest.Title,
+		MergedAt:       event.PullRequest.MergedAt,
+		CreatedAt:      event.PullRequest.CreatedAt,
+		URL:            event.PullRequest.HTMLURL,
+		Additions:      event.PullRequest.Additions,
+		Deletions:      event.PullRequest.Deletions,
+		Comments:       event.PullRequest.Comments,
+		ReviewComments: event.PullRequest.ReviewComments,
+		Author:         event.PullRequest.User.Login,
+		JiraKey:        findJiraKey(wr.opts.List.JiraProjects, event.PullRequest.Title, event.PullRequest.Head.Ref, event.PullRequest.Body),
+	}
+	for _, label := range event.PullRequest.Labels {
+		pr.Labels = append(pr.Labels, label.Name)
+	}
+	if !wr.opts.List.matchesSize(pr) {
+		io.WriteString(w, "ignored\n")
+		return
+	}
+	saved, err := wr.save(repo, pr)
+	if err != nil {
+		events.Error("webhook_save_failed", "repo", repo, "number", pr.Number, "error", err.Error())
+		http.Error(w, "error saving PR", http.StatusInternalServerError)
+		return
+	}
+	if !saved {
+		io.WriteString(w, "already saved\n")
+		return
+	}
+
+	fmt.Printf("Merged PR in %s: #%s %s %s\n", repo, pr.Number, pr.Title, pr.URL)
+	events.Info("pr_merged", "repo", repo, "number", pr.Number, "url", pr.URL)
+	io.WriteString(w, "saved\n")
+
+	// GitHub redelivers if the response takes over 10 seconds, so slow
+	// notifiers run after it has been sent
+	if wr.opts.OnNew != nil {
+		wr.notifying.Add(1)
+		go func() {
+			defer wr.notifying.Done()
+			wr.opts.OnNew(repo, []PR{pr})
+		}()
+	}
+}
+
+// save appends the PR to its repo's output file, reporting false if it was already saved
+func (wr *webhookReceiver) save(repo string, pr PR) (bool, error) {
+	wr.mu.Lock()
+	defer wr.mu.Unlock()
+	if wr.seen[pr.URL] {
+		return false, nil
+	}
+
+	opts := wr.opts.List
+	opts.Repo = repo
+	if err := appendToCSV([]PR{pr}, opts, watchOutputFile(opts, repo)); err != nil {
+		return false, err
+	}
+	wr.seen[pr.URL] = true
+	return true, nil
+}
+
+// runWebhookMode receives webhooks until interrupted or sent SIGTERM, then
+// waits for notifications still being sent. A dry run only prints where it
+// would listen and write.
+func runWebhookMode(opts WebhookOptions) error {
+	if opts.List.DryRun {
+		fmt.Printf("Dry run: would receive GitHub webhooks on %s/webhook and append merged PRs to %s\n", opts.Addr, filepath.Join(opts.List.outputDir(), "watch_<owner>_<repo>.csv"))
+		return nil
+	}
+	if err := prepareOutputDir(opts.List.outputDir()); err != nil {
+		return err
+	}
+
+	receiver := newWebhookReceiver(opts)
+	fmt.Printf("Receiving GitHub webhooks on %s/webhook\n", opts.Addr)
+	err := listenUntilStopped(&http.Server{
+		Addr:              opts.Addr,
+		Handler:           receiver.mux(),
+		ReadHeaderTimeout: 10 * time.Second,
+	})
+	receiver.notifying.Wait()
+	return err
+}
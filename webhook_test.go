@@ -0,0 +1,161 @@
+package main
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+const testPayload = `{
+  "action": "closed",
+  "pull_request": {
+    "number": 12,
+    "title": "Add retries",
+    "html_url": "https://github.com/acme/widgets/pull/12",
+    "merged": true,
+    "merged_at": "2024-03-05T12:00:00Z",
+    "created_at": "2024-03-05T10:00:00Z",
+    "additions": 30,
+    "deletions": 5,
+    "head": {"ref": "feature/OPS-3-retries"}
+  },
+  "repository": {"full_name": "acme/widgets"}
+}`
+
+// sign returns the X-Hub-Signature-256 header GitHub would send for body
+func sign(secret, body string) string {
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write([]byte(body))
+	return "sha256=" + hex.EncodeToString(mac.Sum(nil))
+}
+
+func TestWebhookSavesMergedPRs(t *testing.T) {
+	opts := WebhookOptions{Secret: "s3cret", List: ListOptions{OutDir: t.TempDir()}}
+	server := httptest.NewServer(newWebhookMux(opts))
+	defer server.Close()
+
+	deliver := func(event, payload, signature string) (int, string) {
+		t.Helper()
+		req, _ := http.NewRequest("POST", server.URL+"/webhook", strings.NewReader(payload))
+		req.Header.Set("X-GitHub-Event", event)
+		req.Header.Set("X-Hub-Signature-256", signature)
+		resp, err := http.DefaultClient.Do(req)
+		if err != nil {
+			t.Fatal(err)
+		}
+		defer resp.Body.Close()
+		body, err := io.ReadAll(resp.Body)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return resp.StatusCode, strings.TrimSpace(string(body))
+	}
+
+	if status, _ := deliver("pull_request", testPayload, sign("wrong", testPayload)); status != http.StatusUnauthorized {
+		t.Errorf("badly signed delivery got status %d, want 401", status)
+	}
+	if _, body := deliver("ping", `{}`, sign("s3cret", `{}`)); body != "ignored" {
+		t.Errorf("ping got %q, want ignored", body)
+	}
+	unmerged := strings.Replace(testPayload, `"merged": true`, `"merged": false`, 1)
+	if _, body := deliver("pull_request", unmerged, sign("s3cret", unmerged)); body != "ignored" {
+		t.Errorf("closed unmerged PR got %q, want ignored", body)
+	}
+	if _, body := deliver("pull_request", testPayload, sign("s3cret", testPayload)); body != "saved" {
+		t.Errorf("merged PR got %q, want saved", body)
+	}
+	if _, body := deliver("pull_request", testPayload, sign("s3cret", testPayload)); body != "already saved" {
+		t.Errorf("redelivered PR got %q, want already saved", body)
+	}
+
+	data, err := os.ReadFile(watchOutputFile(opts.List, "acme/widgets"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := "PR Number,Title,Merged At,URL,Time To Merge (hours)\n12,Add retries,2024-03-05T12:00:00Z,https://github.com/acme/widgets/pull/12,2.00\n"
+	if string(data) != want {
+		t.Errorf("output file:\n%s\nwant:\n%s", data, want)
+	}
+}
+
+func TestWebhookFindsJiraKey(t *testing.T) {
+	opts := WebhookOptions{Secret: "s3cret", List: ListOptions{OutDir: t.TempDir(), Fields: []string{"jira"}, JiraURL: "https://acme.atlassian.net"}}
+	server := httptest.NewServer(newWebhookMux(opts))
+	defer server.Close()
+
+	req, _ := http.NewRequest("POST", server.URL+"/webhook", strings.NewReader(testPayload))
+	req.Header.Set("X-GitHub-Event", "pull_request")
+	req.Header.Set("X-Hub-Signature-256", sign("s3cret", testPayload))
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		t.Fatal(err)
+	}
+	resp.Body.Close()
+
+	data, err := os.ReadFile(watchOutputFile(opts.List, "acme/widgets"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(string(data), ",OPS-3,https://acme.atlassian.net/browse/OPS-3\n") {
+		t.Errorf("output file:\n%s\nwant the key from the branch name", data)
+	}
+}
+
+func TestWebhookRespondsBeforeNotifying(t *testing.T) {
+	release := make(chan struct{})
+	notified := make(chan string, 1)
+	opts := WebhookOptions{Secret: "s3cret", List: ListOptions{OutDir: t.TempDir()}, OnNew: func(repo string, prs []PR) {
+		<-release // a notifier slower than GitHub's delivery timeout
+		notified <- repo + "#" + prs[0].Number
+	}}
+	receiver := newWebhookReceiver(opts)
+	server := httptest.NewServer(receiver.mux())
+	defer server.Close()
+
+	req, _ := http.NewRequest("POST", server.URL+"/webhook", strings.NewReader(testPayload))
+	req.Header.Set("X-GitHub-Event", "pull_request")
+	req.Header.Set("X-Hub-Signature-256", sign("s3cret", testPayload))
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		t.Fatal(err)
+	}
+	body, _ := io.ReadAll(resp.Body)
+	resp.Body.Close()
+	if strings.TrimSpace(string(body)) != "saved" {
+		t.Errorf("delivery got %q, want saved", body)
+	}
+
+	close(release)
+	receiver.notifying.Wait()
+	if got := <-notified; got != "acme/widgets#12" {
+		t.Errorf("notified about %s, want acme/widgets#12", got)
+	}
+}
+
+func TestCheckWebhookFields(t *testing.T) {
+	if err := checkWebhookFields([]string{"author", "labels", "jira"}); err != nil {
+		t.Errorf("payload fields rejected: %v", err)
+	}
+	for _, field := range []string{"cycleTime", "firstCommit"} {
+		if err := checkWebhookFields([]string{"author", field}); err == nil || !strings.Contains(err.Error(), field) {
+			t.Errorf("checkWebhookFields(%s) = %v, want it rejected", field, err)
+		}
+	}
+}
+
+func TestRunWebhookModeDryRun(t *testing.T) {
+	dir := filepath.Join(t.TempDir(), "out")
+	if err := runWebhookMode(WebhookOptions{Addr: "127.0.0.1:0", List: ListOptions{OutDir: dir, DryRun: true}}); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := os.Stat(dir); !os.IsNotExist(err) {
+		t.Errorf("dry run created the output directory: %v", err)
+	}
+}